@@ -0,0 +1,93 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-graph exports the dependency graph that pulumi-cdk builds for a synthesized cloud assembly and reports cycles.
+//
+// Usage:
+//
+//	go run ./cmd/cdk-graph -assembly cdk.out [-stack teststack] [-format dot|json] [-o graph.dot]
+//
+// The exit code is 1 when any stack contains a dependency cycle.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+	"github.com/pulumi/pulumi-cdk/internal/graph"
+)
+
+func main() {
+	dir := flag.String("assembly", "cdk.out", "path to the cloud assembly directory")
+	stackID := flag.String("stack", "", "only export the stack with this artifact ID")
+	format := flag.String("format", "dot", "output format: dot or json")
+	out := flag.String("o", "", "write the graph to this file instead of stdout")
+	flag.Parse()
+
+	cycles, err := run(*dir, *stackID, *format, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cdk-graph: %v\n", err)
+		os.Exit(2)
+	}
+	if cycles > 0 {
+		os.Exit(1)
+	}
+}
+
+func run(dir, stackID, format, out string) (int, error) {
+	a, err := assembly.Load(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	total := 0
+	for _, s := range a.Stacks {
+		if stackID != "" && s.ID != stackID {
+			continue
+		}
+		g, err := graph.Build(s)
+		if err != nil {
+			return 0, fmt.Errorf("building graph for stack %s: %w", s.ID, err)
+		}
+		switch format {
+		case "dot":
+			err = g.WriteDOT(w)
+		case "json":
+			err = g.WriteJSON(w)
+		default:
+			return 0, fmt.Errorf("unknown format %q", format)
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range g.Cycles() {
+			fmt.Fprintf(os.Stderr, "cycle in stack %s: %s\n", s.ID, c)
+			total++
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-teardown lists the stacks that acceptance tests left behind according to the teardown journal, see
+// internal/teardown, and optionally destroys them.
+//
+// Usage:
+//
+//	go run ./cmd/cdk-teardown [-journal teardown-journal.jsonl] [-destroy]
+//
+// With -destroy, `pulumi destroy` runs for every leftover stack whose project directory and state still exist, and
+// the outcome is appended to the journal. Stacks whose state is gone have to be cleaned up by their resource prefix.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/artifacts"
+	"github.com/pulumi/pulumi-cdk/internal/backend"
+	"github.com/pulumi/pulumi-cdk/internal/teardown"
+)
+
+func main() {
+	journal := flag.String("journal", filepath.Join(artifacts.Root(), "teardown-journal.jsonl"), "teardown journal")
+	destroy := flag.Bool("destroy", false, "destroy the leftover stacks")
+	flag.Parse()
+
+	leftovers, err := run(*journal, *destroy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cdk-teardown: %v\n", err)
+		os.Exit(2)
+	}
+	if leftovers > 0 {
+		os.Exit(1)
+	}
+}
+
+// run returns the number of stacks that are still left behind.
+func run(journal string, destroy bool) (int, error) {
+	entries, err := teardown.Load(journal)
+	if err != nil {
+		return 0, err
+	}
+	left := 0
+	for _, e := range teardown.Leftovers(entries) {
+		fmt.Println(e)
+		if problem := stateProblem(e); problem != "" {
+			fmt.Printf("    cannot destroy: %s\n", problem)
+			left++
+			continue
+		}
+		if !destroy {
+			fmt.Printf("    PULUMI_BACKEND_URL=%s pulumi destroy --yes --skip-preview --stack %s --cwd %s\n",
+				e.Backend, e.Stack, e.ProjectDir)
+			left++
+			continue
+		}
+		next := e
+		next.Time = time.Now().UTC()
+		next.Status, next.Error = teardown.Destroyed, ""
+		if err := destroyStack(e); err != nil {
+			fmt.Printf("    destroy failed: %v\n", err)
+			next.Status, next.Error = teardown.Failed, err.Error()
+			left++
+		} else {
+			fmt.Println("    destroyed")
+		}
+		if err := teardown.Record(journal, next); err != nil {
+			return left, err
+		}
+	}
+	return left, nil
+}
+
+// stateProblem explains why a stack cannot be destroyed from its journal entry, e.g. because its local backend was
+// removed.
+func stateProblem(e teardown.Entry) string {
+	if _, err := os.Stat(e.ProjectDir); err != nil {
+		return fmt.Sprintf("project directory %s is gone", e.ProjectDir)
+	}
+	if u, err := url.Parse(e.Backend); err == nil && u.Scheme == "file" {
+		if _, err := os.Stat(u.Path); err != nil {
+			return fmt.Sprintf("state in %s is gone", e.Backend)
+		}
+	}
+	return ""
+}
+
+func destroyStack(e teardown.Entry) error {
+	cmd := exec.Command("pulumi", "destroy", "--yes", "--skip-preview", "--non-interactive", "--stack", e.Stack)
+	cmd.Dir = e.ProjectDir
+	cmd.Env = os.Environ()
+	if e.Backend != "" {
+		cmd.Env = append(cmd.Env, "PULUMI_BACKEND_URL="+e.Backend)
+	}
+	// Stacks in a local backend of a test run are encrypted with its generated passphrase.
+	if passphrase := backend.PassphraseFileOf(e.Backend); passphrase != "" {
+		cmd.Env = slices.DeleteFunc(cmd.Env, func(kv string) bool {
+			return strings.HasPrefix(kv, "PULUMI_CONFIG_PASSPHRASE=")
+		})
+		cmd.Env = append(cmd.Env, "PULUMI_CONFIG_PASSPHRASE_FILE="+passphrase)
+	}
+	if e.Region != "" {
+		cmd.Env = append(cmd.Env, "AWS_REGION="+e.Region)
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, lastLine(string(out)))
+	}
+	return nil
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return lines[len(lines)-1]
+}
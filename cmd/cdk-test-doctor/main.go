@@ -0,0 +1,55 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-test-doctor checks that this machine can run the acceptance tests in examples/ and integration/, and prints how
+// to fix every problem it finds. The test packages run the same checks from TestMain.
+//
+// Usage:
+//
+//	go run ./cmd/cdk-test-doctor [-root .] [-sts-endpoint http://localhost:4566]
+//
+// The exit code is 1 when a check failed.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pulumi/pulumi-cdk/internal/doctor"
+)
+
+func main() {
+	root := flag.String("root", "", "root of the repository, found from the working directory by default")
+	stsEndpoint := flag.String("sts-endpoint", "", "check the caller identity against this (fake) STS endpoint")
+	flag.Parse()
+
+	if *root == "" {
+		var err error
+		if *root, err = doctor.FindRoot("."); err != nil {
+			fmt.Fprintf(os.Stderr, "cdk-test-doctor: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if *stsEndpoint != "" {
+		os.Setenv(doctor.STSEndpointEnvVar, *stsEndpoint)
+	}
+
+	results := doctor.Run(context.Background(), doctor.DefaultEnv(*root))
+	doctor.Print(os.Stdout, results, false)
+	if doctor.Failed(results) {
+		os.Exit(1)
+	}
+}
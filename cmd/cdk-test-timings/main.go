@@ -0,0 +1,74 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-test-timings updates the timing file CDK_TEST_SHARD weighs tests by with the durations from run reports, see
+// internal/runreport and internal/shard.
+//
+// Usage:
+//
+//	go run ./cmd/cdk-test-timings -timings examples/timings.json examples-report.json...
+//
+// Tests that passed in a report get its duration, summed over their subtests; other tests keep their previous timing.
+// With several reports, later reports win.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/runreport"
+	"github.com/pulumi/pulumi-cdk/internal/shard"
+)
+
+func main() {
+	timingsFile := flag.String("timings", "", "timing file to update")
+	flag.Parse()
+	if *timingsFile == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: cdk-test-timings -timings <timings.json> <report.json>...")
+		os.Exit(2)
+	}
+	if err := run(*timingsFile, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "cdk-test-timings: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(timingsFile string, reports []string) error {
+	timings, err := shard.LoadTimings(timingsFile)
+	if err != nil {
+		return err
+	}
+	updated := 0
+	for _, path := range reports {
+		report, err := runreport.Load(path)
+		if err != nil {
+			return err
+		}
+		passed := map[string]float64{}
+		for _, t := range report.Tests {
+			if t.Outcome == runreport.Pass {
+				top, _, _ := strings.Cut(t.Name, "/")
+				passed[top] += t.Seconds
+			}
+		}
+		for test, seconds := range passed {
+			timings[test] = seconds
+		}
+		updated += len(passed)
+	}
+	fmt.Printf("updated %d timings in %s\n", updated, timingsFile)
+	return timings.Write(timingsFile)
+}
@@ -0,0 +1,127 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-triage classifies the failed tests of an acceptance test run, e.g. as throttling, IAM propagation or assertion
+// failures, and writes JSON and JUnit reports with the category attached to every failure.
+//
+// Usage:
+//
+//	go test -json ./examples | go run ./cmd/cdk-triage [-rules rules.json] [-json report.json] [-junit report.xml]
+//	go run ./cmd/cdk-triage -input gotestsum.json ...
+//
+// A summary is printed to stdout. The exit code is 1 when a failure is classified as a product bug or could not be
+// classified, so CI can tell flaky infrastructure apart from regressions.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pulumi/pulumi-cdk/internal/junit"
+	"github.com/pulumi/pulumi-cdk/internal/triage"
+)
+
+func main() {
+	input := flag.String("input", "", "`go test -json` output to read instead of stdin")
+	rulesFile := flag.String("rules", "", "rules file to use instead of the built-in rules")
+	jsonFile := flag.String("json", "", "write the JSON report to this file")
+	junitFile := flag.String("junit", "", "write the JUnit report to this file")
+	flag.Parse()
+
+	regression, err := run(*input, *rulesFile, *jsonFile, *junitFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cdk-triage: %v\n", err)
+		os.Exit(2)
+	}
+	if regression {
+		os.Exit(1)
+	}
+}
+
+func run(input, rulesFile, jsonFile, junitFile string) (bool, error) {
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	rules, err := triage.DefaultRules()
+	if rulesFile != "" {
+		rules, err = triage.LoadRules(rulesFile)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	runs, err := triage.ParseGoTest(r)
+	if err != nil {
+		return false, err
+	}
+	report := triage.Triage(runs, rules)
+
+	regression := false
+	for _, res := range report.Results {
+		c := res.Classification
+		if c == nil {
+			continue
+		}
+		fmt.Printf("FAIL %s %s: %s (%s)\n", res.Package, res.Test, c.Category, c.Kind)
+		if c.Evidence != "" {
+			fmt.Printf("     %s\n", c.Evidence)
+		}
+		if c.Kind == triage.KindProduct || c.Kind == triage.KindUnknown {
+			regression = true
+		}
+	}
+	categories := make([]string, 0, len(report.Categories))
+	for c := range report.Categories {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+	for _, c := range categories {
+		fmt.Printf("%s: %d\n", c, report.Categories[c])
+	}
+
+	if jsonFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(jsonFile, append(data, '\n'), 0o644); err != nil {
+			return false, err
+		}
+	}
+	if junitFile != "" {
+		f, err := os.Create(junitFile)
+		if err != nil {
+			return false, err
+		}
+		if err := junit.Write(f, "acceptance", report.JUnit()); err != nil {
+			f.Close()
+			return false, err
+		}
+		if err := f.Close(); err != nil {
+			return false, err
+		}
+	}
+	return regression, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-urn-diff compares the URNs of two `pulumi preview --save-plan` runs of the same program, typically one against
+// the published baseline of @pulumi/cdk and one against the local build, and proposes aliases for the changes.
+//
+// Usage:
+//
+//	go run ./cmd/cdk-urn-diff -baseline baseline.plan.json -current current.plan.json [-ack urns.ack.json] [-aliases out.json]
+//
+// The exit code is 1 when URNs changed and the changes are not listed in the acknowledgement file.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pulumi/pulumi-cdk/internal/urns"
+)
+
+func main() {
+	baseline := flag.String("baseline", "", "plan file of the baseline run")
+	current := flag.String("current", "", "plan file of the current run")
+	ackFile := flag.String("ack", "", "acknowledgement file listing expected URN changes")
+	aliasesFile := flag.String("aliases", "", "write the proposed aliases to this file as JSON")
+	writeAck := flag.String("write-ack", "", "with -ack, rewrite the acknowledgement file to cover every change, using this value as the reason")
+	flag.Parse()
+
+	if *baseline == "" || *current == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	unacked, err := run(*baseline, *current, *ackFile, *aliasesFile, *writeAck)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cdk-urn-diff: %v\n", err)
+		os.Exit(2)
+	}
+	if unacked {
+		os.Exit(1)
+	}
+}
+
+func run(baselineFile, currentFile, ackFile, aliasesFile, writeAck string) (bool, error) {
+	before, err := urns.LoadPlan(baselineFile)
+	if err != nil {
+		return false, err
+	}
+	after, err := urns.LoadPlan(currentFile)
+	if err != nil {
+		return false, err
+	}
+
+	d := urns.Compare(before, after)
+	aliases := urns.ProposeAliases(d)
+
+	ack := &urns.Ack{}
+	if ackFile != "" {
+		if ack, err = urns.LoadAck(ackFile); err != nil {
+			return false, err
+		}
+	}
+	unacked := ack.Unacknowledged(d)
+	if err := urns.WriteReport(os.Stdout, d, aliases, unacked); err != nil {
+		return false, err
+	}
+
+	if aliasesFile != "" {
+		if err := writeJSON(aliasesFile, aliases); err != nil {
+			return false, err
+		}
+	}
+	if writeAck != "" && ackFile != "" {
+		if err := writeJSON(ackFile, urns.AckFor(d, writeAck)); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return !unacked.Empty(), nil
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
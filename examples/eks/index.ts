@@ -57,7 +57,7 @@ class EksStack extends pulumicdk.Stack {
                     },
                     hosts: [
                         {
-                            host: `*.${this.region}.elb.amazonaws.com`,
+                            host: `*.${this.region}.elb.${this.urlSuffix}`,
                             paths: [
                                 {
                                     path: '/',
@@ -18,16 +18,27 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"math/rand"
+	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/gorilla/websocket"
+	"github.com/pulumi/pulumi-cdk/internal/fakeaws"
+	"github.com/pulumi/pulumi-cdk/internal/harness"
+	"github.com/pulumi/pulumi-cdk/internal/lookups"
+	"github.com/pulumi/pulumi-cdk/internal/partitions"
+	"github.com/pulumi/pulumi-cdk/internal/regions"
+	"github.com/pulumi/pulumi-cdk/internal/state"
+	"github.com/pulumi/pulumi-cdk/internal/teardown"
+	"github.com/pulumi/pulumi-cdk/internal/throttle"
 	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,125 +47,135 @@ import (
 func TestAppSvc(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "appsvc"),
+			Dir: filepath.Join(harness.Cwd(t), "appsvc"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestAppRunner(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "apprunner"),
+			Dir: filepath.Join(harness.Cwd(t), "apprunner"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 
 }
 
 func TestCronLambda(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "cron-lambda"),
+			Dir: filepath.Join(harness.Cwd(t), "cron-lambda"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestALB(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir:              filepath.Join(getCwd(t), "alb"),
+			Dir:              filepath.Join(harness.Cwd(t), "alb"),
 			RetryFailedSteps: true, // Workaround for https://github.com/pulumi/pulumi-aws-native/issues/1186
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
+// TestFargate also checks that the Docker image of the service is published to the staging repository.
 func TestFargate(t *testing.T) {
-	test := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir:           filepath.Join(getCwd(t), "fargate"),
-			RunUpdateTest: true,
-			// required to run the update test
-			Overrides: map[string]string{
-				"@pulumi/aws": "6.83.2",
-				"@pulumi/cdk": "1.10.0",
-			},
-			RetryFailedSteps:       true, // Workaround for https://github.com/pulumi/pulumi-aws-native/issues/1186
-			Quick:                  false,
-			SkipEmptyPreviewUpdate: false,
-			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
-				integration.AssertHTTPResultWithRetry(t, stack.Outputs["loadBalancerURL"], nil, time.Duration(time.Minute*1), func(s string) bool {
-					return s == "Hello, world!"
-				})
-			},
-		})
+	harness.ForEachRegion(t, regions.Constraint{}, func(t *testing.T, _ string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:           filepath.Join(harness.Cwd(t), "fargate"),
+				RunUpdateTest: true,
+				// required to run the update test
+				Overrides: map[string]string{
+					"@pulumi/aws": "6.83.2",
+					"@pulumi/cdk": "1.10.0",
+				},
+				RetryFailedSteps:       true, // Workaround for https://github.com/pulumi/pulumi-aws-native/issues/1186
+				Quick:                  false,
+				SkipEmptyPreviewUpdate: false,
+				ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+					integration.AssertHTTPResultWithRetry(t, stack.Outputs["loadBalancerURL"], nil, time.Duration(time.Minute*1), func(s string) bool {
+						return s == "Hello, world!"
+					})
+				},
+			})
 
-	integration.ProgramTest(t, &test)
+		test = harness.WithAssetChecks(t, harness.WithStateInvariants(t, test, state.Options{}))
+
+		harness.RunProgramTest(t, &test, false)
+	})
 }
 
 func TestS3ObjectLambda(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir:                  filepath.Join(getCwd(t), "s3-object-lambda"),
+			Dir:                  filepath.Join(harness.Cwd(t), "s3-object-lambda"),
 			ExpectRefreshChanges: false,
 			EditDirs: []integration.EditDir{
 				{
-					Dir:             filepath.Join(getCwd(t), "s3-object-lambda"),
+					Dir:             filepath.Join(harness.Cwd(t), "s3-object-lambda"),
 					ExpectNoChanges: true,
 					Additive:        true,
 				},
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestEC2Instance(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "ec2-instance"),
+			Dir: filepath.Join(harness.Cwd(t), "ec2-instance"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestCloudFront(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "cloudfront-lambda-urls"),
+			Dir: filepath.Join(harness.Cwd(t), "cloudfront-lambda-urls"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestCloudFrontEdge(t *testing.T) {
-	t.Skip("Lambda@Edge resources cannot be cleaned up in CI")
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "cloudfront-lambda-edge"),
+			Dir: filepath.Join(harness.Cwd(t), "cloudfront-lambda-edge"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestLookups(t *testing.T) {
-	test := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "lookups"),
-			Config: map[string]string{
-				"zoneName": "coolcompany.io",
-			},
+	harness.ForEachRegion(t, regions.Constraint{}, func(t *testing.T, _ string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir: filepath.Join(harness.Cwd(t), "lookups"),
+				Config: map[string]string{
+					"zoneName": "coolcompany.io",
+				},
+			})
+		// The program registers these SSM parameters with `{ parent: app }` itself.
+		test = harness.WithStateInvariants(t, test, state.Options{
+			AllowedOutsideConstructs: []string{"instance-param", "image-param"},
 		})
 
-	integration.ProgramTest(t, &test)
+		harness.RunProgramTest(t, &test, false)
+	})
 }
 
 func TestLookupsEnabled(t *testing.T) {
 	ctx := context.Background()
-	config, err := config.LoadDefaultConfig(ctx)
-	assert.NoError(t, err)
+	fake := harness.FakeAWS(t)
+	config := harness.AWSConfig(t, fake)
 	client := sts.NewFromConfig(config)
 	route53Client := route53.NewFromConfig(config)
 	result, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
@@ -173,9 +194,9 @@ func TestLookupsEnabled(t *testing.T) {
 
 	var output bytes.Buffer
 
-	test := getJSBaseOptions(t).
+	test := harness.WithFakeAWS(getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir:         filepath.Join(getCwd(t), "lookups-enabled"),
+			Dir:         filepath.Join(harness.Cwd(t), "lookups-enabled"),
 			Env:         []string{"PULUMI_CDK_EXPERIMENTAL_LOOKUPS=true"},
 			Stderr:      &output,
 			Quick:       false,
@@ -184,12 +205,18 @@ func TestLookupsEnabled(t *testing.T) {
 				"zoneName":  zoneName,
 				"accountId": accountId,
 			},
-		})
+		}), fake)
+	test, td := harness.WithTeardown(t, harness.WithArtifacts(t, harness.WithNodeModules(t, harness.WithStateInvariants(t, test, state.Options{}))))
+	defer td.Stop()
 
 	tester := integration.ProgramTestManualLifeCycle(t, &test)
 
 	defer func() {
-		tester.TestLifeCycleDestroy()
+		if err := tester.TestLifeCycleDestroy(); err != nil {
+			harness.RecordTeardown(t, tester, &test, teardown.Failed, err)
+		} else {
+			harness.RecordTeardown(t, tester, &test, teardown.Destroyed, nil)
+		}
 		tester.TestCleanUp()
 		route53Client.DeleteHostedZone(ctx, &route53.DeleteHostedZoneInput{
 			Id: &zoneId,
@@ -199,28 +226,35 @@ func TestLookupsEnabled(t *testing.T) {
 	assert.NoError(t, err)
 	err = tester.TestLifeCycleInitialize()
 	assert.NoErrorf(t, err, "error initializing")
+	harness.RecordTeardown(t, tester, &test, teardown.Created, nil)
 	err = tester.RunPulumiCommand("preview")
 	assert.Errorf(t, err, "expected error when running initial preview")
 	assert.Contains(t, output.String(), "Duplicate resource URN")
 
+	if fake != nil {
+		// Nothing can be deployed without AWS, so the second pass stops at the preview with the looked up values.
+		err = tester.RunPulumiCommand("preview")
+		assert.NoErrorf(t, err, "Failed to preview with the looked up values: \n output: %s", output.String())
+		assert.Subset(t, fake.Calls(), []string{"ec2:DescribeImages", "route53:ListHostedZonesByName"})
+		return
+	}
 	err = tester.TestPreviewUpdateAndEdits()
 	assert.NoErrorf(t, err, "Failed to preview update and edits: \n output: %s", output.String())
 }
 
 func TestLookupsEnabledFailWithoutPreview(t *testing.T) {
 	ctx := context.Background()
-	config, err := config.LoadDefaultConfig(ctx)
-	assert.NoError(t, err)
-	client := sts.NewFromConfig(config)
+	fake := harness.FakeAWS(t)
+	client := sts.NewFromConfig(harness.AWSConfig(t, fake))
 	result, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	assert.NoError(t, err)
 	accountId := *result.Account
 
 	var output bytes.Buffer
 
-	test := getJSBaseOptions(t).
+	test := harness.WithFakeAWS(getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir:           filepath.Join(getCwd(t), "lookups-enabled"),
+			Dir:           filepath.Join(harness.Cwd(t), "lookups-enabled"),
 			Env:           []string{"PULUMI_CDK_EXPERIMENTAL_LOOKUPS=true"},
 			Stderr:        &output,
 			SkipPreview:   true,
@@ -230,49 +264,220 @@ func TestLookupsEnabledFailWithoutPreview(t *testing.T) {
 				"accountId":       accountId,
 				"pulumiResources": "false",
 			},
-		})
+		}), fake)
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 	assert.Contains(t, output.String(), "Context lookups have been disabled")
 }
 
+// TestLookupConformance drives every context provider of internal/lookups through the lookup flow of
+// adr/cdk-cli-lib.md against the fakes of internal/fakeaws, first with lookups on and then with them off.
+func TestLookupConformance(t *testing.T) {
+	for _, c := range lookups.Cases {
+		t.Run(c.Provider, func(t *testing.T) {
+			fake := fakeaws.Start(lookups.Fixtures)
+			t.Cleanup(fake.Close)
+
+			var looked map[string]any
+			runLookupConformance(t, fake, c, true, func(pt *integration.ProgramTester, lc *lookupConformance) {
+				// `up` never looks up, even with lookups on.
+				err := pt.RunPulumiCommand("up", "--yes", "--skip-preview")
+				assert.Error(t, err, "expected the update without context to fail")
+				assert.Contains(t, lc.output.String(), "Context lookups have been disabled")
+				assert.Equal(t, 1, lc.passes(), "synth passes of the update")
+				assert.Empty(t, lc.lookupCalls(), "lookups of the update")
+				assert.Empty(t, lookups.Providers(lc.context()))
+
+				// The preview looks up the missing context and synthesizes again, which registers the staging
+				// resources a second time.
+				lc.output.Reset()
+				err = pt.RunPulumiCommand("preview")
+				assert.Error(t, err, "expected the preview with lookups to fail")
+				assert.Contains(t, lc.output.String(), "Duplicate resource URN")
+				assert.Equal(t, c.Passes, lc.passes(), "synth passes of the preview with lookups")
+				assert.Subset(t, lc.lookupCalls(), c.Calls)
+				looked = lc.context()
+				assert.Equal(t, c.Providers, lookups.Providers(looked))
+				assertLookup(t, looked, c)
+
+				// From then on the context is in cdk.context.json.
+				err = pt.RunPulumiCommand("preview")
+				assert.NoErrorf(t, err, "previewing with the looked up context: \n output: %s", lc.output.String())
+				assert.Equal(t, 1, lc.passes(), "synth passes of the preview with context")
+				assert.Empty(t, lc.lookupCalls(), "lookups of the preview with context")
+
+				err = pt.TestPreviewUpdateAndEdits()
+				assert.NoErrorf(t, err, "updating with the looked up context: \n output: %s", lc.output.String())
+			})
+			if t.Failed() {
+				return
+			}
+
+			runLookupConformance(t, fake, c, false, func(pt *integration.ProgramTester, lc *lookupConformance) {
+				err := pt.RunPulumiCommand("preview")
+				assert.Error(t, err, "expected the preview without context to fail")
+				assert.Contains(t, lc.output.String(), "Missing context keys: ")
+				assert.Contains(t, lc.output.String(), c.Provider+":")
+				assert.Equal(t, 1, lc.passes(), "synth passes of the preview without lookups")
+				assert.Empty(t, lc.lookupCalls(), "lookups of the preview without lookups")
+				assert.Empty(t, lookups.Providers(lc.context()))
+
+				// With the context of the first run checked in, the program needs no lookups.
+				data, err := json.MarshalIndent(looked, "", "  ")
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(filepath.Join(lc.dir, lookups.ContextFile), data, 0o600))
+				err = pt.RunPulumiCommand("preview")
+				assert.NoErrorf(t, err, "previewing with the checked in context: \n output: %s", lc.output.String())
+				assert.Equal(t, 1, lc.passes(), "synth passes of the preview with context")
+
+				err = pt.TestPreviewUpdateAndEdits()
+				assert.NoErrorf(t, err, "updating with the checked in context: \n output: %s", lc.output.String())
+				assert.Empty(t, lc.lookupCalls(), "lookups of the update with context")
+			})
+		})
+	}
+}
+
+// lookupConformance is what a step of TestLookupConformance inspects.
+type lookupConformance struct {
+	t *testing.T
+	// dir is the project directory, which holds cdk.context.json.
+	dir    string
+	output *bytes.Buffer
+	// synthLog is the file the program logs its synth passes to.
+	synthLog string
+	fake     *fakeaws.Server
+	calls    []string
+	seen     int
+}
+
+// passes returns the synth passes since the last call.
+func (lc *lookupConformance) passes() int {
+	passes, err := lookups.SynthPasses(lc.synthLog)
+	require.NoError(lc.t, err)
+	if err := os.Remove(lc.synthLog); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		require.NoError(lc.t, err)
+	}
+	return passes
+}
+
+// lookupCalls returns the calls of the case's lookups that the fake served since the last call.
+func (lc *lookupConformance) lookupCalls() []string {
+	all := lc.fake.Calls()
+	var calls []string
+	for _, call := range all[lc.seen:] {
+		if slices.Contains(lc.calls, call) {
+			calls = append(calls, call)
+		}
+	}
+	lc.seen = len(all)
+	return calls
+}
+
+func (lc *lookupConformance) context() map[string]any {
+	context, err := lookups.ReadContext(lc.dir)
+	require.NoError(lc.t, err)
+	return context
+}
+
+// runLookupConformance deploys the lookups-conformance program for a case with lookups on or off, runs the steps and
+// destroys the stack.
+func runLookupConformance(t *testing.T, fake *fakeaws.Server, c lookups.Case, on bool,
+	steps func(*integration.ProgramTester, *lookupConformance),
+) {
+	lc := &lookupConformance{
+		t:        t,
+		output:   &bytes.Buffer{},
+		synthLog: filepath.Join(t.TempDir(), "synth.log"),
+		fake:     fake,
+		calls:    c.Calls,
+		seen:     len(fake.Calls()),
+	}
+	env := []string{lookups.SynthLogEnvVar + "=" + lc.synthLog}
+	if on {
+		env = append(env, lookups.EnvVar+"=true")
+	}
+	test := harness.WithFakeAWS(getJSBaseOptions(t).
+		With(integration.ProgramTestOptions{
+			Dir:         filepath.Join(harness.Cwd(t), "lookups-conformance"),
+			Env:         env,
+			Stderr:      lc.output,
+			Quick:       false,
+			SkipPreview: false,
+			Config: map[string]string{
+				"provider": c.Provider,
+				"query":    c.Query,
+			},
+			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+				assert.Equal(t, c.Output, stack.Outputs["value"])
+			},
+		}), fake)
+	test, td := harness.WithTeardown(t, harness.WithArtifacts(t, harness.WithNodeModules(t, harness.WithStateInvariants(t, test, state.Options{}))))
+	defer td.Stop()
+
+	tester := integration.ProgramTestManualLifeCycle(t, &test)
+	defer func() {
+		if err := tester.TestLifeCycleDestroy(); err != nil {
+			harness.RecordTeardown(t, tester, &test, teardown.Failed, err)
+		} else {
+			harness.RecordTeardown(t, tester, &test, teardown.Destroyed, nil)
+		}
+		tester.TestCleanUp()
+	}()
+	require.NoError(t, tester.TestLifeCyclePrepare())
+	require.NoError(t, tester.TestLifeCycleInitialize(), "error initializing")
+	harness.RecordTeardown(t, tester, &test, teardown.Created, nil)
+	lc.dir = filepath.Join(tester.GetTmpDir(), test.RelativeWorkDir)
+	steps(tester, lc)
+}
+
+// assertLookup checks the context that the case's lookup stored.
+func assertLookup(t *testing.T, context map[string]any, c lookups.Case) {
+	key, value, err := lookups.Lookup(context, c.Provider)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Truef(t, lookups.Contains(value, c.Value), "%s is %v, want %v", key, value, c.Value)
+}
+
 func TestEventBridgeSNS(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "eventbridge-sns"),
+			Dir: filepath.Join(harness.Cwd(t), "eventbridge-sns"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestEventBridgeAtm(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "eventbridge-atm"),
+			Dir: filepath.Join(harness.Cwd(t), "eventbridge-atm"),
 		})
 
-	integration.ProgramTest(t, &test)
+	test = harness.WithThrottle(t, test, throttle.APIGateway)
+	harness.ProgramTest(t, &test)
 }
 
 func TestScalableWebhook(t *testing.T) {
-	// TODO: [pulumi/pulumi-cdk#277]
-	t.Skipf("Skipping test due to throttling errors")
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "scalable-webhook"),
+			Dir: filepath.Join(harness.Cwd(t), "scalable-webhook"),
 			// DeleteRestApi has a limit of 1 request per 30 seconds so we frequently
-			// fail on throttling errors
+			// fail on throttling errors; harness.WithThrottle below keeps the tests from
+			// competing with each other, retries cover everything else
 			// see https://docs.aws.amazon.com/apigateway/latest/developerguide/limits.html#api-gateway-control-service-limits-table
 			RetryFailedSteps: true,
 		})
 
-	integration.ProgramTest(t, &test)
+	test = harness.WithThrottle(t, test, throttle.APIGateway)
+	harness.ProgramTest(t, &test)
 }
 
 func TestEks(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "eks"),
+			Dir: filepath.Join(harness.Cwd(t), "eks"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				t.Helper()
 				require.NotEmpty(t, stack.Outputs["albAddress"], "Expected albAddress to be set")
@@ -295,93 +500,104 @@ func TestEks(t *testing.T) {
 	// Deleting stacks with EKS clusters can sometimes fail due to DependencyViolation caused by leftover ENIs.
 	// Try destroying the cluster to keep the test account clean but do not fail the test if it fails to destroy.
 	// This weakens the test but makes CI deterministic.
-	programTestIgnoreDestroyErrors(t, &test)
+	harness.ProgramTestIgnoreDestroyErrors(t, &test)
 }
 
 func TestStackProvider(t *testing.T) {
-	// App will use default provider and one stack will use explicit provider
-	// with region=us-east-1
-	t.Run("With default env", func(t *testing.T) {
-		test := getJSBaseOptions(t).
-			With(integration.ProgramTestOptions{
-				Dir: filepath.Join(getCwd(t), "stack-provider"),
-				ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
-					east1LogsRegion := stack.Outputs["east1LogsRegion"].(string)
-					defaultLogsRegion := stack.Outputs["defaultLogsRegion"].(string)
-					east1StackRegion := stack.Outputs["east1StackRegion"].(string)
-					defaultStackRegion := stack.Outputs["defaultStackRegion"].(string)
-					assert.Equalf(t, "us-east-1", east1LogsRegion, "Expected east1LogsRegion to be us-east-1, got %s", east1LogsRegion)
-					assert.Equalf(t, "us-east-2", defaultLogsRegion, "Expected defaultLogsRegion to be us-east-2, got %s", defaultLogsRegion)
-					assert.Equalf(t, "us-east-1", east1StackRegion, "Expected east1StackRegion to be us-east-1, got %s", east1StackRegion)
-					assert.Equalf(t, "us-east-2", defaultStackRegion, "Expected defaultStackRegion to be us-east-2, got %s", defaultStackRegion)
-				},
-			})
+	// One stack of the program always deploys to us-east-1, which the default env must differ from.
+	harness.ForEachRegion(t, regions.Constraint{
+		Excluded: []string{"us-east-1"},
+		Reason:   "the program deploys a stack to us-east-1 explicitly",
+	}, func(t *testing.T, region string) {
+		// App will use default provider and one stack will use explicit provider
+		// with region=us-east-1
+		t.Run("With default env", func(t *testing.T) {
+			test := getJSBaseOptions(t).
+				With(integration.ProgramTestOptions{
+					Dir: filepath.Join(harness.Cwd(t), "stack-provider"),
+					ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+						assertStackRegions(t, stack, region)
+					},
+				})
 
-		integration.ProgramTest(t, &test)
-	})
+			harness.ProgramTest(t, &test)
+		})
 
-	// App will use a custom explicit provider and one stack will use explicit provider
-	// with region=us-east-1
-	t.Run("With different env", func(t *testing.T) {
-		test := getJSBaseOptions(t).
-			With(integration.ProgramTestOptions{
-				Dir: filepath.Join(getCwd(t), "stack-provider"),
-				Config: map[string]string{
-					"default-region": "us-west-2",
-				},
-				ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
-					east1LogsRegion := stack.Outputs["east1LogsRegion"].(string)
-					defaultLogsRegion := stack.Outputs["defaultLogsRegion"].(string)
-					east1StackRegion := stack.Outputs["east1StackRegion"].(string)
-					defaultStackRegion := stack.Outputs["defaultStackRegion"].(string)
-					assert.Equalf(t, "us-east-1", east1LogsRegion, "Expected east1LogsRegion to be us-east-1, got %s", east1LogsRegion)
-					assert.Equalf(t, "us-west-2", defaultLogsRegion, "Expected defaultLogsRegion to be us-west-2, got %s", defaultLogsRegion)
-					assert.Equalf(t, "us-east-1", east1StackRegion, "Expected east1StackRegion to be us-east-1, got %s", east1StackRegion)
-					assert.Equalf(t, "us-west-2", defaultStackRegion, "Expected defaultStackRegion to be us-west-2, got %s", defaultStackRegion)
-				},
-			})
+		// App will use a custom explicit provider and one stack will use explicit provider
+		// with region=us-east-1
+		t.Run("With different env", func(t *testing.T) {
+			defaultRegion := regions.Other([]string{"us-west-2", "us-east-2"}, region, "us-east-1")
+			test := getJSBaseOptions(t).
+				With(integration.ProgramTestOptions{
+					Dir: filepath.Join(harness.Cwd(t), "stack-provider"),
+					Config: map[string]string{
+						"default-region": defaultRegion,
+					},
+					ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+						assertStackRegions(t, stack, defaultRegion)
+					},
+				})
+
+			harness.ProgramTest(t, &test)
+		})
 
-		integration.ProgramTest(t, &test)
+		t.Run("Fails with different cdk env", func(t *testing.T) {
+			var output bytes.Buffer
+			test := getJSBaseOptions(t).
+				With(integration.ProgramTestOptions{
+					Dir:           filepath.Join(harness.Cwd(t), "stack-provider"),
+					Stderr:        &output,
+					ExpectFailure: true,
+					Config: map[string]string{
+						"cdk-region": region,
+					},
+					ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+						assert.Contains(t, output.String(), fmt.Sprintf("The stack 'teststack' has conflicting regions between the native provider (us-east-1) and the stack environment (%s)", region))
+					},
+				})
+
+			harness.ProgramTest(t, &test)
+		})
 	})
+}
 
-	t.Run("Fails with different cdk env", func(t *testing.T) {
-		var output bytes.Buffer
+// assertStackRegions checks the regions that examples/stack-provider reports for its stacks: the one with an explicit
+// provider is in us-east-1, the other one in defaultRegion.
+func assertStackRegions(t *testing.T, stack integration.RuntimeValidationStackInfo, defaultRegion string) {
+	east1LogsRegion := stack.Outputs["east1LogsRegion"].(string)
+	defaultLogsRegion := stack.Outputs["defaultLogsRegion"].(string)
+	east1StackRegion := stack.Outputs["east1StackRegion"].(string)
+	defaultStackRegion := stack.Outputs["defaultStackRegion"].(string)
+	assert.Equalf(t, "us-east-1", east1LogsRegion, "Expected east1LogsRegion to be us-east-1, got %s", east1LogsRegion)
+	assert.Equalf(t, defaultRegion, defaultLogsRegion, "Expected defaultLogsRegion to be %s, got %s", defaultRegion, defaultLogsRegion)
+	assert.Equalf(t, "us-east-1", east1StackRegion, "Expected east1StackRegion to be us-east-1, got %s", east1StackRegion)
+	assert.Equalf(t, defaultRegion, defaultStackRegion, "Expected defaultStackRegion to be %s, got %s", defaultRegion, defaultStackRegion)
+}
+
+// TestTheBigFan also checks that the API Gateway integration with SNS refers to the partition of the region.
+func TestTheBigFan(t *testing.T) {
+	harness.ForEachRegion(t, regions.Constraint{}, func(t *testing.T, _ string) {
 		test := getJSBaseOptions(t).
 			With(integration.ProgramTestOptions{
-				Dir:           filepath.Join(getCwd(t), "stack-provider"),
-				Stderr:        &output,
-				ExpectFailure: true,
-				Config: map[string]string{
-					"cdk-region": "us-east-2",
-				},
-				ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
-					assert.Contains(t, output.String(), "The stack 'teststack' has conflicting regions between the native provider (us-east-1) and the stack environment (us-east-2)")
+				Dir: filepath.Join(harness.Cwd(t), "the-big-fan"),
+				// required to run the update test
+				Overrides: map[string]string{
+					"@pulumi/aws": "6.83.2",
+					"@pulumi/cdk": "1.10.0",
 				},
+				RunUpdateTest: true,
 			})
 
-		integration.ProgramTest(t, &test)
+		test = harness.WithThrottle(t, test, throttle.APIGateway)
+		test = harness.WithPartitionChecks(harness.WithStateInvariants(t, test, state.Options{}))
+		harness.RunProgramTest(t, &test, false)
 	})
 }
 
-func TestTheBigFan(t *testing.T) {
-	test := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "the-big-fan"),
-			// required to run the update test
-			Overrides: map[string]string{
-				"@pulumi/aws": "6.83.2",
-				"@pulumi/cdk": "1.10.0",
-			},
-			RunUpdateTest: true,
-		})
-
-	integration.ProgramTest(t, &test)
-}
-
 func TestAPIWebsocketLambdaDynamoDB(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "api-websocket-lambda-dynamodb"),
+			Dir: filepath.Join(harness.Cwd(t), "api-websocket-lambda-dynamodb"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				t.Helper()
 				t.Logf("Outputs: %v", stack.Outputs)
@@ -390,13 +606,13 @@ func TestAPIWebsocketLambdaDynamoDB(t *testing.T) {
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestLookupAzs(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "lookup-azs"),
+			Dir: filepath.Join(harness.Cwd(t), "lookup-azs"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				t.Helper()
 				t.Logf("Outputs: %v", stack.Outputs)
@@ -406,22 +622,19 @@ func TestLookupAzs(t *testing.T) {
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func getJSBaseOptions(t *testing.T) integration.ProgramTestOptions {
-	base := getBaseOptions(t)
+	base := harness.BaseOptions(t)
 	baseJS := base.With(integration.ProgramTestOptions{
 		Env: []string{
 			"CDK_DISABLE_CLI_TELEMETRY=true",
 			"CDK_NOTICES=false",
 		},
-		Dependencies: []string{
-			"@pulumi/cdk",
-		},
 	})
 
-	return baseJS
+	return harness.WithCDK(t, baseJS)
 }
 
 // retryFunc retries a function every 3 seconds for up to 1 minute
@@ -471,3 +684,79 @@ func websocketValidation(t *testing.T, url string) {
 
 	defer c.Close()
 }
+
+// previewSkips lists the programs that the preview-only checks cannot run against, with the reason.
+var previewSkips = map[string]string{
+	"lookups-enabled":     "needs a hosted zone and context lookups, see TestLookupsEnabled",
+	"lookups-conformance": "needs context lookups against the fakes, see TestLookupConformance",
+}
+
+// previewConfig is the extra config each program needs for a preview.
+var previewConfig = map[string]map[string]string{
+	"lookups": {"zoneName": "coolcompany.io"},
+}
+
+func TestDependencyGraphs(t *testing.T) {
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:    filepath.Join(harness.Cwd(t), dir),
+				Config: previewConfig[dir],
+			})
+
+		harness.AssertNoDependencyCycles(t, test)
+	})
+}
+
+func TestZipAssetReproducibility(t *testing.T) {
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:    filepath.Join(harness.Cwd(t), dir),
+				Config: previewConfig[dir],
+			})
+
+		harness.AssertReproducibleZips(t, test)
+	})
+}
+
+// partitionSkips lists the programs that TestPartitions cannot preview against the fake, with the reason.
+var partitionSkips = map[string]string{
+	"appsvc":  "looks up availability zones, which the fake has no fixtures for",
+	"lookups": "looks up a hosted zone and AMIs, which the fake has no fixtures for",
+}
+
+// TestPartitions previews the programs in the partitions that partitions.EnvVar selects, against the fake of
+// internal/fakeaws, and checks that they refer to those partitions only.
+func TestPartitions(t *testing.T) {
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		if reason, ok := partitionSkips[dir]; ok {
+			t.Skip(reason)
+		}
+		harness.InEachPartition(t, func(t *testing.T, p partitions.Partition) {
+			test := getJSBaseOptions(t).
+				With(integration.ProgramTestOptions{
+					Dir:    filepath.Join(harness.Cwd(t), dir),
+					Config: previewConfig[dir],
+				})
+
+			harness.AssertPartitionPreview(t, test, p)
+		})
+	})
+}
+
+// TestURNStability checks that upgrading from the published @pulumi/cdk set in PULUMI_CDK_URN_BASELINE to the local
+// build does not change any URN of the programs.
+func TestURNStability(t *testing.T) {
+	baselineVersion := harness.URNBaselineVersion(t)
+
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:    filepath.Join(harness.Cwd(t), dir),
+				Config: previewConfig[dir],
+			})
+
+		harness.AssertStableURNs(t, test, baselineVersion)
+	})
+}
@@ -0,0 +1,66 @@
+import * as fs from 'fs';
+import * as pulumi from '@pulumi/pulumi';
+import * as pulumicdk from '@pulumi/cdk';
+import {
+    aws_ec2 as ec2,
+    aws_elasticloadbalancingv2 as elbv2,
+    aws_kms as kms,
+    aws_route53 as route53,
+    aws_ssm as ssm,
+    CfnOutput,
+} from 'aws-cdk-lib';
+
+// The Go tests in examples/ (TestLookupConformance) pick the context provider and what to look up, see
+// internal/lookups.
+const config = new pulumi.Config();
+const provider = config.require('provider');
+const query = config.get('query') ?? '';
+const prefix = config.get('prefix') ?? pulumi.getStack();
+
+class LookupStack extends pulumicdk.Stack {
+    constructor(app: pulumicdk.App, id: string) {
+        super(app, id, {
+            props: {
+                env: app.env,
+            },
+        });
+
+        new CfnOutput(this, 'value', { value: this.lookup() });
+    }
+
+    // lookup returns a plain string that the looked up context determines.
+    private lookup(): string {
+        switch (provider) {
+            case 'ami':
+                return new ec2.LookupMachineImage({ name: query, owners: ['amazon'] }).getImage(this).imageId;
+            case 'availability-zones':
+                return this.availabilityZones.join(',');
+            case 'hosted-zone':
+                return route53.HostedZone.fromLookup(this, 'zone', { domainName: query }).hostedZoneId;
+            case 'key-provider':
+                return kms.Key.fromLookup(this, 'key', { aliasName: query }).keyId;
+            case 'ssm':
+                return ssm.StringParameter.valueFromLookup(this, query);
+            case 'security-group':
+                return ec2.SecurityGroup.fromLookupById(this, 'sg', query).securityGroupId;
+            case 'vpc-provider':
+                return ec2.Vpc.fromLookup(this, 'vpc', { vpcId: query }).vpcId;
+            case 'load-balancer':
+                return elbv2.ApplicationLoadBalancer.fromLookup(this, 'lb', { loadBalancerArn: query })
+                    .loadBalancerDnsName;
+            default:
+                throw new Error(`unknown context provider ${provider}`);
+        }
+    }
+}
+
+const app = new pulumicdk.App('app', (scope: pulumicdk.App) => {
+    // Every call is a synth pass, which the tests count.
+    const synthLog = process.env.PULUMI_CDK_TEST_SYNTH_LOG;
+    if (synthLog) {
+        fs.appendFileSync(synthLog, 'synth\n');
+    }
+    new LookupStack(scope, `${prefix}-lookups-conformance`);
+});
+
+export const value = app.outputs['value'];
@@ -20,7 +20,7 @@ export class S3ObjectLambdaStack extends pulumicdk.Stack {
     constructor(id: string) {
         super(id);
 
-        const accessPoint = `arn:aws:s3:${cdk.Aws.REGION}:${cdk.Aws.ACCOUNT_ID}:accesspoint/${S3_ACCESS_POINT_NAME}`;
+        const accessPoint = `arn:${cdk.Aws.PARTITION}:s3:${cdk.Aws.REGION}:${cdk.Aws.ACCOUNT_ID}:accesspoint/${S3_ACCESS_POINT_NAME}`;
 
         // Set up a bucket
         const bucket = new s3.Bucket(this, 'example-bucket', {
@@ -137,7 +137,7 @@ class TheBigFanStack extends pulumicdk.Stack {
             new apigw.Integration({
                 type: apigw.IntegrationType.AWS, //native aws integration
                 integrationHttpMethod: 'POST',
-                uri: 'arn:aws:apigateway:us-east-1:sns:path//', // This is how we setup an SNS Topic publish operation.
+                uri: `arn:${cdk.Aws.PARTITION}:apigateway:${cdk.Aws.REGION}:sns:path//`, // This is how we setup an SNS Topic publish operation.
                 options: {
                     credentialsRole: apigwSnsRole,
                     requestParameters: {
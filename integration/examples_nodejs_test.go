@@ -28,116 +28,127 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
+	"github.com/pulumi/pulumi-cdk/internal/harness"
+	"github.com/pulumi/pulumi-cdk/internal/partitions"
+	"github.com/pulumi/pulumi-cdk/internal/regions"
+	"github.com/pulumi/pulumi-cdk/internal/secrets"
+	"github.com/pulumi/pulumi-cdk/internal/state"
+	"github.com/pulumi/pulumi-cdk/internal/throttle"
 	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestApiGateway(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "apigateway"),
+			Dir: filepath.Join(harness.Cwd(t), "apigateway"),
 		})
 
-	integration.ProgramTest(t, &test)
+	test = harness.WithThrottle(t, test, throttle.APIGateway)
+	harness.ProgramTest(t, &test)
 }
 
 func TestApiGatewayDomain(t *testing.T) {
-	// This can be run manually in the dev account
-	t.Skip("This test requires a valid public Route53 domain which doesn't exist in the CI account")
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "apigateway-domain"),
+			Dir: filepath.Join(harness.Cwd(t), "apigateway-domain"),
 		})
 
-	integration.ProgramTest(t, &test)
+	test = harness.WithThrottle(t, test, throttle.APIGateway)
+	harness.ProgramTest(t, &test)
 }
 
 func TestSecretsManager(t *testing.T) {
+	secretValue := fmt.Sprintf("pulumi-cdk-secret-%d", rand.Intn(1000000))
+	scanner, err := secrets.NewScanner(secrets.Secret{Name: "secretValue", Value: secretValue})
+	require.NoError(t, err)
+
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "secretsmanager"),
+			Dir:     filepath.Join(harness.Cwd(t), "secretsmanager"),
+			Secrets: map[string]string{"secretValue": secretValue},
 		})
+	test = harness.WithSecretScan(t, test, scanner)
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestEc2(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "ec2"),
+			Dir: filepath.Join(harness.Cwd(t), "ec2"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestRoute53(t *testing.T) {
-	test := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "route53"),
-			Config: map[string]string{
-				// This test has to be run in us-east-1 for DNSSEC
-				"aws:region":        "us-east-1",
-				"aws-native:region": "us-east-1",
-			},
-		})
-
-	integration.ProgramTest(t, &test)
+	// This test has to be run in us-east-1 for DNSSEC
+	harness.ForEachRegion(t, regions.Constraint{Pinned: "us-east-1"}, func(t *testing.T, _ string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir: filepath.Join(harness.Cwd(t), "route53"),
+			})
+
+		harness.ProgramTest(t, &test)
+	})
 }
 
 func TestKms(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "kms"),
+			Dir: filepath.Join(harness.Cwd(t), "kms"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestLogs(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "logs"),
+			Dir: filepath.Join(harness.Cwd(t), "logs"),
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestMisc(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "misc-services"),
+			Dir: filepath.Join(harness.Cwd(t), "misc-services"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				repoName := stack.Outputs["repoName"].(string)
 				assert.Containsf(t, repoName, "testrepo", "Expected repoName to contain 'testrepo'; got %s", repoName)
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestCloudFront(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "cloudfront"),
+			Dir: filepath.Join(harness.Cwd(t), "cloudfront"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				bucketName := stack.Outputs["bucketName"].(string)
 				assert.Containsf(t, bucketName, "bucket", "Bucket name should contain 'bucket'")
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestErrors(t *testing.T) {
 	var buf bytes.Buffer
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir:           filepath.Join(getCwd(t), "errors-test"),
+			Dir:           filepath.Join(harness.Cwd(t), "errors-test"),
 			Stderr:        &buf,
 			ExpectFailure: true,
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 	assert.Containsf(t, buf.String(), "Error: Event Bus policy statements must have a sid", "Expected error message not found in pulumi up output")
 }
 
@@ -145,31 +156,36 @@ func TestErrors(t *testing.T) {
 // S3 bucket on delete and another for uploading the index.html file for a static website to the bucket.
 // The test validates that the website is deployed, displays the expected content and gets cleaned up on delete.
 func TestCustomResource(t *testing.T) {
-	test := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "custom-resource"),
-			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
-				t.Logf("Outputs: %v", stack.Outputs)
-				url := stack.Outputs["websiteUrl"].(string)
-				assert.NotEmpty(t, url)
-
-				// Validate that the index.html file is deployed
-				integration.AssertHTTPResultWithRetry(t, url, nil, 60*time.Second, func(body string) bool {
-					return assert.Equal(t, "Hello, World!", body, "Body should equal 'Hello, World!', got %s", body)
-				})
+	harness.ForEachRegion(t, regions.Constraint{}, func(t *testing.T, _ string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir: filepath.Join(harness.Cwd(t), "custom-resource"),
+				ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+					t.Logf("Outputs: %v", stack.Outputs)
+					url := stack.Outputs["websiteUrl"].(string)
+					assert.NotEmpty(t, url)
+
+					// Validate that the index.html file is deployed
+					integration.AssertHTTPResultWithRetry(t, url, nil, 60*time.Second, func(body string) bool {
+						return assert.Equal(t, "Hello, World!", body, "Body should equal 'Hello, World!', got %s", body)
+					})
+
+					objectKeys := stack.Outputs["objectKeys"].([]interface{})
+					assert.NotEmpty(t, objectKeys)
+				},
+			})
 
-				objectKeys := stack.Outputs["objectKeys"].([]interface{})
-				assert.NotEmpty(t, objectKeys)
-			},
-		})
+		// The file assets of the website are published to the staging bucket.
+		test = harness.WithAssetChecks(t, harness.WithStateInvariants(t, test, state.Options{}))
 
-	integration.ProgramTest(t, &test)
+		harness.RunProgramTest(t, &test, false)
+	})
 }
 
 func TestNestedStacks(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "nested-stacks"),
+			Dir: filepath.Join(harness.Cwd(t), "nested-stacks"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				t.Logf("Outputs: %v", stack.Outputs)
 				bucketUrl := stack.Outputs["bucketWebsiteUrl"].(string)
@@ -180,120 +196,128 @@ func TestNestedStacks(t *testing.T) {
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestReplaceOnChanges(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "replace-on-changes"),
+			Dir: filepath.Join(harness.Cwd(t), "replace-on-changes"),
 			EditDirs: []integration.EditDir{
 				{
-					Dir:      filepath.Join(getCwd(t), "replace-on-changes/step2"),
+					Dir:      filepath.Join(harness.Cwd(t), "replace-on-changes/step2"),
 					Additive: true,
 				},
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestSsmDynamic(t *testing.T) {
-	test := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "ssm-dynamic"),
-			EditDirs: []integration.EditDir{
-				{
-					Dir:      filepath.Join(getCwd(t), "ssm-dynamic/step2"),
-					Additive: true,
-					ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
-						t.Logf("\nOutputs: %v\n\n", stack.Outputs)
-
-						stringValue := stack.Outputs["stringValue"].(string)
-						assert.Equal(t, "testvalue", stringValue)
-
-						stringListValue := stack.Outputs["stringListValue"].([]interface{})
-						assert.Equal(t, []interface{}{"abcd", "xyz"}, stringListValue)
-
-						dynamicStringValue := stack.Outputs["dynamicStringValue"].(string)
-						assert.Equal(t, "testvalue", dynamicStringValue)
-
-						dyanmicStringListValue := stack.Outputs["dynamicStringListValue"].([]interface{})
-						assert.Equal(t, []interface{}{"abcd", "xyz"}, dyanmicStringListValue)
-
+	secureValue := fmt.Sprintf("pulumi-cdk-secure-%d", rand.Intn(1000000))
+	scanner, err := secrets.NewScanner(secrets.Secret{Name: "secureValue", Value: secureValue})
+	require.NoError(t, err)
+
+	harness.ForEachRegion(t, regions.Constraint{}, func(t *testing.T, _ string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:     filepath.Join(harness.Cwd(t), "ssm-dynamic"),
+				Secrets: map[string]string{"secureValue": secureValue},
+				EditDirs: []integration.EditDir{
+					{
+						Dir:      filepath.Join(harness.Cwd(t), "ssm-dynamic/step2"),
+						Additive: true,
+						ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+							t.Logf("\nOutputs: %v\n\n", stack.Outputs)
+
+							stringValue := stack.Outputs["stringValue"].(string)
+							assert.Equal(t, "testvalue", stringValue)
+
+							stringListValue := stack.Outputs["stringListValue"].([]interface{})
+							assert.Equal(t, []interface{}{"abcd", "xyz"}, stringListValue)
+
+							dynamicStringValue := stack.Outputs["dynamicStringValue"].(string)
+							assert.Equal(t, "testvalue", dynamicStringValue)
+
+							dyanmicStringListValue := stack.Outputs["dynamicStringListValue"].([]interface{})
+							assert.Equal(t, []interface{}{"abcd", "xyz"}, dyanmicStringListValue)
+
+						},
 					},
 				},
-			},
+			})
+		// The program registers the SecureString parameter with `{ parent: app }` itself.
+		test = harness.WithStateInvariants(t, test, state.Options{
+			AllowedOutsideConstructs: []string{"secure-param"},
 		})
+		test = harness.WithSecretScan(t, test, scanner)
 
-	integration.ProgramTest(t, &test)
+		harness.RunProgramTest(t, &test, false)
+	})
 }
 
 func TestRemovalPolicy(t *testing.T) {
 	// Since we are creating two tests we have to set `NoParallel` on each test
 	// and set parallel here.
 	t.Parallel()
-	ctx := context.Background()
-	config, err := config.LoadDefaultConfig(ctx)
-	assert.NoError(t, err)
-	client := s3.NewFromConfig(config)
-
-	suffix := rand.Intn(10000)
-	bucketName := fmt.Sprintf("pulumi-cdk-removal-test-%d", suffix)
-	t.Logf("Bucket name: %s", bucketName)
+	// Both steps and the checks between them run in one region.
+	harness.ForEachRegion(t, regions.Constraint{}, func(t *testing.T, region string) {
+		ctx := context.Background()
+		config, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+		assert.NoError(t, err)
+		client := s3.NewFromConfig(config)
+
+		suffix := rand.Intn(10000)
+		bucketName := fmt.Sprintf("pulumi-cdk-removal-test-%d", suffix)
+		t.Logf("Bucket name: %s", bucketName)
+
+		testConfig := map[string]string{
+			"bucketName": bucketName,
+		}
 
-	testConfig := map[string]string{
-		"bucketName": bucketName,
-	}
+		// ----------------------------------------------------------
+		// Step 1: Create a bucket with a removal policy of 'retain'
+		// ----------------------------------------------------------
+		test1 := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:        filepath.Join(harness.Cwd(t), "removal-policy"),
+				NoParallel: true,
+				Config:     testConfig,
+			})
+
+		harness.ProgramTest(t, &test1)
+
+		// Assert that the bucket still exists
+		exists, err := bucketExists(ctx, client, bucketName)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+
+		// Delete the bucket before Step 2.
+		_, err = client.DeleteBucket(ctx, &s3.DeleteBucketInput{
+			Bucket: &bucketName,
+		})
+		assert.NoError(t, err)
 
-	// ----------------------------------------------------------
-	// Step 1: Create a bucket with a removal policy of 'retain'
-	// ----------------------------------------------------------
-	test1 := getJSBaseOptions(t).
-		With(integration.ProgramTestOptions{
-			Dir:        filepath.Join(getCwd(t), "removal-policy"),
+		// ----------------------------------------------------------
+		// Step 2: Create a new stack with the same bucket name and a removal policy of 'destroy'
+		// ----------------------------------------------------------
+		test2 := getJSBaseOptions(t).With(integration.ProgramTestOptions{
+			Dir:        filepath.Join(harness.Cwd(t), "removal-policy/step2"),
 			NoParallel: true,
 			Config:     testConfig,
 		})
+		harness.ProgramTest(t, &test2)
 
-	integration.ProgramTest(t, &test1)
-
-	// Assert that the bucket still exists
-	exists, err := bucketExists(ctx, client, bucketName)
-	assert.NoError(t, err)
-	assert.True(t, exists)
-
-	// Delete the bucket before Step 2.
-	_, err = client.DeleteBucket(ctx, &s3.DeleteBucketInput{
-		Bucket: &bucketName,
-	})
-	assert.NoError(t, err)
-
-	// ----------------------------------------------------------
-	// Step 2: Create a new stack with the same bucket name and a removal policy of 'destroy'
-	// ----------------------------------------------------------
-	test2 := getJSBaseOptions(t).With(integration.ProgramTestOptions{
-		Dir:        filepath.Join(getCwd(t), "removal-policy/step2"),
-		NoParallel: true,
-		Config:     testConfig,
+		// Assert that the bucket no longer exists
+		exists, err = bucketExists(ctx, client, bucketName)
+		assert.NoError(t, err)
+		assert.False(t, exists)
 	})
-	integration.ProgramTest(t, &test2)
-
-	// Assert that the bucket no longer exists
-	exists, err = bucketExists(ctx, client, bucketName)
-	assert.NoError(t, err)
-	assert.False(t, exists)
 }
 
 func getJSBaseOptions(t *testing.T) integration.ProgramTestOptions {
-	base := getBaseOptions(t)
-	baseJS := base.With(integration.ProgramTestOptions{
-		Dependencies: []string{
-			"@pulumi/cdk",
-		},
-	})
-
-	return baseJS
+	return harness.WithCDK(t, getBaseOptions(t))
 }
 
 func bucketExists(ctx context.Context, client *s3.Client, bucketName string) (bool, error) {
@@ -316,14 +340,14 @@ func bucketExists(ctx context.Context, client *s3.Client, bucketName string) (bo
 func TestKinesis(t *testing.T) {
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir: filepath.Join(getCwd(t), "kinesis"),
+			Dir: filepath.Join(harness.Cwd(t), "kinesis"),
 			ExtraRuntimeValidation: func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
 				kinesisStreamName := stack.Outputs["kinesisStreamName"].(string)
 				assert.Containsf(t, kinesisStreamName, "mystream", "Kinesis stream name should contain 'mystream'")
 			},
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 }
 
 func TestUnsupportedError(t *testing.T) {
@@ -331,12 +355,89 @@ func TestUnsupportedError(t *testing.T) {
 
 	test := getJSBaseOptions(t).
 		With(integration.ProgramTestOptions{
-			Dir:           filepath.Join(getCwd(t), "unsupported-error"),
+			Dir:           filepath.Join(harness.Cwd(t), "unsupported-error"),
 			Stderr:        &output,
 			SkipPreview:   true,
 			ExpectFailure: true,
 		})
 
-	integration.ProgramTest(t, &test)
+	harness.ProgramTest(t, &test)
 	assert.Contains(t, output.String(), "Resource type 'AWS::ServiceCatalog::Portfolio' is not supported by AWS Cloud Control.")
 }
+
+// previewSkips lists the programs that the preview-only checks cannot run against, with the reason.
+var previewSkips = map[string]string{
+	"apigateway-domain": "requires a valid public Route53 domain, see TestApiGatewayDomain",
+	"errors-test":       "fails to synthesize by design",
+	"unsupported-error": "fails to convert by design",
+}
+
+// previewConfig is the extra config each program needs for a preview.
+var previewConfig = map[string]map[string]string{
+	"removal-policy": {"bucketName": fmt.Sprintf("pulumi-cdk-preview-test-%d", rand.Intn(10000))},
+}
+
+func TestDependencyGraphs(t *testing.T) {
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:    filepath.Join(harness.Cwd(t), dir),
+				Config: previewConfig[dir],
+			})
+
+		harness.AssertNoDependencyCycles(t, test)
+	})
+}
+
+func TestZipAssetReproducibility(t *testing.T) {
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:    filepath.Join(harness.Cwd(t), dir),
+				Config: previewConfig[dir],
+			})
+
+		harness.AssertReproducibleZips(t, test)
+	})
+}
+
+// partitionSkips lists the programs that TestPartitions cannot preview against the fake, with the reason.
+var partitionSkips = map[string]string{
+	"ec2":                "looks up an AMI, which the fake has no fixtures for",
+	"replace-on-changes": "looks up the default VPC, which the fake has no fixtures for",
+}
+
+// TestPartitions previews the programs in the partitions that partitions.EnvVar selects, against the fake of
+// internal/fakeaws, and checks that they refer to those partitions only.
+func TestPartitions(t *testing.T) {
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		if reason, ok := partitionSkips[dir]; ok {
+			t.Skip(reason)
+		}
+		harness.InEachPartition(t, func(t *testing.T, p partitions.Partition) {
+			test := getJSBaseOptions(t).
+				With(integration.ProgramTestOptions{
+					Dir:    filepath.Join(harness.Cwd(t), dir),
+					Config: previewConfig[dir],
+				})
+
+			harness.AssertPartitionPreview(t, test, p)
+		})
+	})
+}
+
+// TestURNStability checks that upgrading from the published @pulumi/cdk set in PULUMI_CDK_URN_BASELINE to the local
+// build does not change any URN of the programs.
+func TestURNStability(t *testing.T) {
+	baselineVersion := harness.URNBaselineVersion(t)
+
+	harness.EachProgram(t, previewSkips, func(t *testing.T, dir string) {
+		test := getJSBaseOptions(t).
+			With(integration.ProgramTestOptions{
+				Dir:    filepath.Join(harness.Cwd(t), dir),
+				Config: previewConfig[dir],
+			})
+
+		harness.AssertStableURNs(t, test, baselineVersion)
+	})
+}
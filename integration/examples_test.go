@@ -15,59 +15,26 @@
 package examples
 
 import (
-	"fmt"
-	"math/rand"
 	"os"
-	"strconv"
 	"testing"
 
+	"github.com/pulumi/pulumi-cdk/internal/harness"
 	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
 )
 
-func getPrefix() string {
-	prefix := os.Getenv("GITHUB_SHA")
-	if prefix == "" {
-		prefix = strconv.Itoa(rand.Intn(10000))
-	}
-	if len(prefix) > 5 {
-		prefix = prefix[:5]
-	}
-	// has to start with a letter
-	return fmt.Sprintf("a%s", prefix)
+func TestMain(m *testing.M) {
+	os.Exit(harness.Main(m, "integration"))
 }
 
-func getEnvRegion(t *testing.T) string {
-	envRegion := os.Getenv("AWS_REGION")
-	if envRegion == "" {
-		t.Skipf("Skipping test due to missing AWS_REGION environment variable")
-	}
-
-	return envRegion
-}
-
-func getCwd(t *testing.T) string {
-	cwd, err := os.Getwd()
-	if err != nil {
-		t.FailNow()
-	}
-
-	return cwd
+func TestQuarantine(t *testing.T) {
+	harness.CheckQuarantine(t)
 }
 
 func getBaseOptions(t *testing.T) integration.ProgramTestOptions {
-	envRegion := getEnvRegion(t)
-	prefix := getPrefix()
-	return integration.ProgramTestOptions{
-		Config: map[string]string{
-			"aws:region":        envRegion,
-			"aws-native:region": envRegion,
-			"prefix":            prefix,
-		},
+	return harness.BaseOptions(t).With(integration.ProgramTestOptions{
 		// some flakiness in some resource creation
 		// @see https://github.com/pulumi/pulumi-aws-native/issues/1714
-		RetryFailedSteps:     true,
-		ExpectRefreshChanges: true,
-		SkipRefresh:          true,
-		Quick:                true,
-	}
+		RetryFailedSteps: true,
+		Quick:            true,
+	})
 }
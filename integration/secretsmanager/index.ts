@@ -4,11 +4,12 @@ import * as lambda from 'aws-cdk-lib/aws-lambda';
 import * as iam from 'aws-cdk-lib/aws-iam';
 import * as secrets from 'aws-cdk-lib/aws-secretsmanager';
 import * as pulumicdk from '@pulumi/cdk';
-import { RemovalPolicy } from 'aws-cdk-lib';
+import { RemovalPolicy, SecretValue } from 'aws-cdk-lib';
 import * as pulumi from '@pulumi/pulumi';
 
 const config = new pulumi.Config();
 const prefix = config.get('prefix') ?? pulumi.getStack();
+const secretValue = config.getSecret('secretValue');
 class SecretsManagerStack extends pulumicdk.Stack {
     constructor(app: pulumicdk.App, id: string, options?: pulumicdk.StackOptions) {
         super(app, id, options);
@@ -50,6 +51,14 @@ class SecretsManagerStack extends pulumicdk.Stack {
         secret.addRotationSchedule('rotation', {
             rotationLambda,
         });
+
+        if (secretValue) {
+            // The test scans the state and the logs for plaintext copies of this value.
+            new secrets.Secret(this, 'ConfigSecret', {
+                description: 'A secret set from Pulumi config',
+                secretStringValue: SecretValue.unsafePlainText(pulumicdk.asString(secretValue)),
+            });
+        }
     }
 }
 
@@ -1,9 +1,11 @@
 import * as pulumi from '@pulumi/pulumi';
+import * as aws from '@pulumi/aws';
 import * as ssm from 'aws-cdk-lib/aws-ssm';
 import * as pulumicdk from '@pulumi/cdk';
 
 const config = new pulumi.Config();
 const prefix = config.get('prefix') ?? pulumi.getStack();
+const secureValue = config.getSecret('secureValue');
 class SsmDynamicStack extends pulumicdk.Stack {
     public readonly stringValue: pulumi.Output<string>;
     public readonly stringListValue: pulumi.Output<string[]>;
@@ -21,6 +23,16 @@ class SsmDynamicStack extends pulumicdk.Stack {
             stringListValue: ['abcd', 'xyz'],
         });
         this.stringListValue = this.asOutput(listParam.stringListValue);
+
+        if (secureValue) {
+            // CloudFormation cannot create SecureString parameters, so the program creates the one that step2 reads
+            // back with an ssm-secure dynamic reference. The test scans the state and the logs for its value.
+            new aws.ssm.Parameter(
+                'secure-param',
+                { name: `${prefix}-secure-param`, type: 'SecureString', value: secureValue },
+                { parent: app },
+            );
+        }
     }
 }
 
@@ -1,10 +1,13 @@
 import * as pulumi from '@pulumi/pulumi';
+import * as aws from '@pulumi/aws';
 import * as ssm from 'aws-cdk-lib/aws-ssm';
+import * as secretsmanager from 'aws-cdk-lib/aws-secretsmanager';
 import * as pulumicdk from '@pulumi/cdk';
-import { CfnDynamicReference, CfnDynamicReferenceService } from 'aws-cdk-lib';
+import { CfnDynamicReference, CfnDynamicReferenceService, SecretValue } from 'aws-cdk-lib';
 
 const config = new pulumi.Config();
 const prefix = config.get('prefix') ?? pulumi.getStack();
+const secureValue = config.getSecret('secureValue');
 class SsmDynamicStack extends pulumicdk.Stack {
     public readonly stringValue: pulumi.Output<string>;
     public readonly stringListValue: pulumi.Output<string[]>;
@@ -25,6 +28,16 @@ class SsmDynamicStack extends pulumicdk.Stack {
         });
         this.stringListValue = this.asOutput(listParam.stringListValue);
 
+        if (secureValue) {
+            // The SecureString parameter stays as in the first step; secureCopy below reads it back with an
+            // ssm-secure dynamic reference.
+            new aws.ssm.Parameter(
+                'secure-param',
+                { name: `${prefix}-secure-param`, type: 'SecureString', value: secureValue },
+                { parent: app },
+            );
+        }
+
         const stringValue = new CfnDynamicReference(CfnDynamicReferenceService.SSM, `${prefix}-param`).toString();
         const stringDynamicParam = new ssm.StringParameter(this, 'stringDynamicParam', {
             stringValue: stringValue,
@@ -39,6 +52,12 @@ class SsmDynamicStack extends pulumicdk.Stack {
             stringValue: stringListValue,
         });
         this.dynamicStringListValue = this.asOutput(stringListDynamicParam.stringValue).apply((v) => v.split(','));
+
+        if (secureValue) {
+            new secretsmanager.Secret(this, 'secureCopy', {
+                secretStringValue: SecretValue.ssmSecure(`${prefix}-secure-param`),
+            });
+        }
     }
 }
 
@@ -0,0 +1,187 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifacts keeps what an acceptance test leaves behind in a per-test directory: the output of every command
+// the test ran, with account IDs, ARNs and credentials redacted, so that CI failures can be diagnosed after the fact.
+package artifacts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RootEnvVar overrides the directory that per-test artifact directories are created in.
+const RootEnvVar = "PULUMI_CDK_TEST_ARTIFACTS"
+
+// Root returns the directory that per-test artifact directories are created in.
+func Root() string {
+	if root := os.Getenv(RootEnvVar); root != "" {
+		return root
+	}
+	return filepath.Join(os.TempDir(), "pulumi-cdk-test-artifacts")
+}
+
+const pointerPrefix = "artifacts of failed test are in "
+
+// Pointer returns the line a failed test logs to point at its artifact directory.
+func Pointer(dir string) string {
+	return pointerPrefix + dir
+}
+
+// ParsePointer returns the artifact directory from a line of test output written with Pointer.
+func ParsePointer(line string) (string, bool) {
+	i := strings.Index(line, pointerPrefix)
+	if i < 0 {
+		return "", false
+	}
+	dir := strings.TrimSpace(line[i+len(pointerPrefix):])
+	return dir, dir != ""
+}
+
+var (
+	unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._/-]+`)
+
+	cleanedMu sync.Mutex
+	cleaned   = map[string]bool{}
+)
+
+// Dir returns the artifact directory of the named test under root. Subtests get a subdirectory of their parent's.
+// Whatever a previous run left in the directory is removed the first time it is requested in this process, so that
+// a test which asks for it more than once keeps everything from the current run.
+func Dir(root, testName string) (string, error) {
+	var parts []string
+	for _, p := range strings.Split(unsafeChars.ReplaceAllString(testName, "_"), "/") {
+		if p != "" && p != "." && p != ".." {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("invalid test name %q", testName)
+	}
+	dir := filepath.Join(append([]string{root}, parts...)...)
+
+	cleanedMu.Lock()
+	defer cleanedMu.Unlock()
+	if !cleaned[dir] {
+		if err := os.RemoveAll(dir); err != nil {
+			return "", err
+		}
+		cleaned[dir] = true
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Capture writes command output to redacted log files in an artifact directory. Output of a command that is
+// bracketed by Begin and End goes to its own numbered log, e.g. `03-preview.log`; everything else, such as package
+// installs, goes to `commands.log`.
+type Capture struct {
+	dir string
+
+	mu      sync.Mutex
+	seq     int
+	current *logFile
+	other   *logFile
+}
+
+type logFile struct {
+	f *os.File
+	w *RedactingWriter
+}
+
+func openLog(path string) (*logFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &logFile{f: f, w: NewRedactingWriter(f)}, nil
+}
+
+func (l *logFile) close() error {
+	return errors.Join(l.w.Flush(), l.f.Close())
+}
+
+// NewCapture returns a capture into dir. Numbering continues after the logs already in dir.
+func NewCapture(dir string) (*Capture, error) {
+	existing, err := filepath.Glob(filepath.Join(dir, "[0-9][0-9]-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	return &Capture{dir: dir, seq: len(existing)}, nil
+}
+
+// Begin sends output to the log of the command named verb until End is called.
+func (c *Capture) Begin(verb string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current != nil {
+		return fmt.Errorf("command output is already being captured")
+	}
+	c.seq++
+	name := fmt.Sprintf("%02d-%s.log", c.seq, unsafeChars.ReplaceAllString(strings.ReplaceAll(verb, "/", "_"), "_"))
+	l, err := openLog(filepath.Join(c.dir, name))
+	if err != nil {
+		return err
+	}
+	c.current = l
+	return nil
+}
+
+// End closes the log of the current command.
+func (c *Capture) End() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.current == nil {
+		return nil
+	}
+	err := c.current.close()
+	c.current = nil
+	return err
+}
+
+func (c *Capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := c.current
+	if l == nil {
+		if c.other == nil {
+			other, err := openLog(filepath.Join(c.dir, "commands.log"))
+			if err != nil {
+				return 0, err
+			}
+			c.other = other
+		}
+		l = c.other
+	}
+	return l.w.Write(p)
+}
+
+// Close closes all open logs.
+func (c *Capture) Close() error {
+	err := c.End()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.other != nil {
+		err = errors.Join(err, c.other.close())
+		c.other = nil
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacts
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+)
+
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// Keep the partition and service of ARNs so that logs stay useful for triage.
+	{regexp.MustCompile(`\barn:(aws[a-z-]*):([a-z0-9-]+):[A-Za-z0-9_/:.*+=,@-]*`), "arn:$1:$2:<redacted>"},
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`), "<redacted access key>"},
+	{
+		regexp.MustCompile(`(?i)((?:aws_secret_access_key|aws_session_token|secretAccessKey|sessionToken)["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{16,}`),
+		"$1<redacted>",
+	},
+	{regexp.MustCompile(`\b\d{12}\b`), "<account>"},
+}
+
+// Redact replaces AWS account IDs, ARNs, access key IDs, secret access keys and session tokens in text.
+func Redact(text []byte) []byte {
+	for _, r := range redactions {
+		text = r.pattern.ReplaceAll(text, []byte(r.replacement))
+	}
+	return text
+}
+
+// RedactingWriter redacts everything written to it line by line before passing it on, so that a value split across
+// two writes is still caught. It is safe for concurrent use, e.g. as both stdout and stderr of a command.
+type RedactingWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	pending []byte
+}
+
+// NewRedactingWriter returns a writer that redacts into w.
+func NewRedactingWriter(w io.Writer) *RedactingWriter {
+	return &RedactingWriter{w: w}
+}
+
+func (r *RedactingWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, p...)
+	i := bytes.LastIndexByte(r.pending, '\n')
+	if i < 0 {
+		return len(p), nil
+	}
+	if _, err := r.w.Write(Redact(r.pending[:i+1])); err != nil {
+		return 0, err
+	}
+	r.pending = append(r.pending[:0], r.pending[i+1:]...)
+	return len(p), nil
+}
+
+// Flush writes a trailing partial line.
+func (r *RedactingWriter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return nil
+	}
+	_, err := r.w.Write(Redact(r.pending))
+	r.pending = r.pending[:0]
+	return err
+}
@@ -0,0 +1,356 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assembly reads a synthesized CDK cloud assembly (a `cdk.out` directory). It mirrors the loading logic of
+// `AssemblyManifestReader` in src/assembly/manifest.ts.
+package assembly
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/cfn"
+)
+
+const (
+	artifactTypeStack         = "aws:cloudformation:stack"
+	artifactTypeAssetManifest = "cdk:asset-manifest"
+	metadataTypeLogicalID     = "aws:cdk:logicalId"
+)
+
+// Address uniquely identifies a CloudFormation resource across a stack and its nested stacks.
+type Address struct {
+	StackPath string `json:"stackPath"`
+	ID        string `json:"id"`
+}
+
+func (a Address) String() string {
+	return a.StackPath + "/" + a.ID
+}
+
+// ConstructInfo is the JSII type information attached to a construct tree node.
+type ConstructInfo struct {
+	Fqn     string `json:"fqn"`
+	Version string `json:"version"`
+}
+
+// TreeNode is a node of the construct tree stored in `tree.json`.
+type TreeNode struct {
+	ID            string               `json:"id"`
+	Path          string               `json:"path"`
+	Children      map[string]*TreeNode `json:"children,omitempty"`
+	Attributes    map[string]any       `json:"attributes,omitempty"`
+	ConstructInfo *ConstructInfo       `json:"constructInfo,omitempty"`
+}
+
+// CfnType returns the `aws:cdk:cloudformation:type` attribute of the node, if any.
+func (n *TreeNode) CfnType() string {
+	t, _ := n.Attributes["aws:cdk:cloudformation:type"].(string)
+	return t
+}
+
+// Fqn returns the JSII fully qualified name of the construct, if any.
+func (n *TreeNode) Fqn() string {
+	if n.ConstructInfo == nil {
+		return ""
+	}
+	return n.ConstructInfo.Fqn
+}
+
+// SortedChildren returns the children of the node ordered by ID.
+func (n *TreeNode) SortedChildren() []*TreeNode {
+	ids := make([]string, 0, len(n.Children))
+	for id := range n.Children {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make([]*TreeNode, len(ids))
+	for i, id := range ids {
+		out[i] = n.Children[id]
+	}
+	return out
+}
+
+// NestedStack is a nested stack template together with the logical ID of its AWS::CloudFormation::Stack resource in
+// the parent template.
+type NestedStack struct {
+	LogicalID    string
+	TemplateFile string
+	Template     *cfn.Template
+}
+
+// Stack is a top-level CloudFormation stack artifact and everything needed to convert it.
+type Stack struct {
+	ID           string
+	Environment  string
+	TemplateFile string
+	Template     *cfn.Template
+	Tree         *TreeNode
+	Dependencies []string
+	// NestedStacks are indexed by their construct path, e.g. `teststack/nesty`.
+	NestedStacks map[string]*NestedStack
+	// Metadata maps a construct path to the address of the resource it produced.
+	Metadata map[string]Address
+}
+
+// Assembly is a loaded cloud assembly.
+type Assembly struct {
+	Directory string
+	Version   string
+	Tree      *TreeNode
+	Stacks    []*Stack
+	// AssetManifests lists the asset manifest files of the assembly, relative to Directory.
+	AssetManifests []string
+}
+
+type manifest struct {
+	Version   string              `json:"version"`
+	Artifacts map[string]artifact `json:"artifacts"`
+}
+
+type artifact struct {
+	Type         string                     `json:"type"`
+	Environment  string                     `json:"environment"`
+	Properties   map[string]any             `json:"properties"`
+	Dependencies []string                   `json:"dependencies"`
+	Metadata     map[string][]metadataEntry `json:"metadata"`
+}
+
+type metadataEntry struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// Load reads the cloud assembly in dir.
+func Load(dir string) (*Assembly, error) {
+	var m manifest
+	if err := readJSON(filepath.Join(dir, "manifest.json"), &m); err != nil {
+		return nil, fmt.Errorf("Cannot read manifest at '%s': %w", dir, err)
+	}
+	var tree struct {
+		Tree *TreeNode `json:"tree"`
+	}
+	if err := readJSON(filepath.Join(dir, "tree.json"), &tree); err != nil {
+		return nil, err
+	}
+	if tree.Tree == nil || tree.Tree.Children == nil {
+		return nil, fmt.Errorf("Invalid tree.json found in %s", dir)
+	}
+
+	a := &Assembly{Directory: dir, Version: m.Version, Tree: tree.Tree}
+	for _, id := range sortedKeys(m.Artifacts) {
+		art := m.Artifacts[id]
+		switch art.Type {
+		case artifactTypeAssetManifest:
+			if f, ok := art.Properties["file"].(string); ok {
+				a.AssetManifests = append(a.AssetManifests, f)
+			}
+		case artifactTypeStack:
+			s, err := a.loadStack(id, art)
+			if err != nil {
+				return nil, err
+			}
+			a.Stacks = append(a.Stacks, s)
+		}
+	}
+	return a, nil
+}
+
+// Stack returns the stack with the given artifact ID, or nil.
+func (a *Assembly) Stack(id string) *Stack {
+	for _, s := range a.Stacks {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (a *Assembly) loadStack(id string, art artifact) (*Stack, error) {
+	templateFile, ok := art.Properties["templateFile"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Invalid CloudFormation artifact. Cannot find the template file")
+	}
+	template, err := cfn.LoadTemplate(filepath.Join(a.Directory, templateFile))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CloudFormation template at path: %s: %w", templateFile, err)
+	}
+	stackTree, ok := a.Tree.Children[id]
+	if !ok {
+		return nil, fmt.Errorf("stack %s not found in tree.json", id)
+	}
+
+	s := &Stack{
+		ID:           id,
+		Environment:  art.Environment,
+		TemplateFile: templateFile,
+		Template:     template,
+		Tree:         stackTree,
+		Dependencies: art.Dependencies,
+		NestedStacks: map[string]*NestedStack{},
+	}
+	if err := a.loadNestedStacks(template, s.NestedStacks); err != nil {
+		return nil, err
+	}
+
+	stackPaths := []string{stackTree.Path}
+	for p := range s.NestedStacks {
+		stackPaths = append(stackPaths, p)
+	}
+	if s.Metadata, err = buildMetadata(art, stackPaths); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadNestedStacks recursively loads the nested stack templates referenced through `aws:asset:path` metadata.
+func (a *Assembly) loadNestedStacks(t *cfn.Template, out map[string]*NestedStack) error {
+	for _, logicalID := range sortedKeys(t.Resources) {
+		r := t.Resources[logicalID]
+		if r.Type != "AWS::CloudFormation::Stack" {
+			continue
+		}
+		assetPath, ok := r.Metadata["aws:asset:path"].(string)
+		if !ok {
+			continue
+		}
+		cdkPath := r.ConstructPath()
+		if cdkPath == "" {
+			return fmt.Errorf("Expected the nested stack %s to have a 'aws:cdk:path' metadata entry", logicalID)
+		}
+		nested, err := cfn.LoadTemplate(filepath.Join(a.Directory, assetPath))
+		if err != nil {
+			return fmt.Errorf("Failed to read CloudFormation template at path: %s: %w", assetPath, err)
+		}
+		stackPath, err := NestedStackPath(cdkPath, logicalID)
+		if err != nil {
+			return err
+		}
+		out[stackPath] = &NestedStack{LogicalID: logicalID, TemplateFile: assetPath, Template: nested}
+		if err := a.loadNestedStacks(nested, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NestedStackPath computes the construct path of a nested stack from the path of its AWS::CloudFormation::Stack
+// resource, e.g. `teststack/nesty.NestedStack/nesty.NestedStackResource` becomes `teststack/nesty`.
+func NestedStackPath(resourcePath, logicalID string) (string, error) {
+	parts := strings.Split(resourcePath, "/")
+	if len(parts) < 3 {
+		return "", fmt.Errorf("Failed to detect the nested stack path for %s. The path is too short %s, expected at least 3 parts",
+			logicalID, resourcePath)
+	}
+	p := strings.Join(parts[:len(parts)-1], "/")
+	if !strings.HasSuffix(p, ".NestedStack") {
+		return "", fmt.Errorf("Failed to detect the nested stack path for %s. The path does not end with '.NestedStack': %s",
+			logicalID, p)
+	}
+	return strings.TrimSuffix(p, ".NestedStack"), nil
+}
+
+// buildMetadata maps construct paths to resource addresses, attributing each resource to the longest stack path that
+// prefixes it.
+func buildMetadata(art artifact, stackPaths []string) (map[string]Address, error) {
+	md := map[string]Address{}
+	for metadataID, entries := range art.Metadata {
+		for _, e := range entries {
+			if e.Type != metadataTypeLogicalID {
+				continue
+			}
+			path := strings.TrimPrefix(metadataID, "/")
+
+			stackPath, found := "", false
+			for _, sp := range stackPaths {
+				if strings.HasPrefix(path, sp+"/") && len(sp) >= len(stackPath) {
+					stackPath, found = sp, true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Failed to determine the stack path for resource at path %s", path)
+			}
+
+			var logicalID string
+			switch d := e.Data.(type) {
+			case string:
+				logicalID = d
+			case map[string]any:
+				logicalID, _ = d["logicalId"].(string)
+			}
+			if logicalID == "" {
+				return nil, fmt.Errorf("Failed to determine logical id for resource at path %s", path)
+			}
+			md[path] = Address{StackPath: stackPath, ID: logicalID}
+		}
+	}
+	return md, nil
+}
+
+// Templates returns the templates of the stack and all its nested stacks, keyed by stack path.
+func (s *Stack) Templates() map[string]*cfn.Template {
+	out := map[string]*cfn.Template{s.Tree.Path: s.Template}
+	for p, n := range s.NestedStacks {
+		out[p] = n.Template
+	}
+	return out
+}
+
+// IsRootStack reports whether the stack path is the top-level stack rather than a nested one.
+func (s *Stack) IsRootStack(stackPath string) bool {
+	return stackPath == s.Tree.Path
+}
+
+// ResourceAddressForPath returns the address of the resource created by the construct at path.
+func (s *Stack) ResourceAddressForPath(path string) (Address, error) {
+	a, ok := s.Metadata[path]
+	if !ok {
+		return Address{}, fmt.Errorf("Could not find resource address for path %s", path)
+	}
+	return a, nil
+}
+
+// Resource returns the template resource at the given address.
+func (s *Stack) Resource(a Address) (cfn.Resource, bool) {
+	t, ok := s.Templates()[a.StackPath]
+	if !ok {
+		return cfn.Resource{}, false
+	}
+	r, ok := t.Resources[a.ID]
+	return r, ok
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
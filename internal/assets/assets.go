@@ -0,0 +1,185 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assets checks what PulumiSynthesizer (src/synthesizer.ts) publishes for the assets of a cloud assembly. It
+// uploads every file asset to the staging bucket of the app, under the object key of the asset manifest, and pushes
+// every Docker image asset to a staging repository of the app, tagged with the source hash of the asset. Verify
+// compares the asset manifests with what the bucket and the registry hold, so that an asset that is missing, has
+// other content, or was published without being in any manifest shows up as a named problem.
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+)
+
+// Packaging is how a file asset is uploaded.
+type Packaging string
+
+const (
+	// PackagingFile uploads the file as is.
+	PackagingFile Packaging = "file"
+	// PackagingZip uploads the zip of a directory, which the synthesizer writes next to it as <path>.zip.
+	PackagingZip Packaging = "zip"
+)
+
+// File is a file asset that the synthesizer uploads to the staging bucket.
+type File struct {
+	// ID is the ID of the asset in the manifest, its source hash.
+	ID string `json:"id"`
+	// Path is the source of the asset, relative to the assembly directory.
+	Path      string    `json:"path"`
+	Packaging Packaging `json:"packaging"`
+	// Key is the object key in the staging bucket.
+	Key string `json:"key"`
+	// Retained marks an asset of an earlier assembly, whose source has since been overwritten.
+	Retained bool `json:"retained,omitempty"`
+}
+
+// Image is a Docker image asset that the synthesizer pushes to a staging repository.
+type Image struct {
+	// ID is the ID of the asset in the manifest.
+	ID string `json:"id"`
+	// Directory is the build context of the image, relative to the assembly directory.
+	Directory string `json:"directory"`
+	// Repository is the name that the synthesizer registers the repository under, `<appId>/<assetName>`. The
+	// repository itself is auto-named after it.
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
+
+// Expected is what the asset manifests of one or more assemblies expect to be published.
+type Expected struct {
+	// Dir is the assembly directory that Path and Directory are relative to.
+	Dir    string  `json:"dir"`
+	Files  []File  `json:"files"`
+	Images []Image `json:"images"`
+}
+
+// manifest is the asset manifest format of the cloud assembly schema, with the parts that the synthesizer writes.
+type manifest struct {
+	Files map[string]struct {
+		Source struct {
+			Path      string    `json:"path"`
+			Packaging Packaging `json:"packaging"`
+		} `json:"source"`
+		Destinations map[string]struct {
+			ObjectKey string `json:"objectKey"`
+		} `json:"destinations"`
+	} `json:"files"`
+	DockerImages map[string]struct {
+		Source struct {
+			Directory string `json:"directory"`
+		} `json:"source"`
+		Destinations map[string]struct {
+			RepositoryName string `json:"repositoryName"`
+			ImageTag       string `json:"imageTag"`
+		} `json:"destinations"`
+	} `json:"dockerImages"`
+}
+
+// Load reads the asset manifests of the cloud assembly in dir. The templates of the top-level stacks are left out,
+// since the synthesizer only lists them in the manifest and never uploads them; nested stack templates are uploaded
+// like any other file.
+func Load(dir string) (*Expected, error) {
+	a, err := assembly.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	templates := map[string]bool{}
+	for _, s := range a.Stacks {
+		templates[s.TemplateFile] = true
+	}
+
+	e := &Expected{Dir: dir}
+	for _, path := range a.AssetManifests {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for id, f := range m.Files {
+			if templates[f.Source.Path] {
+				continue
+			}
+			packaging := f.Source.Packaging
+			if packaging == "" {
+				packaging = PackagingFile
+			}
+			for _, d := range f.Destinations {
+				e.addFile(File{ID: id, Path: f.Source.Path, Packaging: packaging, Key: d.ObjectKey})
+			}
+		}
+		for id, img := range m.DockerImages {
+			for _, d := range img.Destinations {
+				e.addImage(Image{ID: id, Directory: img.Source.Directory, Repository: d.RepositoryName,
+					Tag: d.ImageTag})
+			}
+		}
+	}
+	e.sort()
+	return e, nil
+}
+
+// Merge adds the assets of a later assembly, e.g. of the next edit of a test. The synthesizer retains what it
+// published before, so those assets stay expected, as retained ones.
+func (e *Expected) Merge(later *Expected) {
+	for i := range e.Files {
+		e.Files[i].Retained = true
+	}
+	for _, f := range later.Files {
+		if i := slices.IndexFunc(e.Files, func(g File) bool { return g.Key == f.Key }); i >= 0 {
+			e.Files[i] = f
+		} else {
+			e.Files = append(e.Files, f)
+		}
+	}
+	for _, img := range later.Images {
+		e.addImage(img)
+	}
+	e.Dir = later.Dir
+	e.sort()
+}
+
+// addFile adds f unless an asset with the same key is there already; several stacks share assets.
+func (e *Expected) addFile(f File) {
+	if !slices.ContainsFunc(e.Files, func(g File) bool { return g.Key == f.Key }) {
+		e.Files = append(e.Files, f)
+	}
+}
+
+func (e *Expected) addImage(img Image) {
+	if !slices.ContainsFunc(e.Images, func(i Image) bool { return i.Repository == img.Repository && i.Tag == img.Tag }) {
+		e.Images = append(e.Images, img)
+	}
+}
+
+func (e *Expected) sort() {
+	sort.Slice(e.Files, func(i, j int) bool { return e.Files[i].Key < e.Files[j].Key })
+	sort.Slice(e.Images, func(i, j int) bool {
+		if e.Images[i].Repository != e.Images[j].Repository {
+			return e.Images[i].Repository < e.Images[j].Repository
+		}
+		return e.Images[i].Tag < e.Images[j].Tag
+	})
+}
@@ -0,0 +1,296 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pulumi/pulumi-cdk/internal/fakeaws"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nestedStackAssembly = "../../tests/test-data/nested-stack"
+
+func TestLoad(t *testing.T) {
+	e, err := Load(nestedStackAssembly)
+	require.NoError(t, err)
+	keys := make([]string, len(e.Files))
+	for i, f := range e.Files {
+		keys[i] = f.Key
+	}
+	// The template of teststack is not uploaded, the nested one is.
+	assert.Equal(t, []string{
+		"95f78991f9fb0b754d5d23113980217d0636f22d034b8f8bb451154a21d811a6.json",
+		"a386ba9b8c0d9b386083b2f6952db278a5a0ce88f497484eb5e62172219468fd.zip",
+		"deploy-time/2d56e153cac88d3e0c2f842e8e6f6783b8725bf91f95e0673b4725448a56e96d.zip",
+		"deploy-time/6620cb784ea0d3cf3a6e3e128827087f88e7e9999cd41b0be7c50431fbf12026.zip",
+		"faa95a81ae7d7373f3e1f242268f904eb748d8d0fdd306e8a6fe515a1905a7d6.zip",
+	}, keys)
+	assert.Equal(t, File{
+		ID:        "6620cb784ea0d3cf3a6e3e128827087f88e7e9999cd41b0be7c50431fbf12026",
+		Path:      "asset.6620cb784ea0d3cf3a6e3e128827087f88e7e9999cd41b0be7c50431fbf12026.zip",
+		Packaging: PackagingFile,
+		Key:       "deploy-time/6620cb784ea0d3cf3a6e3e128827087f88e7e9999cd41b0be7c50431fbf12026.zip",
+	}, e.Files[3])
+	assert.Equal(t, PackagingZip, e.Files[4].Packaging)
+	assert.Empty(t, e.Images)
+}
+
+// writeAssembly writes a cloud assembly with one stack whose asset manifest has a file asset, a zip asset, the
+// stack template and an image asset, and returns its directory.
+func writeAssembly(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"manifest.json": `{"version": "36.0.0", "artifacts": {
+			"stack.assets": {"type": "cdk:asset-manifest", "properties": {"file": "stack.assets.json"}},
+			"stack": {"type": "aws:cloudformation:stack", "properties": {"templateFile": "stack.template.json"}}}}`,
+		"tree.json":           `{"tree": {"id": "App", "path": "", "children": {"stack": {"id": "stack", "path": "stack"}}}}`,
+		"stack.template.json": `{"Resources": {}}`,
+		"stack.assets.json": `{"version": "36.0.0",
+			"files": {
+				"aaa": {"source": {"path": "asset.aaa.json", "packaging": "file"},
+					"destinations": {"d": {"bucketName": "b", "objectKey": "aaa.json"}}},
+				"bbb": {"source": {"path": "asset.bbb", "packaging": "zip"},
+					"destinations": {"d": {"bucketName": "b", "objectKey": "deploy-time/bbb.zip"}}},
+				"tpl": {"source": {"path": "stack.template.json", "packaging": "file"},
+					"destinations": {"d": {"bucketName": "b", "objectKey": "deploy-time/tpl.json"}}}
+			},
+			"dockerImages": {
+				"ccc": {"source": {"directory": "asset.ccc"},
+					"destinations": {"d": {"repositoryName": "app/image", "imageTag": "ccc"}}}
+			}}`,
+		"asset.aaa.json":       `{"hello": "world"}`,
+		"asset.bbb/index.js":   `exports.handler = () => {}`,
+		"asset.bbb.zip":        "zipped",
+		"asset.ccc/Dockerfile": "FROM scratch",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestMerge(t *testing.T) {
+	e := &Expected{Files: []File{{Key: "a"}, {Key: "b"}}, Images: []Image{{Repository: "r", Tag: "1"}}}
+	e.Merge(&Expected{Dir: "later", Files: []File{{Key: "b", Path: "asset.b"}, {Key: "c"}},
+		Images: []Image{{Repository: "r", Tag: "1"}, {Repository: "r", Tag: "2"}}})
+	assert.Equal(t, &Expected{
+		Dir:    "later",
+		Files:  []File{{Key: "a", Retained: true}, {Key: "b", Path: "asset.b"}, {Key: "c"}},
+		Images: []Image{{Repository: "r", Tag: "1"}, {Repository: "r", Tag: "2"}},
+	}, e)
+}
+
+func md5Hex(s string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+}
+
+func TestVerify(t *testing.T) {
+	dir := writeAssembly(t)
+	e, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, e.Files, 2)
+	require.Len(t, e.Images, 1)
+	ctx := context.Background()
+
+	published := map[string]string{
+		"aaa.json":            `{"hello": "world"}`,
+		"deploy-time/bbb.zip": "zipped",
+		// Written by custom resource handlers, not an asset.
+		"deploy-time/pulumi/custom-resources/stack/Custom/response": "{}",
+	}
+	verify := func(t *testing.T, e *Expected, objects map[string]string, tags []string) []string {
+		fake := fakeaws.Start(fakeaws.Fixtures{Buckets: []fakeaws.Bucket{{Name: "staging", Objects: objects}}})
+		defer fake.Close()
+		registry := StartRegistry()
+		defer registry.Close()
+		for _, tag := range tags {
+			registry.Push("image-1234", tag, []byte(`{"schemaVersion": 2, "tag": "`+tag+`"}`))
+		}
+		client := s3.NewFromConfig(fake.Config("us-east-2"), func(o *s3.Options) { o.UsePathStyle = true })
+		problems, err := Verify(ctx, e, Target{
+			Bucket:       S3Bucket{Client: client, Name: "staging"},
+			Registry:     OCIRegistry{URL: registry.URL},
+			Repositories: map[string]string{"app/image": "image-1234"},
+		})
+		require.NoError(t, err)
+		var out []string
+		for _, p := range problems {
+			out = append(out, p.String())
+		}
+		return out
+	}
+	t.Run("published", func(t *testing.T) {
+		assert.Empty(t, verify(t, e, published, []string{"ccc"}))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"deploy-time/bbb.zip: [missing] asset bbb from asset.bbb is not in the staging bucket",
+			"app/image:ccc: [missing] repository image-1234 has no image tagged ccc",
+		}, verify(t, e, map[string]string{"aaa.json": published["aaa.json"]}, nil))
+	})
+
+	t.Run("unexpected", func(t *testing.T) {
+		objects := map[string]string{"deploy-time/tpl.json": "{}"}
+		for k, v := range published {
+			objects[k] = v
+		}
+		assert.Equal(t, []string{
+			"deploy-time/tpl.json: [unexpected] no asset manifest lists the object",
+			"app/image:old: [unexpected] no asset manifest lists the image in repository image-1234",
+		}, verify(t, e, objects, []string{"ccc", "old"}))
+	})
+
+	t.Run("content", func(t *testing.T) {
+		objects := map[string]string{"deploy-time/bbb.zip": "other"}
+		objects["aaa.json"] = published["aaa.json"]
+		assert.Equal(t, []string{
+			fmt.Sprintf("deploy-time/bbb.zip: [content] the object has MD5 %s but asset.bbb.zip has %s",
+				md5Hex("other"), md5Hex("zipped")),
+		}, verify(t, e, objects, []string{"ccc"}))
+
+		// The asset of an earlier assembly may have been overwritten since.
+		retained := *e
+		retained.Files = append([]File(nil), e.Files...)
+		retained.Files[1].Retained = true
+		assert.Empty(t, verify(t, &retained, objects, []string{"ccc"}))
+	})
+
+	t.Run("no staging", func(t *testing.T) {
+		problems, err := Verify(ctx, e, Target{})
+		require.NoError(t, err)
+		require.Len(t, problems, 3)
+		assert.Equal(t, KindMissing, problems[0].Kind)
+		assert.Equal(t, "the app has no staging repository app/image", problems[2].Message)
+	})
+}
+
+func TestECRRegistry(t *testing.T) {
+	fake := fakeaws.Start(fakeaws.Fixtures{Repositories: []fakeaws.Repository{{Name: "app/image", Tags: []string{"a", "b"}}}})
+	defer fake.Close()
+	registry := ECRRegistry{Config: fake.Config("us-east-2")}
+	ctx := context.Background()
+
+	tags, err := registry.Tags(ctx, "app/image")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, tags)
+	tags, err = registry.Tags(ctx, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	assert.Equal(t, []string{"ecr:ListImages", "ecr:ListImages"}, fake.Calls())
+
+	assert.Equal(t, "https://api.ecr.cn-north-1.amazonaws.com.cn", ECRRegistry{}.withRegion("cn-north-1").endpoint())
+	assert.Equal(t, "https://api.ecr.eu-west-1.amazonaws.com", ECRRegistry{}.withRegion("eu-west-1").endpoint())
+}
+
+func (r ECRRegistry) withRegion(region string) ECRRegistry {
+	r.Config.Region = region
+	return r
+}
+
+func TestLocalRegistry(t *testing.T) {
+	registry := StartRegistry()
+	defer registry.Close()
+	for _, tag := range []string{"c", "a", "b"} {
+		registry.Push("app/image", tag, []byte(tag))
+	}
+
+	resp, err := http.Get(registry.URL + "/v2/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "registry/2.0", resp.Header.Get("Docker-Distribution-API-Version"))
+
+	req, err := http.NewRequest(http.MethodPut, registry.URL+"/v2/app/image/manifests/d", strings.NewReader("d"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	digest := resp.Header.Get("Docker-Content-Digest")
+	assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("d"))), digest)
+
+	resp, err = http.Head(registry.URL + "/v2/app/image/manifests/d")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, digest, resp.Header.Get("Docker-Content-Digest"))
+	assert.Equal(t, "application/vnd.docker.distribution.manifest.v2+json", resp.Header.Get("Content-Type"))
+
+	resp, err = http.Head(registry.URL + "/v2/app/image/manifests/missing")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Paginated through the Link header.
+	tags, err := OCIRegistry{URL: registry.URL, PageSize: 3}.Tags(context.Background(), "app/image")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, tags)
+	tags, err = OCIRegistry{URL: registry.URL}.Tags(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestStaging(t *testing.T) {
+	const (
+		stack = "urn:pulumi:dev::app::cdk:index:App$cdk:construct:StagingStack::staging-stack"
+		child = "urn:pulumi:dev::app::cdk:index:App$cdk:construct:StagingStack$"
+	)
+	deployment := &apitype.DeploymentV3{Resources: []apitype.ResourceV3{
+		{URN: resource.URN(stack), Type: "cdk:construct:StagingStack"},
+		{
+			URN:     resource.URN(child + "aws:s3/bucket:Bucket::pulumi-cdk-app-staging"),
+			Type:    "aws:s3/bucket:Bucket",
+			Parent:  resource.URN(stack),
+			Outputs: map[string]any{"bucket": "pulumi-cdk-app-staging-1234"},
+		},
+		{
+			URN:     resource.URN(child + "aws:ecr/repository:Repository::app/image"),
+			Type:    "aws:ecr/repository:Repository",
+			Parent:  resource.URN(stack),
+			Outputs: map[string]any{"name": "app/image-5678"},
+		},
+		{
+			// A bucket of the program itself.
+			URN:     "urn:pulumi:dev::app::cdk:index:App$cdk:construct:Stack$aws:s3/bucket:Bucket::data",
+			Type:    "aws:s3/bucket:Bucket",
+			Parent:  "urn:pulumi:dev::app::cdk:index:App$cdk:construct:Stack::stack",
+			Outputs: map[string]any{"bucket": "data-1234"},
+		},
+	}}
+	bucket, repositories := Staging(deployment)
+	assert.Equal(t, "pulumi-cdk-app-staging-1234", bucket)
+	assert.Equal(t, map[string]string{"app/image": "app/image-5678"}, repositories)
+
+	bucket, repositories = Staging(nil)
+	assert.Empty(t, bucket)
+	assert.Empty(t, repositories)
+}
@@ -0,0 +1,249 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Bucket lists a bucket with ListObjectsV2.
+type S3Bucket struct {
+	Client *s3.Client
+	Name   string
+}
+
+// Objects implements Bucket.
+func (b S3Bucket) Objects(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	pages := s3.NewListObjectsV2Paginator(b.Client, &s3.ListObjectsV2Input{Bucket: aws.String(b.Name)})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Contents {
+			objects = append(objects, Object{
+				Key:  aws.ToString(o.Key),
+				ETag: aws.ToString(o.ETag),
+				Size: aws.ToInt64(o.Size),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// ECRRegistry lists the tags of ECR repositories with ListImages. The module has no ECR client, so the calls are
+// signed here; the endpoint is the BaseEndpoint of the config, if it has one.
+type ECRRegistry struct {
+	Config aws.Config
+}
+
+const ecrTarget = "AmazonEC2ContainerRegistry_V20150921."
+
+// Tags implements Registry.
+func (r ECRRegistry) Tags(ctx context.Context, repository string) ([]string, error) {
+	var tags []string
+	token := ""
+	for {
+		var page struct {
+			ImageIDs []struct {
+				ImageTag string `json:"imageTag"`
+			} `json:"imageIds"`
+			NextToken string `json:"nextToken"`
+		}
+		err := r.call(ctx, "ListImages", map[string]any{
+			"repositoryName": repository,
+			"filter":         map[string]string{"tagStatus": "TAGGED"},
+			"nextToken":      token,
+		}, &page)
+		var apiErr *ecrError
+		if errors.As(err, &apiErr) && apiErr.Type == "RepositoryNotFoundException" {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range page.ImageIDs {
+			tags = append(tags, id.ImageTag)
+		}
+		if page.NextToken == "" {
+			return tags, nil
+		}
+		token = page.NextToken
+	}
+}
+
+// ecrError is an error of the ECR JSON protocol.
+type ecrError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (e *ecrError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (r ECRRegistry) call(ctx context.Context, operation string, input map[string]any, output any) error {
+	if input["nextToken"] == "" {
+		delete(input, "nextToken")
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", ecrTarget+operation)
+	creds, err := r.Config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "ecr", r.Config.Region,
+		time.Now()); err != nil {
+		return err
+	}
+
+	client := r.Config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &ecrError{}
+		if err := json.Unmarshal(data, apiErr); err != nil || apiErr.Type == "" {
+			return fmt.Errorf("ecr:%s: %s: %s", operation, resp.Status, data)
+		}
+		// The type may be qualified with a namespace, e.g. com.amazonaws.ecr#RepositoryNotFoundException.
+		apiErr.Type = apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+		return apiErr
+	}
+	return json.Unmarshal(data, output)
+}
+
+func (r ECRRegistry) endpoint() string {
+	if r.Config.BaseEndpoint != nil {
+		return *r.Config.BaseEndpoint
+	}
+	suffix := "amazonaws.com"
+	if strings.HasPrefix(r.Config.Region, "cn-") {
+		suffix = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://api.ecr.%s.%s", r.Config.Region, suffix)
+}
+
+// OCIRegistry lists the tags of repositories with the tag listing of the OCI distribution API, e.g. of the local
+// registry that stands in for ECR in tests.
+type OCIRegistry struct {
+	// URL is the base URL of the registry, without /v2/.
+	URL string
+	// Username and Password are sent as basic authentication if set.
+	Username, Password string
+	// PageSize is the number of tags to list per request; the registry decides if it is 0.
+	PageSize int
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Tags implements Registry.
+func (r OCIRegistry) Tags(ctx context.Context, repository string) ([]string, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	next, err := url.Parse(strings.TrimSuffix(r.URL, "/") + "/v2/" + repository + "/tags/list")
+	if err != nil {
+		return nil, err
+	}
+	if r.PageSize > 0 {
+		next.RawQuery = url.Values{"n": {strconv.Itoa(r.PageSize)}}.Encode()
+	}
+	var tags []string
+	for next != nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if r.Username != "" || r.Password != "" {
+			req.SetBasicAuth(r.Username, r.Password)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("listing the tags of %s: %s", repository, resp.Status)
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+		if next, err = nextLink(next, resp.Header.Get("Link")); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// nextLink resolves the URL of the next page from a Link header like `</v2/app/tags/list?last=b&n=2>; rel="next"`.
+func nextLink(base *url.URL, header string) (*url.URL, error) {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		ref, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return nil, err
+		}
+		return base.ResolveReference(ref), nil
+	}
+	return nil, nil
+}
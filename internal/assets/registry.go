@@ -0,0 +1,199 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LocalRegistry is an in-process stand-in for a registry that serves the parts of the OCI distribution API that
+// pushing and verifying images needs: the version check at /v2/, pushing, checking and pulling manifests by tag or
+// digest, and listing tags with pagination. Blobs are not stored and not required.
+type LocalRegistry struct {
+	// URL is the base URL of the registry.
+	URL string
+
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	repositories map[string]*localRepository
+}
+
+type localRepository struct {
+	manifests map[string]localManifest // by digest
+	tags      map[string]string        // tag to digest
+}
+
+type localManifest struct {
+	mediaType string
+	content   []byte
+}
+
+// StartRegistry starts an empty registry. Close stops it.
+func StartRegistry() *LocalRegistry {
+	r := &LocalRegistry{repositories: map[string]*localRepository{}}
+	r.srv = httptest.NewServer(r)
+	r.URL = r.srv.URL
+	return r
+}
+
+// Close stops the registry.
+func (r *LocalRegistry) Close() {
+	r.srv.Close()
+}
+
+// Push stores the manifest under the tag, as `docker push` would, and returns its digest.
+func (r *LocalRegistry) Push(repository, tag string, manifest []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.put(repository, tag, "application/vnd.oci.image.manifest.v1+json", manifest)
+}
+
+func (r *LocalRegistry) put(repository, reference, mediaType string, content []byte) string {
+	repo, ok := r.repositories[repository]
+	if !ok {
+		repo = &localRepository{manifests: map[string]localManifest{}, tags: map[string]string{}}
+		r.repositories[repository] = repo
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+	repo.manifests[digest] = localManifest{mediaType: mediaType, content: content}
+	if reference != digest {
+		repo.tags[reference] = digest
+	}
+	return digest
+}
+
+// registryError is an error of the distribution API.
+type registryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeRegistryError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string][]registryError{"errors": {{Code: code, Message: message}}})
+}
+
+func (r *LocalRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/")
+	switch {
+	case !ok:
+		writeRegistryError(w, http.StatusNotFound, "UNSUPPORTED", "not a distribution API path")
+	case path == "":
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		_, _ = w.Write([]byte("{}"))
+	case strings.HasSuffix(path, "/tags/list") && req.Method == http.MethodGet:
+		r.serveTags(w, req, strings.TrimSuffix(path, "/tags/list"))
+	case strings.Contains(path, "/manifests/"):
+		i := strings.LastIndex(path, "/manifests/")
+		r.serveManifest(w, req, path[:i], path[i+len("/manifests/"):])
+	default:
+		writeRegistryError(w, http.StatusNotFound, "UNSUPPORTED", "the local registry does not implement "+path)
+	}
+}
+
+func (r *LocalRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch req.Method {
+	case http.MethodPut:
+		content, err := io.ReadAll(req.Body)
+		if err != nil {
+			writeRegistryError(w, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+			return
+		}
+		digest := r.put(repository, reference, req.Header.Get("Content-Type"), content)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Location", "/v2/"+repository+"/manifests/"+digest)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		repo, ok := r.repositories[repository]
+		if !ok {
+			writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name not known to registry")
+			return
+		}
+		digest := reference
+		if d, ok := repo.tags[reference]; ok {
+			digest = d
+		}
+		m, ok := repo.manifests[digest]
+		if !ok {
+			writeRegistryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown")
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.content)))
+		w.Header().Set("Docker-Content-Digest", digest)
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(m.content)
+		}
+	default:
+		writeRegistryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", req.Method+" is not supported")
+	}
+}
+
+// serveTags lists the tags in lexical order, n at a time after the tag given as last, with a Link to the next page.
+func (r *LocalRegistry) serveTags(w http.ResponseWriter, req *http.Request, repository string) {
+	r.mu.Lock()
+	repo, ok := r.repositories[repository]
+	var tags []string
+	if ok {
+		for tag := range repo.tags {
+			tags = append(tags, tag)
+		}
+	}
+	r.mu.Unlock()
+	if !ok {
+		writeRegistryError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name not known to registry")
+		return
+	}
+	slices.Sort(tags)
+
+	q := req.URL.Query()
+	if last := q.Get("last"); last != "" {
+		i, _ := slices.BinarySearch(tags, last)
+		for i < len(tags) && tags[i] <= last {
+			i++
+		}
+		tags = tags[i:]
+	}
+	if v := q.Get("n"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeRegistryError(w, http.StatusBadRequest, "PAGINATION_NUMBER_INVALID", "invalid n "+v)
+			return
+		}
+		if n == 0 {
+			tags = nil
+		} else if n < len(tags) {
+			tags = tags[:n]
+			next := url.Values{"n": {v}, "last": {tags[len(tags)-1]}}
+			w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?%s>; rel="next"`, repository, next.Encode()))
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"name": repository, "tags": append([]string{}, tags...)})
+}
@@ -0,0 +1,270 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+const (
+	stagingStackType = "cdk:construct:StagingStack"
+	bucketType       = "aws:s3/bucket:Bucket"
+	bucketV2Type     = "aws:s3/bucketV2:BucketV2"
+	repositoryType   = "aws:ecr/repository:Repository"
+
+	// customResourcePrefix is where the custom resource emulation of src/custom-resource-mapping.ts has the handlers
+	// of custom resources write their responses to. Those objects are not assets.
+	customResourcePrefix = "deploy-time/pulumi/custom-resources/"
+)
+
+// Object is an object in the staging bucket.
+type Object struct {
+	Key string
+	// ETag is the entity tag as S3 lists it, quoted. For objects uploaded in one part and encrypted with SSE-S3, which
+	// the staging bucket defaults to, it is the MD5 of the content.
+	ETag string
+	Size int64
+}
+
+// Bucket lists the objects of a staging bucket.
+type Bucket interface {
+	Objects(ctx context.Context) ([]Object, error)
+}
+
+// Registry lists the image tags of a repository. A repository that does not exist has no tags.
+type Registry interface {
+	Tags(ctx context.Context, repository string) ([]string, error)
+}
+
+// Target is where the assets of an app were published.
+type Target struct {
+	// Bucket is the staging bucket, or nil if the app has none.
+	Bucket Bucket
+	// Registry holds the staging repositories, and may be nil if the app has no image assets.
+	Registry Registry
+	// Repositories maps the names that the synthesizer registers the staging repositories under to the names of the
+	// repositories in the registry, see Staging.
+	Repositories map[string]string
+}
+
+// Kind names what is wrong with an asset.
+type Kind string
+
+const (
+	// KindMissing is an asset of a manifest that was not published.
+	KindMissing Kind = "missing"
+	// KindUnexpected is an object or an image tag that no manifest lists.
+	KindUnexpected Kind = "unexpected"
+	// KindContent is a file asset whose object has other content than the file in the assembly.
+	KindContent Kind = "content"
+)
+
+// Problem is an asset that was not published as its manifest says.
+type Problem struct {
+	// Asset is the object key of a file asset, or `<repository>:<tag>` for an image asset.
+	Asset   string `json:"asset"`
+	Kind    Kind   `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: [%s] %s", p.Asset, p.Kind, p.Message)
+}
+
+// Verify compares the expected assets with what the target holds.
+func Verify(ctx context.Context, e *Expected, t Target) ([]Problem, error) {
+	var problems []Problem
+	files, err := verifyFiles(ctx, e, t.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, files...)
+	images, err := verifyImages(ctx, e, t)
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, images...), nil
+}
+
+func verifyFiles(ctx context.Context, e *Expected, bucket Bucket) ([]Problem, error) {
+	var problems []Problem
+	if bucket == nil {
+		for _, f := range e.Files {
+			problems = append(problems, Problem{Asset: f.Key, Kind: KindMissing, Message: "the app has no staging bucket"})
+		}
+		return problems, nil
+	}
+	objects, err := bucket.Objects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing the staging bucket: %w", err)
+	}
+	byKey := map[string]Object{}
+	for _, o := range objects {
+		byKey[o.Key] = o
+	}
+
+	expected := map[string]bool{}
+	for _, f := range e.Files {
+		expected[f.Key] = true
+		o, ok := byKey[f.Key]
+		if !ok {
+			problems = append(problems, Problem{Asset: f.Key, Kind: KindMissing,
+				Message: fmt.Sprintf("asset %s from %s is not in the staging bucket", f.ID, f.Path)})
+			continue
+		}
+		if f.Retained {
+			continue
+		}
+		if p, err := compareContent(e.Dir, f, o); err != nil {
+			return nil, err
+		} else if p != nil {
+			problems = append(problems, *p)
+		}
+	}
+	for _, o := range objects {
+		if !expected[o.Key] && !strings.HasPrefix(o.Key, customResourcePrefix) {
+			problems = append(problems, Problem{Asset: o.Key, Kind: KindUnexpected,
+				Message: "no asset manifest lists the object"})
+		}
+	}
+	return problems, nil
+}
+
+// compareContent compares the MD5 of the file that the synthesizer uploaded with the ETag of the object. It cannot
+// when the file is gone or the ETag is not an MD5, e.g. for multipart uploads.
+func compareContent(dir string, f File, o Object) (*Problem, error) {
+	etag := strings.Trim(o.ETag, `"`)
+	if etag == "" || strings.Contains(etag, "-") {
+		return nil, nil
+	}
+	path := filepath.Join(dir, f.Path)
+	if f.Packaging == PackagingZip {
+		path += ".zip"
+	}
+	sum, err := md5File(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sum == etag {
+		return nil, nil
+	}
+	return &Problem{Asset: f.Key, Kind: KindContent,
+		Message: fmt.Sprintf("the object has MD5 %s but %s has %s", etag, filepath.Base(path), sum)}, nil
+}
+
+func md5File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func verifyImages(ctx context.Context, e *Expected, t Target) ([]Problem, error) {
+	var problems []Problem
+	byRepository := map[string][]string{}
+	for _, img := range e.Images {
+		byRepository[img.Repository] = append(byRepository[img.Repository], img.Tag)
+	}
+	for _, name := range sortedKeys(byRepository) {
+		want := byRepository[name]
+		repository, ok := t.Repositories[name]
+		if !ok || t.Registry == nil {
+			for _, tag := range want {
+				problems = append(problems, Problem{Asset: name + ":" + tag, Kind: KindMissing,
+					Message: fmt.Sprintf("the app has no staging repository %s", name)})
+			}
+			continue
+		}
+		tags, err := t.Registry.Tags(ctx, repository)
+		if err != nil {
+			return nil, fmt.Errorf("listing the tags of %s: %w", repository, err)
+		}
+		for _, tag := range want {
+			if !slices.Contains(tags, tag) {
+				problems = append(problems, Problem{Asset: name + ":" + tag, Kind: KindMissing,
+					Message: fmt.Sprintf("repository %s has no image tagged %s", repository, tag)})
+			}
+		}
+		for _, tag := range tags {
+			if !slices.Contains(want, tag) {
+				problems = append(problems, Problem{Asset: name + ":" + tag, Kind: KindUnexpected,
+					Message: fmt.Sprintf("no asset manifest lists the image in repository %s", repository)})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// Staging finds the staging bucket and the staging repositories of the app in the deployment. It returns the name of
+// the bucket, empty if there is none, and the names of the repositories by the names the synthesizer registers them
+// under.
+func Staging(deployment *apitype.DeploymentV3) (bucket string, repositories map[string]string) {
+	repositories = map[string]string{}
+	if deployment == nil {
+		return "", repositories
+	}
+	staging := map[resource.URN]bool{}
+	for _, r := range deployment.Resources {
+		if r.Type == stagingStackType {
+			staging[r.URN] = true
+		}
+	}
+	for _, r := range deployment.Resources {
+		if !staging[r.Parent] {
+			continue
+		}
+		switch r.Type {
+		case bucketType, bucketV2Type:
+			if name, ok := r.Outputs["bucket"].(string); ok {
+				bucket = name
+			}
+		case repositoryType:
+			if name, ok := r.Outputs["name"].(string); ok {
+				repositories[r.URN.Name()] = name
+			}
+		}
+	}
+	return bucket, repositories
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
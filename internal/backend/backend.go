@@ -0,0 +1,98 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backend gives a test run its own Pulumi backend: a file:// backend in a temporary directory, with a
+// passphrase secrets provider whose passphrase is generated for the run. Tests need no Pulumi Cloud account, and
+// parallel runs never share state.
+package backend
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// AmbientEnvVar keeps the backend and secrets provider the environment configures, e.g. the Pulumi Cloud with
+// PULUMI_TEST_USE_SERVICE, instead of a local backend per run.
+const AmbientEnvVar = "PULUMI_CDK_TEST_AMBIENT_BACKEND"
+
+// Ambient reports whether AmbientEnvVar is set.
+func Ambient() bool {
+	return os.Getenv(AmbientEnvVar) != ""
+}
+
+// SecretsProvider is the secrets provider of stacks in a local backend.
+const SecretsProvider = "passphrase"
+
+// passphraseFile is the name of the file in the backend directory that holds the passphrase. The Pulumi CLI keeps
+// state under .pulumi/ and ignores it.
+const passphraseFile = "passphrase"
+
+// Local is a file:// backend.
+type Local struct {
+	// Dir holds the state and the passphrase.
+	Dir string
+	// URL is the backend URL, for `pulumi login` or PULUMI_BACKEND_URL.
+	URL string
+}
+
+// NewLocal creates a backend in a new directory in parent, the default temporary directory if it is empty, and
+// generates its passphrase.
+func NewLocal(parent string) (*Local, error) {
+	dir, err := os.MkdirTemp(parent, "pulumi-cdk-test-backend-")
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Join(err, os.RemoveAll(dir))
+	}
+	if err := os.WriteFile(filepath.Join(dir, passphraseFile), []byte(hex.EncodeToString(secret)), 0o600); err != nil {
+		return nil, errors.Join(err, os.RemoveAll(dir))
+	}
+	return &Local{Dir: dir, URL: "file://" + filepath.ToSlash(dir)}, nil
+}
+
+// PassphraseFile is the file that holds the passphrase, for PULUMI_CONFIG_PASSPHRASE_FILE.
+func (l *Local) PassphraseFile() string {
+	return filepath.Join(l.Dir, passphraseFile)
+}
+
+// Env is the environment of Pulumi commands that use the backend. The Pulumi CLI prefers PULUMI_CONFIG_PASSPHRASE to
+// the file, so it must not be set in the environment they inherit.
+func (l *Local) Env() []string {
+	return []string{"PULUMI_CONFIG_PASSPHRASE_FILE=" + l.PassphraseFile()}
+}
+
+// Remove deletes the backend with the state of every stack in it.
+func (l *Local) Remove() error {
+	return os.RemoveAll(l.Dir)
+}
+
+// PassphraseFileOf returns the passphrase file of a local backend created by NewLocal, given its URL, or "" for
+// other backends.
+func PassphraseFileOf(backendURL string) string {
+	u, err := url.Parse(backendURL)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	path := filepath.Join(filepath.FromSlash(u.Path), passphraseFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
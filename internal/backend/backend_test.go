@@ -0,0 +1,60 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocal(t *testing.T) {
+	parent := t.TempDir()
+	a, err := NewLocal(parent)
+	require.NoError(t, err)
+	b, err := NewLocal(parent)
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Dir, b.Dir, "every run gets its own backend")
+
+	assert.Equal(t, "file://"+filepath.ToSlash(a.Dir), a.URL)
+	assert.Equal(t, []string{"PULUMI_CONFIG_PASSPHRASE_FILE=" + a.PassphraseFile()}, a.Env())
+
+	pa, err := os.ReadFile(a.PassphraseFile())
+	require.NoError(t, err)
+	pb, err := os.ReadFile(b.PassphraseFile())
+	require.NoError(t, err)
+	assert.Len(t, pa, 64)
+	assert.NotEqual(t, pa, pb, "passphrases are generated")
+	info, err := os.Stat(a.PassphraseFile())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, a.Remove())
+	assert.NoDirExists(t, a.Dir)
+}
+
+func TestPassphraseFileOf(t *testing.T) {
+	l, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, l.PassphraseFile(), PassphraseFileOf(l.URL))
+
+	assert.Empty(t, PassphraseFileOf("https://api.pulumi.com"))
+	assert.Empty(t, PassphraseFileOf("file://"+filepath.ToSlash(t.TempDir())), "not created by NewLocal")
+	require.NoError(t, l.Remove())
+	assert.Empty(t, PassphraseFileOf(l.URL))
+}
@@ -0,0 +1,568 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfn
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PseudoParameters are the values substituted for `AWS::*` references.
+//
+// See https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/pseudo-parameter-reference.html
+type PseudoParameters struct {
+	AccountID string
+	Region    string
+	Partition string
+	URLSuffix string
+	// StackNodeID replaces both AWS::StackName and AWS::StackId, as the converter does.
+	StackNodeID string
+}
+
+// DefaultPseudoParameters returns a set of pseudo-parameters for a fictional account in us-east-1.
+func DefaultPseudoParameters() PseudoParameters {
+	return PseudoParameters{
+		AccountID:   "123456789012",
+		Region:      "us-east-1",
+		Partition:   "aws",
+		URLSuffix:   "amazonaws.com",
+		StackNodeID: "stack",
+	}
+}
+
+// UnsupportedError is returned when evaluation reaches an intrinsic or pseudo-parameter that pulumi-cdk cannot handle.
+type UnsupportedError struct {
+	Name string
+}
+
+// SubVariables is the name under which Unsupported and UnsupportedIntrinsics report an Fn::Sub whose template string
+// refers to a variable of its variable map, which the converter ignores.
+const SubVariables = "Fn::Sub variables"
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported intrinsic function %s", e.Name)
+}
+
+// Evaluator evaluates CloudFormation expressions over a template without contacting AWS. It follows the semantics of
+// `processIntrinsics` in src/converters/app-converter.ts and the intrinsics in src/converters/intrinsics.ts.
+//
+// Values that only exist after deployment are replaced with placeholders: a Ref to a resource becomes
+// `{{Ref:LogicalId}}` and an Fn::GetAtt becomes `{{GetAtt:LogicalId.Attr}}`, unless PhysicalIDs or Attributes supply
+// a value.
+type Evaluator struct {
+	Template   *Template
+	Parameters map[string]any
+	Pseudo     PseudoParameters
+
+	// PhysicalIDs optionally overrides the placeholder for a Ref to a resource.
+	PhysicalIDs map[string]string
+	// Attributes optionally overrides the placeholder for an Fn::GetAtt, keyed by logical ID then attribute name.
+	Attributes map[string]map[string]any
+
+	unsupported map[string]struct{}
+}
+
+// NewEvaluator creates an Evaluator over the template with the given parameter values and default pseudo-parameters.
+func NewEvaluator(t *Template, params map[string]any) *Evaluator {
+	return &Evaluator{
+		Template:   t,
+		Parameters: params,
+		Pseudo:     DefaultPseudoParameters(),
+	}
+}
+
+// Unsupported lists the intrinsic functions that evaluation has reached but could not evaluate, sorted by name.
+func (e *Evaluator) Unsupported() []string {
+	names := make([]string, 0, len(e.unsupported))
+	for n := range e.unsupported {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EvaluateCondition evaluates the named entry of the template's Conditions section.
+func (e *Evaluator) EvaluateCondition(name string) (bool, error) {
+	expr, ok := e.Template.Conditions[name]
+	if !ok {
+		return false, fmt.Errorf("No condition '%s' found", name)
+	}
+	v, err := e.Evaluate(expr)
+	if err != nil {
+		return false, err
+	}
+	return mustBeBoolean(v)
+}
+
+// EvaluateOutput evaluates the value of the named template output.
+func (e *Evaluator) EvaluateOutput(name string) (any, error) {
+	out, ok := e.Template.Outputs[name]
+	if !ok {
+		return nil, fmt.Errorf("no output %q in template", name)
+	}
+	return e.Evaluate(out.Value)
+}
+
+// EvaluateProperty evaluates a top-level property of a resource.
+func (e *Evaluator) EvaluateProperty(logicalID, property string) (any, error) {
+	r, ok := e.Template.Resources[logicalID]
+	if !ok {
+		return nil, fmt.Errorf("no resource %q in template", logicalID)
+	}
+	return e.Evaluate(r.Properties[property])
+}
+
+// Evaluate resolves all intrinsic functions within the expression. `AWS::NoValue` entries are dropped from objects and
+// lists, and a bare `AWS::NoValue` evaluates to nil.
+func (e *Evaluator) Evaluate(expr any) (any, error) {
+	switch x := expr.(type) {
+	case []any:
+		out := make([]any, 0, len(x))
+		for _, v := range x {
+			if isNoValue(v) {
+				continue
+			}
+			r, err := e.Evaluate(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r)
+		}
+		return out, nil
+	case map[string]any:
+		if ref, ok := x["Ref"]; ok && ref != nil {
+			return e.ref(ref)
+		}
+		if len(x) == 1 {
+			for k, v := range x {
+				if strings.HasPrefix(k, "Fn::") {
+					return e.intrinsic(k, v)
+				}
+			}
+		}
+		out := make(map[string]any, len(x))
+		for k, v := range x {
+			if isNoValue(v) {
+				continue
+			}
+			r, err := e.Evaluate(v)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	default:
+		return expr, nil
+	}
+}
+
+func isNoValue(v any) bool {
+	m, ok := v.(map[string]any)
+	return ok && m["Ref"] == "AWS::NoValue"
+}
+
+func (e *Evaluator) markUnsupported(name string) error {
+	if e.unsupported == nil {
+		e.unsupported = map[string]struct{}{}
+	}
+	e.unsupported[name] = struct{}{}
+	return &UnsupportedError{Name: name}
+}
+
+func (e *Evaluator) intrinsic(fn string, params any) (any, error) {
+	switch fn {
+	case "Fn::GetAtt":
+		args, err := e.stringList(fn, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) != 2 {
+			return nil, fmt.Errorf("Fn::GetAtt expects exactly 2 params, got %d", len(args))
+		}
+		return e.getAtt(args[0], args[1])
+
+	case "Fn::Join":
+		args, err := e.evaluateList(fn, params, 2)
+		if err != nil {
+			return nil, err
+		}
+		delim, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("Fn::Join expects a string delimiter, got %T", args[0])
+		}
+		items, ok := args[1].([]any)
+		if !ok {
+			return nil, fmt.Errorf("Fn::Join expects a list of values, got %T", args[1])
+		}
+		strs := make([]string, len(items))
+		for i, it := range items {
+			strs[i] = toString(it)
+		}
+		return strings.Join(strs, delim), nil
+
+	case "Fn::Select":
+		args, err := e.evaluateList(fn, params, 2)
+		if err != nil {
+			return nil, err
+		}
+		index, err := toIndex(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("Fn::Select: %w", err)
+		}
+		list, ok := args[1].([]any)
+		if !ok {
+			return nil, fmt.Errorf("Fn::Select expects a list, got %T", args[1])
+		}
+		if index < 0 || index >= len(list) {
+			// JavaScript yields undefined for an out of range index.
+			return nil, nil
+		}
+		return list[index], nil
+
+	case "Fn::Split":
+		args, err := e.evaluateList(fn, params, 2)
+		if err != nil {
+			return nil, err
+		}
+		delim, ok1 := args[0].(string)
+		str, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("Fn::Split expects a string delimiter and a string")
+		}
+		var out []any
+		for _, s := range strings.Split(str, delim) {
+			out = append(out, s)
+		}
+		return out, nil
+
+	case "Fn::Base64":
+		v, err := e.Evaluate(params)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.EncodeToString([]byte(toString(v))), nil
+
+	case "Fn::Sub":
+		return e.sub(params)
+
+	case "Fn::FindInMap":
+		return e.findInMap(params)
+
+	case "Fn::Equals":
+		args, ok := params.([]any)
+		if !ok || len(args) != 2 {
+			return nil, fmt.Errorf("Fn::Equals expects exactly 2 params, got %d", paramCount(params))
+		}
+		x, err := e.Evaluate(args[0])
+		if err != nil {
+			return nil, err
+		}
+		y, err := e.Evaluate(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.DeepEqual(x, y), nil
+
+	case "Fn::If":
+		args, ok := params.([]any)
+		if !ok || len(args) != 3 {
+			return nil, fmt.Errorf("Expected 3 parameters, got %d", paramCount(params))
+		}
+		name, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("Expected the first parameter to be a condition name string literal")
+		}
+		cond, err := e.EvaluateCondition(name)
+		if err != nil {
+			return nil, err
+		}
+		if cond {
+			return e.Evaluate(args[1])
+		}
+		return e.Evaluate(args[2])
+
+	case "Fn::Or":
+		args, ok := params.([]any)
+		if !ok || len(args) < 2 {
+			return nil, fmt.Errorf("Fn::Or expects at least 2 params, got %d", paramCount(params))
+		}
+		for _, a := range args {
+			v, err := e.conditionSubExpression(a)
+			if err != nil {
+				return nil, err
+			}
+			if v {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	default:
+		// Fn::Cidr and Fn::GetAZs need provider calls, Fn::Transform and Fn::ImportValue are rejected by the
+		// converter, and anything else, including Fn::And and Fn::Not, is unknown to it.
+		return nil, e.markUnsupported(fn)
+	}
+}
+
+func (e *Evaluator) ref(param any) (any, error) {
+	if m, ok := param.(map[string]any); ok {
+		if id, ok := m["PulumiOutput"]; ok {
+			// Outputs passed through CDK tokens only exist while the Pulumi program runs.
+			return fmt.Sprintf("{{PulumiOutput:%v}}", id), nil
+		}
+	}
+	name, ok := param.(string)
+	if !ok {
+		v, err := e.Evaluate(param)
+		if err != nil {
+			return nil, err
+		}
+		if name, ok = v.(string); !ok {
+			return nil, fmt.Errorf("Expected a string, got %T", v)
+		}
+	}
+
+	switch name {
+	case "AWS::AccountId":
+		return e.Pseudo.AccountID, nil
+	case "AWS::NoValue":
+		return nil, nil
+	case "AWS::Partition":
+		return e.Pseudo.Partition, nil
+	case "AWS::Region":
+		return e.Pseudo.Region, nil
+	case "AWS::URLSuffix":
+		return e.Pseudo.URLSuffix, nil
+	case "AWS::NotificationARNs":
+		return nil, e.markUnsupported(name)
+	case "AWS::StackId", "AWS::StackName":
+		return e.Pseudo.StackNodeID, nil
+	}
+
+	if p, ok := e.Template.Parameters[name]; ok {
+		if v, ok := e.Parameters[name]; ok {
+			return v, nil
+		}
+		if p.Default != nil {
+			return p.Default, nil
+		}
+		return nil, fmt.Errorf("No value for the CloudFormation parameter %q", name)
+	}
+
+	if _, ok := e.Template.Resources[name]; ok {
+		if id, ok := e.PhysicalIDs[name]; ok {
+			return id, nil
+		}
+		return fmt.Sprintf("{{Ref:%s}}", name), nil
+	}
+
+	return nil, fmt.Errorf("Ref intrinsic unable to resolve %s: not a known logical resource or parameter reference", name)
+}
+
+func (e *Evaluator) getAtt(logicalID, attr string) (any, error) {
+	if _, ok := e.Template.Resources[logicalID]; !ok {
+		return nil, fmt.Errorf("missing reference for %s", logicalID)
+	}
+	if v, ok := e.Attributes[logicalID][attr]; ok {
+		return v, nil
+	}
+	return fmt.Sprintf("{{GetAtt:%s.%s}}", logicalID, attr), nil
+}
+
+// sub implements Fn::Sub like the converter, which substitutes every `${Name}` as a Ref and ignores the variable map.
+// A template that refers to a variable of the map would not evaluate the way CloudFormation evaluates it, so that is
+// reported as SubVariables instead.
+func (e *Evaluator) sub(params any) (any, error) {
+	var template string
+	switch p := params.(type) {
+	case string:
+		template = p
+	case []any:
+		if len(p) != 2 {
+			return nil, fmt.Errorf("Fn::Sub expects 1 or 2 params, got %d", len(p))
+		}
+		s, ok := p[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("Fn::Sub expects a template string, got %T", p[0])
+		}
+		template = s
+		if usesSubVariables(p) {
+			return nil, e.markUnsupported(SubVariables)
+		}
+		// The converter evaluates the variable map along with the template, so its errors surface all the same.
+		if _, err := e.Evaluate(p[1]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Fn::Sub expects a string or a list, got %T", params)
+	}
+
+	return joinSubParts(ParseSub(template), func(ref SubRef) (string, error) {
+		if ref.Attr == "" {
+			v, err := e.ref(ref.ID)
+			return toString(v), err
+		}
+		v, err := e.getAtt(ref.ID, ref.Attr)
+		return toString(v), err
+	})
+}
+
+func (e *Evaluator) findInMap(params any) (any, error) {
+	if paramCount(params) != 3 {
+		return nil, fmt.Errorf("Fn::FindInMap requires exactly 3 parameters, got %d", paramCount(params))
+	}
+	args, err := e.stringList("Fn::FindInMap", params)
+	if err != nil {
+		return nil, err
+	}
+	mapping, ok := e.Template.Mappings[args[0]]
+	if !ok {
+		return nil, fmt.Errorf("Mapping %s not found in mappings. Available mappings are %s",
+			args[0], strings.Join(sortedKeys(e.Template.Mappings), ","))
+	}
+	top, ok := mapping[args[1]]
+	if !ok {
+		return nil, fmt.Errorf("Key %s not found in mapping %s. Available keys are %s",
+			args[1], args[0], strings.Join(sortedKeys(mapping), ","))
+	}
+	v, ok := top[args[2]]
+	if !ok {
+		return nil, fmt.Errorf("Key %s not found in mapping %s.%s. Available keys are %s",
+			args[2], args[0], args[1], strings.Join(sortedKeys(top), ","))
+	}
+	return v, nil
+}
+
+// conditionSubExpression is like Evaluate but also recognizes `{"Condition": "Name"}` as Fn::Or requires.
+func (e *Evaluator) conditionSubExpression(expr any) (bool, error) {
+	if m, ok := expr.(map[string]any); ok {
+		if name, ok := m["Condition"].(string); ok {
+			return e.EvaluateCondition(name)
+		}
+	}
+	v, err := e.Evaluate(expr)
+	if err != nil {
+		return false, err
+	}
+	return mustBeBoolean(v)
+}
+
+func (e *Evaluator) evaluateList(fn string, params any, n int) ([]any, error) {
+	v, err := e.Evaluate(params)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := v.([]any)
+	if !ok || len(list) != n {
+		return nil, fmt.Errorf("%s expects exactly %d params, got %d", fn, n, paramCount(v))
+	}
+	return list, nil
+}
+
+func (e *Evaluator) stringList(fn string, params any) ([]string, error) {
+	v, err := e.Evaluate(params)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s expects a list of params, got %T", fn, v)
+	}
+	out := make([]string, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s expects string params, got %T", fn, item)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+func mustBeBoolean(v any) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("Expected a boolean, got %s", jsType(v))
+	}
+	return b, nil
+}
+
+func paramCount(params any) int {
+	if l, ok := params.([]any); ok {
+		return len(l)
+	}
+	return 1
+}
+
+func toIndex(v any) (int, error) {
+	switch i := v.(type) {
+	case float64:
+		return int(i), nil
+	case int:
+		return i, nil
+	case string:
+		return strconv.Atoi(i)
+	}
+	return 0, fmt.Errorf("expected a numeric index, got %T", v)
+}
+
+// toString formats a value the way JavaScript's toString would for the scalar types found in templates.
+func toString(v any) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case []any:
+		strs := make([]string, len(s))
+		for i, it := range s {
+			strs[i] = toString(it)
+		}
+		return strings.Join(strs, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// jsType names the JavaScript type of a JSON value so that error messages match the converter's.
+func jsType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64, int:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "undefined"
+	}
+	return "object"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
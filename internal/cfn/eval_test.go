@@ -0,0 +1,232 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestTemplate(t *testing.T) *Template {
+	t.Helper()
+	tmpl, err := LoadTemplate("testdata/conditions.template.json")
+	require.NoError(t, err)
+	return tmpl
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+
+	cases := []struct {
+		env, region string
+		expected    map[string]bool
+	}{
+		{"prod", "us-east-1", map[string]bool{"IsProd": true, "IsEast": true, "ProdOrEast": true}},
+		{"prod", "us-west-2", map[string]bool{"IsProd": true, "IsEast": false, "ProdOrEast": true}},
+		{"dev", "us-west-2", map[string]bool{"IsProd": false, "IsEast": false, "ProdOrEast": false}},
+	}
+	for _, c := range cases {
+		e := NewEvaluator(tmpl, map[string]any{"Env": c.env})
+		e.Pseudo.Region = c.region
+		for name, want := range c.expected {
+			got, err := e.EvaluateCondition(name)
+			require.NoError(t, err)
+			assert.Equalf(t, want, got, "%s with Env=%s in %s", name, c.env, c.region)
+		}
+	}
+
+	// The converter does not dispatch Fn::And and Fn::Not, so conditions that use them fail.
+	e := NewEvaluator(tmpl, map[string]any{"Env": "prod"})
+	for name, fn := range map[string]string{"ProdInEast": "Fn::And", "NotProd": "Fn::Not"} {
+		_, err := e.EvaluateCondition(name)
+		var unsupported *UnsupportedError
+		require.ErrorAs(t, err, &unsupported, name)
+		assert.Equal(t, fn, unsupported.Name)
+	}
+}
+
+func TestEvaluateFnIf(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+
+	e := NewEvaluator(tmpl, map[string]any{"Env": "prod"})
+	stage, err := e.EvaluateOutput("Stage")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-east", stage)
+
+	e.Pseudo.Region = "eu-west-1"
+	stage, err = e.EvaluateOutput("Stage")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-elsewhere", stage)
+
+	// Without a value the parameter default applies.
+	e = NewEvaluator(tmpl, nil)
+	stage, err = e.EvaluateOutput("Stage")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", stage)
+}
+
+func TestEvaluateNoValue(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+
+	// Like the converter, only literal AWS::NoValue entries are dropped; an Fn::If that picks AWS::NoValue leaves nil.
+	tags, err := NewEvaluator(tmpl, map[string]any{"Env": "dev"}).EvaluateProperty("Bucket", "Tags")
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"Key": "ami", "Value": "ami-east"}, nil}, tags)
+
+	v, err := NewEvaluator(tmpl, nil).Evaluate(map[string]any{"A": map[string]any{"Ref": "AWS::NoValue"}, "B": "b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"B": "b"}, v)
+
+	tags, err = NewEvaluator(tmpl, map[string]any{"Env": "prod"}).EvaluateProperty("Bucket", "Tags")
+	require.NoError(t, err)
+	assert.Len(t, tags, 2)
+}
+
+func TestEvaluateSub(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+	e := NewEvaluator(tmpl, map[string]any{"Env": "test"})
+
+	name, err := e.EvaluateProperty("Bucket", "BucketName")
+	require.NoError(t, err)
+	assert.Equal(t, "stack-test-bucket", name)
+
+	url, err := e.EvaluateOutput("Url")
+	require.NoError(t, err)
+	assert.Equal(t, "https://{{Ref:Bucket}}.s3.amazonaws.com/${Literal}", url)
+
+	e.PhysicalIDs = map[string]string{"Bucket": "my-bucket"}
+	e.Pseudo.URLSuffix = "amazonaws.com.cn"
+	url, err = e.EvaluateOutput("Url")
+	require.NoError(t, err)
+	assert.Equal(t, "https://my-bucket.s3.amazonaws.com.cn/${Literal}", url)
+
+	// Like the converter, the variable map is ignored as long as the template does not use it.
+	v, err := e.Evaluate(map[string]any{"Fn::Sub": []any{"${Env}-${Bucket}", map[string]any{"Greeting": "hi"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "test-my-bucket", v)
+
+	_, err = e.Evaluate(map[string]any{"Fn::Sub": []any{"${Greeting}-${Env}", map[string]any{"Greeting": "hi"}}})
+	var unsupported *UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, SubVariables, unsupported.Name)
+	assert.Equal(t, []string{SubVariables}, e.Unsupported())
+}
+
+func TestEvaluateGetAtt(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+	e := NewEvaluator(tmpl, nil)
+
+	arn, err := e.EvaluateOutput("BucketArn")
+	require.NoError(t, err)
+	assert.Equal(t, "{{GetAtt:Bucket.Arn}}", arn)
+
+	e.Attributes = map[string]map[string]any{"Bucket": {"Arn": "arn:aws:s3:::my-bucket"}}
+	arn, err = e.EvaluateOutput("BucketArn")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:s3:::my-bucket", arn)
+
+	_, err = e.Evaluate(map[string]any{"Fn::GetAtt": []any{"Missing", "Arn"}})
+	assert.ErrorContains(t, err, "missing reference for Missing")
+}
+
+func TestEvaluateSelectSplitJoin(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+	e := NewEvaluator(tmpl, map[string]any{"Subnets": []any{"subnet-a", "subnet-b", "subnet-c"}})
+
+	name, err := e.EvaluateProperty("Queue", "QueueName")
+	require.NoError(t, err)
+	assert.Equal(t, "subnet-b", name)
+}
+
+func TestEvaluateFindInMapErrors(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+	e := NewEvaluator(tmpl, nil)
+	e.Pseudo.Region = "eu-central-1"
+
+	_, err := e.EvaluateProperty("Bucket", "Tags")
+	assert.EqualError(t, err, "Key eu-central-1 not found in mapping RegionMap. Available keys are us-east-1,us-west-2")
+
+	_, err = e.Evaluate(map[string]any{"Fn::FindInMap": []any{"RegionMap", "us-east-1"}})
+	assert.EqualError(t, err, "Fn::FindInMap requires exactly 3 parameters, got 2")
+}
+
+func TestEvaluateErrorsMatchConverter(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+	e := NewEvaluator(tmpl, nil)
+
+	_, err := e.Evaluate(map[string]any{"Fn::If": []any{"Missing", "a", "b"}})
+	assert.EqualError(t, err, "No condition 'Missing' found")
+
+	_, err = e.Evaluate(map[string]any{"Fn::Or": []any{false}})
+	assert.EqualError(t, err, "Fn::Or expects at least 2 params, got 1")
+
+	_, err = e.Evaluate(map[string]any{"Fn::Or": []any{"yes", false}})
+	assert.EqualError(t, err, "Expected a boolean, got string")
+
+	_, err = e.Evaluate(map[string]any{"Ref": "Nope"})
+	assert.ErrorContains(t, err, "Ref intrinsic unable to resolve Nope")
+
+	_, err = e.Evaluate(map[string]any{"Ref": "Subnets"})
+	assert.ErrorContains(t, err, `No value for the CloudFormation parameter "Subnets"`)
+}
+
+func TestUnsupportedIntrinsics(t *testing.T) {
+	tmpl := loadTestTemplate(t)
+	e := NewEvaluator(tmpl, nil)
+
+	_, err := e.EvaluateProperty("Queue", "Policy")
+	var unsupported *UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, "Fn::GetAZs", unsupported.Name)
+
+	_, err = e.Evaluate(map[string]any{"Ref": "AWS::NotificationARNs"})
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, []string{"AWS::NotificationARNs", "Fn::GetAZs"}, e.Unsupported())
+
+	assert.Equal(t, []IntrinsicUse{
+		{Name: "Fn::Not", Location: "Conditions.NotProd"},
+		{Name: "Fn::And", Location: "Conditions.ProdInEast"},
+		{Name: "Fn::GetAZs", Location: "Resources.Queue.Properties.Policy"},
+	}, tmpl.UnsupportedIntrinsics())
+}
+
+func TestIsSupported(t *testing.T) {
+	for _, fn := range []string{"Ref", "Fn::Sub", "Fn::Equals", "Fn::If", "Fn::Or"} {
+		assert.True(t, IsSupported(fn), fn)
+	}
+	for _, fn := range []string{"Fn::And", "Fn::Not", "Fn::GetAZs", "Fn::Cidr", "Fn::ImportValue", "Fn::Transform"} {
+		assert.False(t, IsSupported(fn), fn)
+	}
+}
+
+func TestUnsupportedSubVariables(t *testing.T) {
+	tmpl := &Template{Outputs: map[string]Output{
+		"Used":   {Value: map[string]any{"Fn::Sub": []any{"${Name}-x", map[string]any{"Name": "n"}}}},
+		"Unused": {Value: map[string]any{"Fn::Sub": []any{"${AWS::Region}", map[string]any{"Name": "n"}}}},
+	}}
+	assert.Equal(t, []IntrinsicUse{
+		{Name: SubVariables, Location: "Outputs.Used.Value"},
+	}, tmpl.UnsupportedIntrinsics())
+}
+
+func TestParseSub(t *testing.T) {
+	assert.Equal(t, []SubPart{
+		{Str: "arn:", Ref: &SubRef{ID: "AWS::Partition"}},
+		{Str: ":s3:::", Ref: &SubRef{ID: "Bucket", Attr: "Arn"}},
+		{Str: "/${literal}"},
+	}, ParseSub("arn:${AWS::Partition}:s3:::${Bucket.Arn}/${!literal}"))
+}
@@ -0,0 +1,128 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// supportedIntrinsics are the intrinsic functions the Evaluator can resolve offline. Fn::And and Fn::Not are missing:
+// src/converters/intrinsics.ts implements them, but processIntrinsics does not dispatch to them, so the converter
+// rejects them like any unknown function.
+var supportedIntrinsics = map[string]bool{
+	"Ref":           true,
+	"Fn::GetAtt":    true,
+	"Fn::Join":      true,
+	"Fn::Select":    true,
+	"Fn::Split":     true,
+	"Fn::Base64":    true,
+	"Fn::Sub":       true,
+	"Fn::FindInMap": true,
+	"Fn::Equals":    true,
+	"Fn::If":        true,
+	"Fn::Or":        true,
+}
+
+// IsSupported reports whether the Evaluator can resolve the named intrinsic function.
+func IsSupported(intrinsic string) bool {
+	return supportedIntrinsics[intrinsic]
+}
+
+// IntrinsicUse records one occurrence of an intrinsic function in a template.
+type IntrinsicUse struct {
+	Name string
+	// Location is a dotted path to the occurrence, e.g. `Resources.MyBucket.Properties.BucketName`.
+	Location string
+}
+
+func (u IntrinsicUse) String() string {
+	return fmt.Sprintf("%s at %s", u.Name, u.Location)
+}
+
+// Intrinsics walks the template's Conditions, Resources and Outputs and lists every intrinsic function used, in a
+// stable order.
+func (t *Template) Intrinsics() []IntrinsicUse {
+	var uses []IntrinsicUse
+	for _, name := range sortedKeys(t.Conditions) {
+		uses = collectIntrinsics(t.Conditions[name], "Conditions."+name, uses)
+	}
+	for _, id := range sortedKeys(t.Resources) {
+		uses = collectIntrinsics(t.Resources[id].Properties, "Resources."+id+".Properties", uses)
+	}
+	for _, name := range sortedKeys(t.Outputs) {
+		uses = collectIntrinsics(t.Outputs[name].Value, "Outputs."+name+".Value", uses)
+	}
+	return uses
+}
+
+// UnsupportedIntrinsics lists the intrinsic functions used in the template that the Evaluator cannot resolve.
+func (t *Template) UnsupportedIntrinsics() []IntrinsicUse {
+	var out []IntrinsicUse
+	for _, u := range t.Intrinsics() {
+		if !IsSupported(u.Name) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+func collectIntrinsics(expr any, location string, uses []IntrinsicUse) []IntrinsicUse {
+	switch x := expr.(type) {
+	case []any:
+		for i, v := range x {
+			uses = collectIntrinsics(v, fmt.Sprintf("%s[%d]", location, i), uses)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if k == "Ref" || (len(x) == 1 && strings.HasPrefix(k, "Fn::")) {
+				uses = append(uses, IntrinsicUse{Name: k, Location: location})
+				if s, ok := x[k].(string); ok && k == "Ref" && s == "AWS::NotificationARNs" {
+					uses = append(uses, IntrinsicUse{Name: s, Location: location})
+				}
+				if k == "Fn::Sub" && usesSubVariables(x[k]) {
+					uses = append(uses, IntrinsicUse{Name: SubVariables, Location: location})
+				}
+			}
+			uses = collectIntrinsics(x[k], location+"."+k, uses)
+		}
+	}
+	return uses
+}
+
+// usesSubVariables reports whether the template string of the Fn::Sub params refers to a variable of its variable map.
+func usesSubVariables(params any) bool {
+	p, ok := params.([]any)
+	if !ok || len(p) != 2 {
+		return false
+	}
+	template, _ := p[0].(string)
+	vars, _ := p[1].(map[string]any)
+	for _, part := range ParseSub(template) {
+		if part.Ref == nil || part.Ref.Attr != "" {
+			continue
+		}
+		if _, ok := vars[part.Ref.ID]; ok {
+			return true
+		}
+	}
+	return false
+}
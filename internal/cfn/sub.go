@@ -0,0 +1,79 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cfn
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SubRef is a `${Id}` or `${Id.Attr}` reference inside an Fn::Sub template string.
+type SubRef struct {
+	ID   string `json:"id"`
+	Attr string `json:"attr,omitempty"`
+}
+
+// SubPart is a literal string optionally followed by a reference, mirroring `SubPart` in src/sub.ts.
+type SubPart struct {
+	Str string  `json:"str"`
+	Ref *SubRef `json:"ref,omitempty"`
+}
+
+var (
+	subRegex = regexp.MustCompile(`\$\{([^!][^.}]*)(\.[^}]*)?\}`)
+	litRegex = regexp.MustCompile(`\$\{!`)
+)
+
+// ParseSub splits an Fn::Sub template into literal parts and references. It is a port of `parseSub` in src/sub.ts and
+// must stay in sync with it.
+func ParseSub(template string) []SubPart {
+	var parts []SubPart
+	endIndex := 0
+	for _, m := range subRegex.FindAllStringSubmatchIndex(template, -1) {
+		startIndex := endIndex
+		endIndex = m[1]
+
+		str := litRegex.ReplaceAllString(template[startIndex:m[0]], "${")
+		ref := &SubRef{ID: template[m[2]:m[3]]}
+		if m[4] >= 0 {
+			// Slice off the leading '.'
+			ref.Attr = template[m[4]+1 : m[5]]
+		}
+		parts = append(parts, SubPart{Str: str, Ref: ref})
+	}
+
+	if endIndex != len(template) {
+		parts = append(parts, SubPart{Str: litRegex.ReplaceAllString(template[endIndex:], "${")})
+	}
+
+	return parts
+}
+
+// joinSubParts renders parsed parts back into a string with the references resolved by the given function.
+func joinSubParts(parts []SubPart, resolve func(ref SubRef) (string, error)) (string, error) {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(p.Str)
+		if p.Ref == nil {
+			continue
+		}
+		v, err := resolve(*p.Ref)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+	}
+	return b.String(), nil
+}
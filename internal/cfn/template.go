@@ -0,0 +1,94 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cfn provides an offline model of the CloudFormation templates that CDK synthesizes, so that tests can make
+// assertions about them without deploying anything.
+package cfn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Template is the subset of a CloudFormation template that pulumi-cdk consumes.
+//
+// See https://docs.aws.amazon.com/AWSCloudFormation/latest/UserGuide/template-anatomy.html
+type Template struct {
+	Parameters map[string]Parameter                 `json:"Parameters,omitempty"`
+	Mappings   map[string]map[string]map[string]any `json:"Mappings,omitempty"`
+	Conditions map[string]any                       `json:"Conditions,omitempty"`
+	Resources  map[string]Resource                  `json:"Resources,omitempty"`
+	Outputs    map[string]Output                    `json:"Outputs,omitempty"`
+}
+
+// Parameter is a template parameter declaration.
+type Parameter struct {
+	Type    string `json:"Type"`
+	Default any    `json:"Default,omitempty"`
+}
+
+// Resource is a template resource declaration.
+type Resource struct {
+	Type                string         `json:"Type"`
+	Properties          map[string]any `json:"Properties,omitempty"`
+	DependsOn           any            `json:"DependsOn,omitempty"`
+	Condition           string         `json:"Condition,omitempty"`
+	DeletionPolicy      string         `json:"DeletionPolicy,omitempty"`
+	UpdateReplacePolicy string         `json:"UpdateReplacePolicy,omitempty"`
+	Metadata            map[string]any `json:"Metadata,omitempty"`
+}
+
+// Output is a template output declaration.
+type Output struct {
+	Value       any    `json:"Value"`
+	Description string `json:"Description,omitempty"`
+	Condition   string `json:"Condition,omitempty"`
+}
+
+// LoadTemplate reads a JSON template such as `cdk.out/MyStack.template.json`.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// DependsOnList normalizes DependsOn, which CloudFormation allows to be either a string or a list of strings.
+func (r Resource) DependsOnList() []string {
+	switch d := r.DependsOn.(type) {
+	case string:
+		return []string{d}
+	case []any:
+		var out []string
+		for _, v := range d {
+			if s, ok := v.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// ConstructPath returns the `aws:cdk:path` metadata of the resource, or an empty string when it is not set.
+func (r Resource) ConstructPath() string {
+	p, _ := r.Metadata["aws:cdk:path"].(string)
+	return p
+}
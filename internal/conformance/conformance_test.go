@@ -0,0 +1,116 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/pulumi/pulumi-cdk/internal/cfn"
+	"github.com/pulumi/pulumi-cdk/internal/customresource"
+	"github.com/pulumi/pulumi-cdk/internal/naming"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const repoRoot = "../.."
+
+func loadCorpus(t *testing.T) *Corpus {
+	t.Helper()
+	c, err := Load(DefaultPath(repoRoot))
+	require.NoError(t, err)
+	return c
+}
+
+func checkCase[In, Out any](t *testing.T, c Case[In, Out], got Out, err error) {
+	t.Helper()
+	if c.Error != "" {
+		assert.EqualErrorf(t, err, c.Error, "input %v", c.Input)
+		return
+	}
+	if assert.NoErrorf(t, err, "input %v", c.Input) {
+		assert.Equalf(t, c.Expected, got, "input %v", c.Input)
+	}
+}
+
+func TestCorpusTypeToken(t *testing.T) {
+	for _, c := range loadCorpus(t).TypeToken {
+		got, err := naming.TypeToken(c.Input)
+		checkCase(t, c, got, err)
+	}
+}
+
+func TestCorpusModuleName(t *testing.T) {
+	for _, c := range loadCorpus(t).ModuleName {
+		got, err := naming.ModuleName(c.Input)
+		checkCase(t, c, got, err)
+	}
+}
+
+func TestCorpusToSdkName(t *testing.T) {
+	for _, c := range loadCorpus(t).ToSdkName {
+		checkCase(t, c, naming.ToSdkName(c.Input), nil)
+	}
+}
+
+func TestCorpusParseSub(t *testing.T) {
+	for _, c := range loadCorpus(t).ParseSub {
+		got := cfn.ParseSub(c.Input)
+		if len(c.Expected) == 0 {
+			assert.Emptyf(t, got, "input %q", c.Input)
+			continue
+		}
+		checkCase(t, c, got, nil)
+	}
+}
+
+func TestCorpusMakeUniqueID(t *testing.T) {
+	for _, c := range loadCorpus(t).MakeUniqueID {
+		got, err := naming.MakeUniqueID(c.Input)
+		checkCase(t, c, got, err)
+	}
+}
+
+func TestCorpusCustomTimeouts(t *testing.T) {
+	for _, c := range loadCorpus(t).CustomTimeouts {
+		checkCase(t, c, customresource.CustomTimeouts(c.Input["ServiceTimeout"]), nil)
+	}
+}
+
+func TestCorpusRejectsUnknownVersion(t *testing.T) {
+	path := t.TempDir() + "/corpus.json"
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), 0o600))
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "has version 99")
+}
+
+// TestMetadataTypeTokens checks the naming rules against every resource in the aws-native metadata. The metadata is
+// fetched by `make renovate`, so the test is skipped when it is not present, except in CI, where it must be.
+func TestMetadataTypeTokens(t *testing.T) {
+	m, err := LoadMetadata(MetadataPath(repoRoot))
+	if errors.Is(err, os.ErrNotExist) && os.Getenv("CI") == "" {
+		t.Skip("schemas/aws-native-metadata.json not found; run `make renovate` to fetch it")
+	}
+	require.NoError(t, err)
+
+	assert.Empty(t, m.CheckTypeTokens())
+
+	for _, c := range loadCorpus(t).TypeToken {
+		if c.Error == "" {
+			assert.Truef(t, m.HasToken(c.Expected), "corpus token %s for %s is not in the metadata", c.Expected, c.Input)
+		}
+	}
+}
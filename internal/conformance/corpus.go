@@ -0,0 +1,72 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance loads the cross-language corpus in tests/test-data/conformance. The TypeScript unit tests in
+// tests/conformance.test.ts read the same file, so any rule change has to be made on both sides at once.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pulumi/pulumi-cdk/internal/cfn"
+	"github.com/pulumi/pulumi-cdk/internal/customresource"
+)
+
+// Version is the corpus format understood by this runner. Bump it together with tests/conformance.test.ts when the
+// shape of the file changes.
+const Version = 1
+
+// Case maps an input to either an expected output or an expected error message.
+type Case[In, Out any] struct {
+	Input    In     `json:"input"`
+	Expected Out    `json:"expected,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Corpus is the set of cases for `typeToken`, `moduleName` and `toSdkName` (src/naming.ts), `parseSub` (src/sub.ts),
+// `makeUniqueId` (src/cdk-logical-id.ts) and `convertToCustomTimeouts` (src/custom-resource-mapping.ts), whose cases
+// take the properties of a custom resource.
+type Corpus struct {
+	Version        int                                              `json:"version"`
+	TypeToken      []Case[string, string]                           `json:"typeToken"`
+	ModuleName     []Case[string, string]                           `json:"moduleName"`
+	ToSdkName      []Case[string, string]                           `json:"toSdkName"`
+	ParseSub       []Case[string, []cfn.SubPart]                    `json:"parseSub"`
+	MakeUniqueID   []Case[[]string, string]                         `json:"makeUniqueId"`
+	CustomTimeouts []Case[map[string]any, *customresource.Timeouts] `json:"customTimeouts"`
+}
+
+// DefaultPath returns the location of the corpus relative to the repository root.
+func DefaultPath(root string) string {
+	return filepath.Join(root, "tests", "test-data", "conformance", "corpus.json")
+}
+
+// Load reads and validates the corpus at path.
+func Load(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Corpus
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing corpus %s: %w", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("corpus %s has version %d, this runner understands version %d", path, c.Version, Version)
+	}
+	return &c, nil
+}
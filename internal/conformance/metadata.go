@@ -0,0 +1,73 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pulumi/pulumi-cdk/internal/naming"
+)
+
+// Metadata is the subset of schemas/aws-native-metadata.json needed to check naming rules.
+type Metadata struct {
+	Resources map[string]struct {
+		CfType string `json:"cfType"`
+	} `json:"resources"`
+}
+
+// MetadataPath returns the location of the aws-native metadata relative to the repository root.
+func MetadataPath(root string) string {
+	return filepath.Join(root, "schemas", "aws-native-metadata.json")
+}
+
+// LoadMetadata reads the aws-native metadata.
+func LoadMetadata(path string) (*Metadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing metadata %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// CheckTypeTokens verifies that every CloudFormation type in the metadata maps back to the token it is listed under,
+// which is what `Metadata.tryFindResource` in src/pulumi-metadata.ts relies on. It returns one message per mismatch.
+func (m *Metadata) CheckTypeTokens() []string {
+	var problems []string
+	for token, r := range m.Resources {
+		got, err := naming.TypeToken(r.CfType)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %v", token, err))
+		case got != token:
+			problems = append(problems, fmt.Sprintf("%s maps to %s, expected %s", r.CfType, got, token))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// HasToken reports whether the metadata lists a resource under the given token.
+func (m *Metadata) HasToken(token string) bool {
+	_, ok := m.Resources[token]
+	return ok
+}
@@ -0,0 +1,308 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package customresource emulates the CloudFormation custom resource protocol locally, so that handlers and the
+// custom resource mapping of src/custom-resource-mapping.ts can be tested without Lambda or a response bucket. The
+// emulator sends Create, Update and Delete requests to a Handler, hosts the pre-signed response URL the handler PUTs
+// its response to, checks the response like CloudFormation does and waits no longer than the ServiceTimeout of the
+// resource.
+package customresource
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request types.
+const (
+	Create = "Create"
+	Update = "Update"
+	Delete = "Delete"
+)
+
+// Response statuses.
+const (
+	Success = "SUCCESS"
+	Failed  = "FAILED"
+)
+
+// Request is what CloudFormation sends the handler.
+type Request struct {
+	RequestType           string         `json:"RequestType"`
+	ServiceToken          string         `json:"ServiceToken"`
+	ResponseURL           string         `json:"ResponseURL"`
+	StackID               string         `json:"StackId"`
+	RequestID             string         `json:"RequestId"`
+	LogicalResourceID     string         `json:"LogicalResourceId"`
+	PhysicalResourceID    string         `json:"PhysicalResourceId,omitempty"`
+	ResourceType          string         `json:"ResourceType"`
+	ResourceProperties    map[string]any `json:"ResourceProperties"`
+	OldResourceProperties map[string]any `json:"OldResourceProperties,omitempty"`
+}
+
+// Response is what the handler PUTs to the response URL.
+type Response struct {
+	Status             string         `json:"Status"`
+	Reason             string         `json:"Reason,omitempty"`
+	PhysicalResourceID string         `json:"PhysicalResourceId"`
+	StackID            string         `json:"StackId"`
+	RequestID          string         `json:"RequestId"`
+	LogicalResourceID  string         `json:"LogicalResourceId"`
+	NoEcho             bool           `json:"NoEcho,omitempty"`
+	Data               map[string]any `json:"Data,omitempty"`
+}
+
+// Resource is a custom resource of a template.
+type Resource struct {
+	LogicalID string
+	// Type is AWS::CloudFormation::CustomResource or Custom::<name>.
+	Type string
+	// Properties include ServiceToken, and ServiceTimeout if the resource has one.
+	Properties map[string]any
+}
+
+// Limits of CloudFormation on responses.
+const (
+	maxResponseSize   = 4096
+	maxPhysicalIDSize = 1024
+)
+
+// DefaultTimeout is how long CloudFormation waits for a response of a resource without ServiceTimeout.
+const DefaultTimeout = time.Hour
+
+var (
+	// ErrTimeout is returned when the handler did not respond in time.
+	ErrTimeout = errors.New("timed out waiting for a response")
+	// ErrFailed is returned when the handler responded with FAILED.
+	ErrFailed = errors.New("the custom resource failed")
+	// ErrInvalidResponse is returned when CloudFormation would reject the response.
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// Emulator is a running emulator. Close stops it.
+type Emulator struct {
+	// Handler handles the requests.
+	Handler Handler
+	// StackID is the StackId of the requests.
+	StackID string
+	// Timeout is how long to wait for a response of a resource without ServiceTimeout, DefaultTimeout by default.
+	Timeout time.Duration
+
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	pending map[string]*pendingResponse
+}
+
+// pendingResponse is a response URL that has not been used yet.
+type pendingResponse struct {
+	signature string
+	body      chan []byte
+}
+
+// Start starts an emulator for the handler.
+func Start(h Handler) *Emulator {
+	e := &Emulator{
+		Handler: h,
+		StackID: "arn:aws:cloudformation:us-east-2:123456789012:stack/emulator/00000000-0000-0000-0000-000000000000",
+		pending: map[string]*pendingResponse{},
+	}
+	e.srv = httptest.NewServer(http.HandlerFunc(e.serveResponse))
+	return e
+}
+
+// Close stops the emulator.
+func (e *Emulator) Close() {
+	e.srv.Close()
+}
+
+// Create creates a resource.
+func (e *Emulator) Create(ctx context.Context, r Resource) (*Response, error) {
+	return e.send(ctx, r, Request{RequestType: Create})
+}
+
+// Update updates a resource. A response with another physical ID replaces the resource: like CloudFormation, the
+// emulator then deletes the old one with its old properties.
+func (e *Emulator) Update(ctx context.Context, r Resource, physicalID string, old map[string]any) (*Response, error) {
+	resp, err := e.send(ctx, r, Request{
+		RequestType:           Update,
+		PhysicalResourceID:    physicalID,
+		OldResourceProperties: old,
+	})
+	if err != nil || resp.PhysicalResourceID == physicalID {
+		return resp, err
+	}
+	if _, err := e.Delete(ctx, Resource{LogicalID: r.LogicalID, Type: r.Type, Properties: old}, physicalID); err != nil {
+		return resp, fmt.Errorf("cleaning up the replaced %s: %w", physicalID, err)
+	}
+	return resp, nil
+}
+
+// Delete deletes a resource.
+func (e *Emulator) Delete(ctx context.Context, r Resource, physicalID string) (*Response, error) {
+	return e.send(ctx, r, Request{RequestType: Delete, PhysicalResourceID: physicalID})
+}
+
+func (e *Emulator) send(ctx context.Context, r Resource, req Request) (*Response, error) {
+	timeout, err := e.timeout(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	p := &pendingResponse{signature: signature, body: make(chan []byte, 1)}
+	e.mu.Lock()
+	e.pending[id] = p
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		delete(e.pending, id)
+		e.mu.Unlock()
+	}()
+
+	token, _ := r.Properties["ServiceToken"].(string)
+	req.ServiceToken = token
+	req.ResponseURL = fmt.Sprintf("%s/responses/%s?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Expires=7200&X-Amz-Signature=%s",
+		e.srv.URL, id, signature)
+	req.StackID = e.StackID
+	req.RequestID = id
+	req.LogicalResourceID = r.LogicalID
+	req.ResourceType = r.Type
+	req.ResourceProperties = r.Properties
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	invoked := make(chan error, 1)
+	go func() { invoked <- e.Handler.Invoke(ctx, req) }()
+	for {
+		select {
+		case body := <-p.body:
+			return check(req, body)
+		case err := <-invoked:
+			if err != nil {
+				return nil, fmt.Errorf("%s of %s: invoking the handler: %w", req.RequestType, r.LogicalID, err)
+			}
+			// Handlers may respond after the invocation returns.
+			invoked = nil
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s of %s: %w after %s", req.RequestType, r.LogicalID, ErrTimeout, timeout)
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// timeout is how long to wait for a response of the resource.
+func (e *Emulator) timeout(r Resource) (time.Duration, error) {
+	if v, ok := r.Properties["ServiceTimeout"]; ok {
+		return ServiceTimeout(v)
+	}
+	if e.Timeout != 0 {
+		return e.Timeout, nil
+	}
+	return DefaultTimeout, nil
+}
+
+// serveResponse accepts a response on a pre-signed URL, like S3 would: only a PUT with the signature of the URL.
+func (e *Emulator) serveResponse(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutPrefix(r.URL.Path, "/responses/")
+	e.mu.Lock()
+	p := e.pending[id]
+	e.mu.Unlock()
+	if !ok || p == nil || r.URL.Query().Get("X-Amz-Signature") != p.signature {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	select {
+	case p.body <- body:
+	default:
+		// CloudFormation reads the first response.
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// check reads a response the way CloudFormation does.
+func check(req Request, body []byte) (*Response, error) {
+	var problems []string
+	if len(body) > maxResponseSize {
+		problems = append(problems, fmt.Sprintf("the response is %d bytes, more than %d", len(body), maxResponseSize))
+	}
+	var resp Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("%s of %s: %w: %v", req.RequestType, req.LogicalResourceID, ErrInvalidResponse, err)
+	}
+	if resp.Status != Success && resp.Status != Failed {
+		problems = append(problems, fmt.Sprintf("Status is %q, not %s or %s", resp.Status, Success, Failed))
+	}
+	for _, echo := range []struct{ name, got, want string }{
+		{"StackId", resp.StackID, req.StackID},
+		{"RequestId", resp.RequestID, req.RequestID},
+		{"LogicalResourceId", resp.LogicalResourceID, req.LogicalResourceID},
+	} {
+		if echo.got != echo.want {
+			problems = append(problems, fmt.Sprintf("%s is %q, not %q of the request", echo.name, echo.got, echo.want))
+		}
+	}
+	switch {
+	case resp.PhysicalResourceID == "":
+		problems = append(problems, "PhysicalResourceId is missing")
+	case len(resp.PhysicalResourceID) > maxPhysicalIDSize:
+		problems = append(problems, fmt.Sprintf("PhysicalResourceId is longer than %d bytes", maxPhysicalIDSize))
+	case req.RequestType == Delete && resp.PhysicalResourceID != req.PhysicalResourceID:
+		problems = append(problems, fmt.Sprintf("PhysicalResourceId of the Delete is %q, not %q of the resource",
+			resp.PhysicalResourceID, req.PhysicalResourceID))
+	}
+	if len(problems) > 0 {
+		return &resp, fmt.Errorf("%s of %s: %w: %s", req.RequestType, req.LogicalResourceID, ErrInvalidResponse,
+			strings.Join(problems, "; "))
+	}
+	if resp.Status == Failed {
+		return &resp, fmt.Errorf("%s of %s: %w: %s", req.RequestType, req.LogicalResourceID, ErrFailed, resp.Reason)
+	}
+	return &resp, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
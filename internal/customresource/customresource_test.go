@@ -0,0 +1,282 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customresource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resource(props map[string]any) Resource {
+	p := map[string]any{"ServiceToken": "arn:aws:lambda:us-east-2:123456789012:function:handler"}
+	for k, v := range props {
+		p[k] = v
+	}
+	return Resource{LogicalID: "Thing", Type: "Custom::Thing", Properties: p}
+}
+
+// respond PUTs a response to the response URL of a request.
+func respond(req Request, resp Response) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequest(http.MethodPut, req.ResponseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// success is the response of a handler that did what it was asked.
+func success(req Request, physicalID string) Response {
+	return Response{
+		Status:             Success,
+		PhysicalResourceID: physicalID,
+		StackID:            req.StackID,
+		RequestID:          req.RequestID,
+		LogicalResourceID:  req.LogicalResourceID,
+	}
+}
+
+func requireNode(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+}
+
+func TestNodeHandler(t *testing.T) {
+	requireNode(t)
+	e := Start(NodeHandler{Script: "testdata/handler.js"})
+	defer e.Close()
+	ctx := context.Background()
+
+	created, err := e.Create(ctx, resource(map[string]any{"Name": "a"}))
+	require.NoError(t, err)
+	assert.Equal(t, "thing-a", created.PhysicalResourceID)
+	assert.Equal(t, map[string]any{"Greeting": "hello a", "HasTime": true}, created.Data)
+
+	updated, err := e.Update(ctx, resource(map[string]any{"Name": "a", "Other": "x"}), "thing-a",
+		resource(map[string]any{"Name": "a"}).Properties)
+	require.NoError(t, err)
+	assert.Equal(t, "thing-a", updated.PhysicalResourceID, "an update in place keeps the physical ID")
+
+	replaced, err := e.Update(ctx, resource(map[string]any{"Name": "b"}), "thing-a",
+		resource(map[string]any{"Name": "a"}).Properties)
+	require.NoError(t, err)
+	assert.Equal(t, "thing-b", replaced.PhysicalResourceID)
+
+	_, err = e.Delete(ctx, resource(map[string]any{"Name": "b"}), "thing-b")
+	require.NoError(t, err)
+}
+
+func TestNodeHandlerErrors(t *testing.T) {
+	requireNode(t)
+	e := Start(NodeHandler{Script: "testdata/handler.js"})
+	defer e.Close()
+	ctx := context.Background()
+
+	_, err := e.Create(ctx, resource(map[string]any{"Name": "a", "Behavior": "fail"}))
+	assert.ErrorIs(t, err, ErrFailed)
+	assert.ErrorContains(t, err, "no a for you")
+
+	_, err = e.Create(ctx, resource(map[string]any{"Behavior": "throw"}))
+	assert.ErrorContains(t, err, "handler blew up")
+
+	start := time.Now()
+	_, err = e.Create(ctx, resource(map[string]any{"Behavior": "hang", "ServiceTimeout": "1"}))
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 30*time.Second, "the ServiceTimeout bounds the wait")
+}
+
+func TestHTTPHandler(t *testing.T) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	// Like an asynchronous Lambda invocation, the endpoint accepts the request and responds later.
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.ResourceProperties["Reject"] != nil {
+			http.Error(w, "throttled", http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := success(req, "thing")
+			resp.Data = map[string]any{"Type": req.RequestType}
+			assert.NoError(t, respond(req, resp))
+		}()
+	}))
+	defer endpoint.Close()
+	e := Start(HTTPHandler{URL: endpoint.URL})
+	defer e.Close()
+
+	resp, err := e.Create(context.Background(), resource(nil))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"Type": Create}, resp.Data)
+
+	_, err = e.Create(context.Background(), resource(map[string]any{"Reject": true}))
+	assert.ErrorContains(t, err, "429 Too Many Requests: throttled")
+}
+
+func TestResponseChecks(t *testing.T) {
+	for name, c := range map[string]struct {
+		requestType string
+		respond     func(req Request) Response
+		problem     string
+	}{
+		"status": {Create, func(req Request) Response {
+			resp := success(req, "thing")
+			resp.Status = "OK"
+			return resp
+		}, `Status is "OK"`},
+		"missing physical ID": {Create, func(req Request) Response {
+			return success(req, "")
+		}, "PhysicalResourceId is missing"},
+		"long physical ID": {Create, func(req Request) Response {
+			return success(req, strings.Repeat("x", 1025))
+		}, "PhysicalResourceId is longer than 1024 bytes"},
+		"request ID": {Create, func(req Request) Response {
+			resp := success(req, "thing")
+			resp.RequestID = "other"
+			return resp
+		}, `RequestId is "other"`},
+		"logical ID": {Create, func(req Request) Response {
+			resp := success(req, "thing")
+			resp.LogicalResourceID = "Other"
+			return resp
+		}, `LogicalResourceId is "Other"`},
+		"size": {Create, func(req Request) Response {
+			resp := success(req, "thing")
+			resp.Data = map[string]any{"Blob": strings.Repeat("x", 4096)}
+			return resp
+		}, "more than 4096"},
+		"delete changes the physical ID": {Delete, func(req Request) Response {
+			return success(req, "other")
+		}, `PhysicalResourceId of the Delete is "other", not "thing"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := Start(HandlerFunc(func(_ context.Context, req Request) error {
+				return respond(req, c.respond(req))
+			}))
+			defer e.Close()
+			var err error
+			if c.requestType == Delete {
+				_, err = e.Delete(context.Background(), resource(nil), "thing")
+			} else {
+				_, err = e.Create(context.Background(), resource(nil))
+			}
+			assert.ErrorIs(t, err, ErrInvalidResponse)
+			assert.ErrorContains(t, err, c.problem)
+		})
+	}
+}
+
+func TestResponseURL(t *testing.T) {
+	statuses := make(chan int, 3)
+	e := Start(HandlerFunc(func(_ context.Context, req Request) error {
+		for _, url := range []string{
+			strings.Replace(req.ResponseURL, "X-Amz-Signature=", "X-Amz-Signature=0", 1),
+			strings.Replace(req.ResponseURL, req.RequestID, "other", 1),
+		} {
+			r, err := http.NewRequest(http.MethodPut, url, strings.NewReader("{}"))
+			if err != nil {
+				return err
+			}
+			res, err := http.DefaultClient.Do(r)
+			if err != nil {
+				return err
+			}
+			res.Body.Close()
+			statuses <- res.StatusCode
+		}
+		res, err := http.Get(req.ResponseURL)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		statuses <- res.StatusCode
+		return nil
+	}))
+	defer e.Close()
+	e.Timeout = 100 * time.Millisecond
+
+	_, err := e.Create(context.Background(), resource(nil))
+	assert.ErrorIs(t, err, ErrTimeout, "a response to a tampered URL never arrives")
+	assert.Equal(t, http.StatusForbidden, <-statuses)
+	assert.Equal(t, http.StatusForbidden, <-statuses)
+	assert.Equal(t, http.StatusMethodNotAllowed, <-statuses)
+}
+
+func TestReplacement(t *testing.T) {
+	var mu sync.Mutex
+	var requests []Request
+	e := Start(HandlerFunc(func(_ context.Context, req Request) error {
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		physicalID := req.PhysicalResourceID
+		if req.RequestType != Delete {
+			physicalID = "thing-" + req.ResourceProperties["Name"].(string)
+		}
+		return respond(req, success(req, physicalID))
+	}))
+	defer e.Close()
+
+	old := resource(map[string]any{"Name": "a"}).Properties
+	resp, err := e.Update(context.Background(), resource(map[string]any{"Name": "b"}), "thing-a", old)
+	require.NoError(t, err)
+	assert.Equal(t, "thing-b", resp.PhysicalResourceID)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 2)
+	assert.Equal(t, Update, requests[0].RequestType)
+	assert.Equal(t, old, requests[0].OldResourceProperties)
+	assert.Equal(t, Delete, requests[1].RequestType, "the replaced resource is deleted")
+	assert.Equal(t, "thing-a", requests[1].PhysicalResourceID)
+	assert.Equal(t, old, requests[1].ResourceProperties)
+}
+
+func TestServiceTimeout(t *testing.T) {
+	for v, want := range map[any]time.Duration{300.0: 5 * time.Minute, "60": time.Minute, 1.5: 1500 * time.Millisecond} {
+		got, err := ServiceTimeout(v)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "ServiceTimeout %v", v)
+	}
+	for _, v := range []any{"soon", 0.0, -1.0} {
+		_, err := ServiceTimeout(v)
+		assert.Error(t, err, "ServiceTimeout %v", v)
+	}
+}
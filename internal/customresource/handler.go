@@ -0,0 +1,141 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customresource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Handler handles custom resource requests, like the Lambda function behind a ServiceToken. It responds by PUTting a
+// Response to the ResponseURL of the request, during or after Invoke.
+type Handler interface {
+	Invoke(ctx context.Context, req Request) error
+}
+
+// HandlerFunc is a Handler in Go.
+type HandlerFunc func(ctx context.Context, req Request) error
+
+// Invoke calls f.
+func (f HandlerFunc) Invoke(ctx context.Context, req Request) error {
+	return f(ctx, req)
+}
+
+// HTTPHandler POSTs requests to an endpoint, e.g. a local stand-in of a Lambda function.
+type HTTPHandler struct {
+	URL string
+	// Client is http.DefaultClient by default.
+	Client *http.Client
+}
+
+// Invoke POSTs the request as JSON and fails on any status but 2xx.
+func (h HTTPHandler) Invoke(ctx context.Context, req Request) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", h.URL, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// NodeHandler runs an exported function of a Node.js script the way Lambda would, with the request as the event and a
+// context whose getRemainingTimeInMillis counts down to the timeout.
+type NodeHandler struct {
+	// Script is the path of the script.
+	Script string
+	// Export is the name of the function, handler by default.
+	Export string
+	// Env is added to the environment of node.
+	Env []string
+}
+
+// nodeRunner requires the script and calls the handler with the event it reads from stdin.
+const nodeRunner = `
+const [script, name, deadline] = process.argv.slice(1);
+let input = '';
+process.stdin.on('data', (d) => (input += d));
+process.stdin.on('end', async () => {
+    const event = JSON.parse(input);
+    const context = {
+        awsRequestId: event.RequestId,
+        functionName: 'customresource-emulator',
+        logStreamName: 'local',
+        getRemainingTimeInMillis: () => Math.max(0, Number(deadline) - Date.now()),
+    };
+    try {
+        await require(script)[name](event, context);
+    } catch (e) {
+        console.error(e);
+        process.exitCode = 1;
+    }
+});
+`
+
+// Invoke runs node until the handler settles. The request is cancelled, and node killed, at the timeout.
+func (h NodeHandler) Invoke(ctx context.Context, req Request) error {
+	event, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	export := h.Export
+	if export == "" {
+		export = "handler"
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	script, err := filepath.Abs(h.Script)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "node", "-e", nodeRunner, script, export,
+		strconv.FormatInt(deadline.UnixMilli(), 10))
+	cmd.Env = append(os.Environ(), h.Env...)
+	cmd.Stdin = bytes.NewReader(event)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("node %s: %w\n%s", h.Script, err, output.Bytes())
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// A custom resource handler for the emulator tests. ResourceProperties.Behavior picks what it does.
+exports.handler = async (event, context) => {
+    const props = event.ResourceProperties;
+    const respond = (status, physicalId, extra) =>
+        fetch(event.ResponseURL, {
+            method: 'PUT',
+            body: JSON.stringify({
+                Status: status,
+                PhysicalResourceId: physicalId,
+                StackId: event.StackId,
+                RequestId: event.RequestId,
+                LogicalResourceId: event.LogicalResourceId,
+                ...extra,
+            }),
+        });
+
+    switch (props.Behavior) {
+        case 'fail':
+            return respond('FAILED', event.PhysicalResourceId ?? 'failed', { Reason: `no ${props.Name} for you` });
+        case 'throw':
+            throw new Error('handler blew up');
+        case 'hang':
+            return new Promise(() => setTimeout(() => {}, 60000));
+    }
+    const physicalId = event.RequestType === 'Delete' ? event.PhysicalResourceId : `thing-${props.Name}`;
+    return respond('SUCCESS', physicalId, {
+        Data: { Greeting: `hello ${props.Name}`, HasTime: context.getRemainingTimeInMillis() > 0 },
+    });
+};
@@ -0,0 +1,58 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package customresource
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Timeouts are the customTimeouts resource option of a Pulumi resource.
+type Timeouts struct {
+	Create string `json:"create"`
+	Update string `json:"update"`
+	Delete string `json:"delete"`
+}
+
+// CustomTimeouts mirrors convertToCustomTimeouts in src/custom-resource-mapping.ts: the ServiceTimeout of a custom
+// resource, in seconds, bounds all of its operations. A resource without one has none.
+func CustomTimeouts(serviceTimeout any) *Timeouts {
+	if serviceTimeout == nil {
+		return nil
+	}
+	d := seconds(serviceTimeout) + "s"
+	return &Timeouts{Create: d, Update: d, Delete: d}
+}
+
+// ServiceTimeout parses the ServiceTimeout property, which is a number of seconds or a string of one.
+func ServiceTimeout(v any) (time.Duration, error) {
+	s, err := strconv.ParseFloat(seconds(v), 64)
+	if err != nil || s <= 0 {
+		return 0, fmt.Errorf("ServiceTimeout %v is not a positive number of seconds", v)
+	}
+	return time.Duration(s * float64(time.Second)), nil
+}
+
+// seconds formats a number of seconds the way JavaScript does in a template string.
+func seconds(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	}
+	return fmt.Sprint(v)
+}
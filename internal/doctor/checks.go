@@ -0,0 +1,292 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/backend"
+	"github.com/pulumi/pulumi-cdk/internal/regions"
+)
+
+// tool is a tool pinned in mise.toml.
+type tool struct {
+	// key is the name in the [tools] table.
+	key  string
+	bin  string
+	args []string
+	// required tools fail the check when missing, the others only warn.
+	required bool
+}
+
+var tools = []tool{
+	{key: "node", bin: "node", args: []string{"--version"}, required: true},
+	{key: "npm:yarn", bin: "yarn", args: []string{"--version"}, required: true},
+	{key: "github:pulumi/pulumi", bin: "pulumi", args: []string{"version"}, required: true},
+	{key: "go", bin: "go", args: []string{"version"}, required: true},
+	{key: "gotestsum", bin: "gotestsum", args: []string{"--version"}},
+	{key: "github:pulumi/pulumictl", bin: "pulumictl", args: []string{"version"}},
+}
+
+// ReadMiseTools returns the [tools] table of a mise.toml. Only `key = "value"` lines are understood, which is all
+// the table uses.
+func ReadMiseTools(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	out := map[string]string{}
+	inTools := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inTools = line == "[tools]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inTools || !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(value, "#"); i >= 0 && strings.Count(value[:i], `"`)%2 == 0 &&
+			strings.Count(value[:i], "'")%2 == 0 {
+			value = value[:i]
+		}
+		out[unquote(key)] = unquote(value)
+	}
+	return out, scanner.Err()
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"'`)
+}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// pinned reports whether a mise.toml version is an exact version rather than e.g. "latest" or a template.
+func pinned(version string) bool {
+	return versionPattern.FindString(version) == version
+}
+
+func checkTools(ctx context.Context, env *Env) []Result {
+	want, err := ReadMiseTools(filepath.Join(env.Root, "mise.toml"))
+	if err != nil {
+		return []Result{{Check: "tools", Status: Fail, Detail: err.Error(), Remedy: "run the doctor from the repository"}}
+	}
+	var results []Result
+	for _, tool := range tools {
+		version, ok := want[tool.key]
+		if !ok {
+			continue
+		}
+		check := "tool " + tool.bin
+		cctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		out, err := env.Command(cctx, tool.bin, tool.args...)
+		cancel()
+		if err != nil {
+			status := Warn
+			if tool.required {
+				status = Fail
+			}
+			results = append(results, Result{
+				Check:  check,
+				Status: status,
+				Detail: fmt.Sprintf("%s is not available: %v", tool.bin, err),
+				Remedy: "run `mise install` in " + env.Root + " and activate mise in your shell",
+			})
+			continue
+		}
+		got := versionPattern.FindString(out)
+		switch {
+		case !pinned(version):
+			results = append(results, Result{Check: check, Status: OK, Detail: got})
+		case got != version:
+			results = append(results, Result{
+				Check:  check,
+				Status: Warn,
+				Detail: fmt.Sprintf("found %s, mise.toml pins %s", got, version),
+				Remedy: "run `mise install` to use the pinned version",
+			})
+		default:
+			results = append(results, Result{Check: check, Status: OK, Detail: got})
+		}
+	}
+	return results
+}
+
+// LinkEnvVar makes the tests link the @pulumi/cdk registered with `yarn link` instead of packing the working tree.
+const LinkEnvVar = "PULUMI_CDK_TEST_LINK"
+
+// checkBuild checks that the tests can build @pulumi/cdk. When they link it, lib/ must hold a build of src/ that is
+// newer than every source file.
+func checkBuild(ctx context.Context, env *Env) []Result {
+	const check = "build"
+	remedy := "run `yarn install && yarn build` in " + env.Root
+	if env.Getenv(LinkEnvVar) == "" {
+		if _, err := os.Stat(filepath.Join(env.Root, "node_modules", ".bin", "tsc")); err != nil {
+			return []Result{{
+				Check:  check,
+				Status: Fail,
+				Detail: "the dependencies of @pulumi/cdk are not installed, the tests cannot pack it",
+				Remedy: "run `yarn install` in " + env.Root,
+			}}
+		}
+		return []Result{{Check: check, Status: OK, Detail: "the tests build and pack @pulumi/cdk"}}
+	}
+	index := filepath.Join(env.Root, "lib", "index.js")
+	built, err := os.Stat(index)
+	if err != nil {
+		return []Result{{Check: check, Status: Fail, Detail: "lib/index.js is missing", Remedy: remedy}}
+	}
+	var newest string
+	var newestTime time.Time
+	err = filepath.WalkDir(filepath.Join(env.Root, "src"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".ts") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(newestTime) {
+			newest, newestTime = path, info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return []Result{{Check: check, Status: Fail, Detail: err.Error(), Remedy: remedy}}
+	}
+	if newestTime.After(built.ModTime()) {
+		rel, _ := filepath.Rel(env.Root, newest)
+		return []Result{{
+			Check:  check,
+			Status: Warn,
+			Detail: fmt.Sprintf("%s changed after lib/ was built, the tests would not use the change", rel),
+			Remedy: remedy,
+		}}
+	}
+	return []Result{{Check: check, Status: OK, Detail: "lib/ is up to date"}}
+}
+
+// checkLink checks that `yarn link @pulumi/cdk`, which linking tests run in every program, picks up this repository.
+func checkLink(ctx context.Context, env *Env) []Result {
+	const check = "yarn link"
+	if env.Getenv(LinkEnvVar) == "" {
+		return []Result{{Check: check, Status: OK, Detail: "not used, the tests install a packed @pulumi/cdk"}}
+	}
+	remedy := "run `yarn link` in " + env.Root
+	link := filepath.Join(env.LinkDir, "@pulumi", "cdk")
+	target, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return []Result{{Check: check, Status: Fail, Detail: "@pulumi/cdk is not linked", Remedy: remedy}}
+	}
+	root, err := filepath.EvalSymlinks(env.Root)
+	if err != nil {
+		root = env.Root
+	}
+	if target != root {
+		return []Result{{
+			Check:  check,
+			Status: Fail,
+			Detail: fmt.Sprintf("@pulumi/cdk is linked to %s", target),
+			Remedy: "run `yarn unlink` in " + target + ", then " + remedy,
+		}}
+	}
+	return []Result{{Check: check, Status: OK, Detail: "@pulumi/cdk is linked to " + target}}
+}
+
+// checkBackend checks the Pulumi backend the tests use: a local backend per run, or with backend.AmbientEnvVar the
+// one the environment configures, i.e. a temporary local backend per test or the Pulumi Cloud when
+// PULUMI_TEST_USE_SERVICE is set.
+func checkBackend(ctx context.Context, env *Env) []Result {
+	const check = "backend"
+	if env.Getenv(backend.AmbientEnvVar) == "" {
+		return []Result{{Check: check, Status: OK, Detail: "the run uses its own local backend and passphrase"}}
+	}
+	if env.Getenv("PULUMI_TEST_USE_SERVICE") != "true" {
+		return []Result{{Check: check, Status: OK, Detail: "every test uses a temporary local backend"}}
+	}
+	if env.Getenv("PULUMI_ACCESS_TOKEN") == "" {
+		return []Result{{
+			Check:  check,
+			Status: Fail,
+			Detail: "PULUMI_TEST_USE_SERVICE is set but PULUMI_ACCESS_TOKEN is not, the tests will be skipped",
+			Remedy: "export PULUMI_ACCESS_TOKEN, or unset PULUMI_TEST_USE_SERVICE to use local backends",
+		}}
+	}
+	cctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	out, err := env.Command(cctx, "pulumi", "whoami")
+	if err != nil {
+		return []Result{{
+			Check:  check,
+			Status: Fail,
+			Detail: fmt.Sprintf("pulumi whoami: %v: %s", err, out),
+			Remedy: "check PULUMI_ACCESS_TOKEN and PULUMI_API",
+		}}
+	}
+	return []Result{{Check: check, Status: OK, Detail: "Pulumi Cloud as " + out}}
+}
+
+// checkAWS checks the region matrix and the caller identity, in the first region of the matrix.
+func checkAWS(ctx context.Context, env *Env) []Result {
+	matrix, err := regions.Matrix(env.Getenv)
+	if err != nil {
+		return []Result{
+			{Check: "region", Status: Fail, Detail: err.Error(), Remedy: "list regions like us-east-2,eu-west-1"},
+			{Check: "identity", Status: Warn, Detail: "not checked without a region"},
+		}
+	}
+	if len(matrix) == 0 {
+		return []Result{
+			{
+				Check:  "region",
+				Status: Fail,
+				Detail: "AWS_REGION is not set, nor " + regions.EnvVar + ", the acceptance tests will be skipped",
+				Remedy: "export AWS_REGION=us-east-2, the region CI uses",
+			},
+			{Check: "identity", Status: Warn, Detail: "not checked without a region"},
+		}
+	}
+	region := matrix[0]
+	results := []Result{{Check: "region", Status: OK, Detail: strings.Join(matrix, ", ")}}
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	account, arn, err := env.Identity(cctx, region)
+	if err != nil {
+		return append(results, Result{
+			Check:  "identity",
+			Status: Fail,
+			Detail: fmt.Sprintf("sts:GetCallerIdentity failed: %v", err),
+			Remedy: "log in to AWS, e.g. `aws sso login` or export AWS_PROFILE or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY" +
+				"; set " + STSEndpointEnvVar + " to use a fake STS",
+		})
+	}
+	detail := fmt.Sprintf("account %s as %s", account, arn)
+	if endpoint := env.Getenv(STSEndpointEnvVar); endpoint != "" {
+		detail += " (STS at " + endpoint + ")"
+	}
+	return append(results, Result{Check: "identity", Status: OK, Detail: detail})
+}
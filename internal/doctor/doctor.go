@@ -0,0 +1,156 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package doctor checks that the machine can run the acceptance tests: the tools pinned in mise.toml, a built and
+// linked @pulumi/cdk, a Pulumi backend, an AWS region and AWS credentials. Every failed check comes with a
+// remediation, so that tests do not skip silently or fail in confusing ways.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Status of a check.
+type Status string
+
+const (
+	OK   Status = "ok"
+	Warn Status = "warn"
+	Fail Status = "FAIL"
+)
+
+// Result is the outcome of a check.
+type Result struct {
+	Check  string
+	Status Status
+	Detail string
+	// Remedy says how to fix a warning or failure.
+	Remedy string
+}
+
+// Env is what the checks look at. Tests replace the functions to check a machine they do not run on.
+type Env struct {
+	// Root is the root of the repository.
+	Root   string
+	Getenv func(string) string
+	// Command runs a tool and returns its trimmed combined output.
+	Command func(ctx context.Context, name string, args ...string) (string, error)
+	// Identity returns the caller identity of the AWS credentials, through STS.
+	Identity func(ctx context.Context, region string) (account, arn string, err error)
+	// LinkDir is the directory `yarn link` registers packages in.
+	LinkDir string
+}
+
+// STSEndpointEnvVar points the identity check at a fake STS. The AWS SDK honours it for every STS client.
+const STSEndpointEnvVar = "AWS_ENDPOINT_URL_STS"
+
+// DefaultEnv checks the current machine.
+func DefaultEnv(root string) *Env {
+	return &Env{
+		Root:   root,
+		Getenv: os.Getenv,
+		Command: func(ctx context.Context, name string, args ...string) (string, error) {
+			out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+			return strings.TrimSpace(string(out)), err
+		},
+		Identity: func(ctx context.Context, region string) (string, string, error) {
+			cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+			if err != nil {
+				return "", "", err
+			}
+			out, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+			if err != nil {
+				return "", "", err
+			}
+			return aws.ToString(out.Account), aws.ToString(out.Arn), nil
+		},
+		LinkDir: yarnLinkDir(),
+	}
+}
+
+// yarnLinkDir is where yarn 1 keeps the packages registered with `yarn link`.
+func yarnLinkDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "Yarn", "config", "link")
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "yarn", "link")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "yarn", "link")
+}
+
+// FindRoot returns the closest directory at or above dir that holds mise.toml.
+func FindRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "mise.toml")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no mise.toml found above %s", dir)
+		}
+		dir = parent
+	}
+}
+
+// Run runs every check.
+func Run(ctx context.Context, env *Env) []Result {
+	var results []Result
+	for _, check := range []func(context.Context, *Env) []Result{
+		checkTools, checkBuild, checkLink, checkBackend, checkAWS,
+	} {
+		results = append(results, check(ctx, env)...)
+	}
+	return results
+}
+
+// Failed reports whether any check failed.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the results, with the remedy under every warning and failure. With problemsOnly, passed checks are
+// left out.
+func Print(w io.Writer, results []Result, problemsOnly bool) {
+	for _, r := range results {
+		if problemsOnly && r.Status == OK {
+			continue
+		}
+		fmt.Fprintf(w, "%-4s  %s: %s\n", r.Status, r.Check, r.Detail)
+		if r.Status != OK && r.Remedy != "" {
+			fmt.Fprintf(w, "      -> %s\n", r.Remedy)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/backend"
+	"github.com/pulumi/pulumi-cdk/internal/regions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const miseTOML = `[env]
+_.vfox-pulumi = { module_path = "." } # Sets GO_VERSION_MISE
+
+[tools]
+node = "24.12.0"
+"npm:yarn" = "1.22.22"
+"github:pulumi/pulumictl" = '0.0.50'
+go = "{{ env.GO_VERSION_MISE }}"
+"github:pulumi/pulumi" = "latest"
+
+[settings]
+experimental = true # Required for Go binaries
+`
+
+func TestReadMiseTools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mise.toml")
+	require.NoError(t, os.WriteFile(path, []byte(miseTOML), 0o600))
+	tools, err := ReadMiseTools(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"node":                    "24.12.0",
+		"npm:yarn":                "1.22.22",
+		"github:pulumi/pulumictl": "0.0.50",
+		"go":                      "{{ env.GO_VERSION_MISE }}",
+		"github:pulumi/pulumi":    "latest",
+	}, tools)
+}
+
+// fakeRepo returns the root of a repository with a built lib/ and an env whose tools all match mise.toml.
+func fakeRepo(t *testing.T) *Env {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mise.toml"), []byte(miseTOML), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "src"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "index.ts"), nil, 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "lib", "index.js"), nil, 0o600))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "src", "index.ts"), old, old))
+
+	linkDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(linkDir, "@pulumi"), 0o755))
+	require.NoError(t, os.Symlink(root, filepath.Join(linkDir, "@pulumi", "cdk")))
+
+	vars := map[string]string{"AWS_REGION": "us-east-2", LinkEnvVar: "true"}
+	versions := map[string]string{
+		"node":      "v24.12.0",
+		"yarn":      "1.22.22",
+		"pulumi":    "v3.200.0",
+		"go":        "go version go1.24.11 linux/amd64",
+		"pulumictl": "v0.0.50",
+	}
+	return &Env{
+		Root:   root,
+		Getenv: func(k string) string { return vars[k] },
+		Command: func(ctx context.Context, name string, args ...string) (string, error) {
+			if v, ok := versions[name]; ok {
+				return v, nil
+			}
+			return "", errors.New("executable file not found in $PATH")
+		},
+		Identity: func(ctx context.Context, region string) (string, string, error) {
+			return "123456789012", "arn:aws:iam::123456789012:user/ci", nil
+		},
+		LinkDir: linkDir,
+	}
+}
+
+func statuses(results []Result) map[string]Status {
+	out := map[string]Status{}
+	for _, r := range results {
+		out[r.Check] = r.Status
+	}
+	return out
+}
+
+func TestRun(t *testing.T) {
+	env := fakeRepo(t)
+	results := Run(context.Background(), env)
+	assert.False(t, Failed(results))
+	assert.Equal(t, map[string]Status{
+		"tool node":      OK,
+		"tool yarn":      OK,
+		"tool pulumi":    OK,
+		"tool go":        OK,
+		"tool pulumictl": OK,
+		"build":          OK,
+		"yarn link":      OK,
+		"backend":        OK,
+		"region":         OK,
+		"identity":       OK,
+	}, statuses(results))
+
+	var out bytes.Buffer
+	Print(&out, results, true)
+	assert.Empty(t, out.String())
+}
+
+func TestRunProblems(t *testing.T) {
+	env := fakeRepo(t)
+	command := env.Command
+	env.Command = func(ctx context.Context, name string, args ...string) (string, error) {
+		switch name {
+		case "yarn":
+			return "1.22.19", nil
+		case "pulumi":
+			return "", errors.New("executable file not found in $PATH")
+		}
+		return command(ctx, name, args...)
+	}
+	env.Getenv = func(k string) string {
+		return map[string]string{
+			"PULUMI_TEST_USE_SERVICE": "true",
+			backend.AmbientEnvVar:     "true",
+			LinkEnvVar:                "true",
+		}[k]
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(env.Root, "src", "new.ts"), nil, 0o600))
+	require.NoError(t, os.Remove(filepath.Join(env.LinkDir, "@pulumi", "cdk")))
+
+	results := Run(context.Background(), env)
+	assert.True(t, Failed(results))
+	s := statuses(results)
+	assert.Equal(t, Warn, s["tool yarn"])
+	assert.Equal(t, Fail, s["tool pulumi"])
+	assert.Equal(t, Warn, s["build"])
+	assert.Equal(t, Fail, s["yarn link"])
+	assert.Equal(t, Fail, s["backend"])
+	assert.Equal(t, Fail, s["region"])
+
+	var out bytes.Buffer
+	Print(&out, results, true)
+	assert.Contains(t, out.String(), "warn  tool yarn: found 1.22.19, mise.toml pins 1.22.22\n"+
+		"      -> run `mise install` to use the pinned version\n")
+	assert.Contains(t, out.String(), "FAIL  region: AWS_REGION is not set")
+	assert.NotContains(t, out.String(), "tool node")
+}
+
+func TestRegionMatrix(t *testing.T) {
+	env := fakeRepo(t)
+	vars := map[string]string{regions.EnvVar: "eu-west-1,us-east-2", LinkEnvVar: "true"}
+	env.Getenv = func(k string) string { return vars[k] }
+	var identityRegion string
+	env.Identity = func(ctx context.Context, region string) (string, string, error) {
+		identityRegion = region
+		return "123456789012", "arn:aws:iam::123456789012:user/ci", nil
+	}
+
+	results := Run(context.Background(), env)
+	assert.Equal(t, OK, statuses(results)["region"])
+	assert.Equal(t, "eu-west-1", identityRegion)
+	var out bytes.Buffer
+	Print(&out, results, false)
+	assert.Contains(t, out.String(), "ok    region: eu-west-1, us-east-2")
+
+	vars[regions.EnvVar] = "eu-west"
+	assert.Equal(t, Fail, statuses(Run(context.Background(), env))["region"])
+}
+
+func TestRunPacking(t *testing.T) {
+	env := fakeRepo(t)
+	getenv := env.Getenv
+	env.Getenv = func(k string) string {
+		if k == LinkEnvVar {
+			return ""
+		}
+		return getenv(k)
+	}
+	require.NoError(t, os.Remove(filepath.Join(env.LinkDir, "@pulumi", "cdk")))
+
+	s := statuses(Run(context.Background(), env))
+	assert.Equal(t, OK, s["yarn link"], "packing tests do not need a link")
+	assert.Equal(t, Fail, s["build"], "packing needs the dependencies")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(env.Root, "node_modules", ".bin"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(env.Root, "node_modules", ".bin", "tsc"), nil, 0o700))
+	assert.Equal(t, OK, statuses(Run(context.Background(), env))["build"])
+}
+
+func TestIdentityThroughFakeSTS(t *testing.T) {
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "GetCallerIdentity", r.Form.Get("Action"))
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write([]byte(`<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:iam::123456789012:user/fake</Arn>
+    <UserId>AIDAFAKE</UserId>
+    <Account>123456789012</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata><RequestId>fake</RequestId></ResponseMetadata>
+</GetCallerIdentityResponse>`))
+	}))
+	defer sts.Close()
+	t.Setenv(STSEndpointEnvVar, sts.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "fake")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "fake")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	account, arn, err := DefaultEnv(t.TempDir()).Identity(context.Background(), "us-east-2")
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", account)
+	assert.Equal(t, "arn:aws:iam::123456789012:user/fake", arn)
+}
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mise.toml"), nil, 0o600))
+	dir := filepath.Join(root, "examples", "bucket")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	found, err := FindRoot(dir)
+	require.NoError(t, err)
+	assert.Equal(t, root, found)
+}
@@ -0,0 +1,723 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Image is an AMI.
+type Image struct {
+	ID      string `json:"imageId"`
+	Name    string `json:"name"`
+	OwnerID string `json:"ownerId"`
+	// OwnerAlias is e.g. "amazon", which matches the owner of the same name in DescribeImages.
+	OwnerAlias   string `json:"ownerAlias,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+	// CreationDate picks the newest image in CDK lookups, e.g. 2024-01-31T00:00:00.000Z.
+	CreationDate string            `json:"creationDate,omitempty"`
+	Platform     string            `json:"platform,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// AvailabilityZone is an availability zone. Without fixtures, the fake has zones a, b and c in the region of each
+// request.
+type AvailabilityZone struct {
+	Name   string `json:"zoneName"`
+	ID     string `json:"zoneId,omitempty"`
+	Region string `json:"regionName,omitempty"`
+	// State is available by default.
+	State string `json:"state,omitempty"`
+}
+
+// Vpc is a VPC.
+type Vpc struct {
+	ID        string            `json:"vpcId"`
+	CidrBlock string            `json:"cidrBlock"`
+	IsDefault bool              `json:"isDefault,omitempty"`
+	OwnerID   string            `json:"ownerId,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+// Subnet is a subnet of a VPC.
+type Subnet struct {
+	ID                  string            `json:"subnetId"`
+	VpcID               string            `json:"vpcId"`
+	AvailabilityZone    string            `json:"availabilityZone"`
+	AvailabilityZoneID  string            `json:"availabilityZoneId,omitempty"`
+	CidrBlock           string            `json:"cidrBlock"`
+	MapPublicIPOnLaunch bool              `json:"mapPublicIpOnLaunch,omitempty"`
+	Tags                map[string]string `json:"tags,omitempty"`
+}
+
+// RouteTable is a route table of a VPC.
+type RouteTable struct {
+	ID     string  `json:"routeTableId"`
+	VpcID  string  `json:"vpcId"`
+	Routes []Route `json:"routes,omitempty"`
+	// Main is set for the main route table, which applies to the subnets with no association.
+	Main bool `json:"main,omitempty"`
+	// Subnets are the subnets explicitly associated with the table.
+	Subnets []string          `json:"subnets,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+// Route is a route in a route table.
+type Route struct {
+	DestinationCidrBlock string `json:"destinationCidrBlock" xml:"destinationCidrBlock"`
+	GatewayID            string `json:"gatewayId,omitempty" xml:"gatewayId,omitempty"`
+	NatGatewayID         string `json:"natGatewayId,omitempty" xml:"natGatewayId,omitempty"`
+	TransitGatewayID     string `json:"transitGatewayId,omitempty" xml:"transitGatewayId,omitempty"`
+}
+
+// VpnGateway is a virtual private gateway.
+type VpnGateway struct {
+	ID string `json:"vpnGatewayId"`
+	// VpcIDs are the VPCs the gateway is attached to.
+	VpcIDs []string          `json:"vpcIds,omitempty"`
+	Tags   map[string]string `json:"tags,omitempty"`
+}
+
+// SecurityGroup is a security group.
+type SecurityGroup struct {
+	ID          string `json:"groupId"`
+	Name        string `json:"groupName"`
+	VpcID       string `json:"vpcId,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Egress are the CIDRs the group allows all outbound traffic to, e.g. 0.0.0.0/0 and ::/0, which the security
+	// group lookup reports as allowAllOutbound and allowAllIpv6Outbound.
+	Egress []string          `json:"egress,omitempty"`
+	Tags   map[string]string `json:"tags,omitempty"`
+}
+
+const ec2Namespace = "http://ec2.amazonaws.com/doc/2016-11-15/"
+
+type tagXML struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+func tagSet(tags map[string]string) []tagXML {
+	set := make([]tagXML, 0, len(tags))
+	for k, v := range tags {
+		set = append(set, tagXML{Key: k, Value: v})
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i].Key < set[j].Key })
+	return set
+}
+
+// tagValues answers the tag filters: tag:<key> and tag-key.
+func tagValues(tags map[string]string, name string) ([]string, bool) {
+	if key, ok := strings.CutPrefix(name, "tag:"); ok {
+		if v, ok := tags[key]; ok {
+			return []string{v}, true
+		}
+		return nil, true
+	}
+	if name == "tag-key" {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		return keys, true
+	}
+	return nil, false
+}
+
+// filter is a DescribeX filter: a resource matches if it has any of the values, which may contain * and ? wildcards.
+type filter struct {
+	Name   string
+	Values []string
+}
+
+// filterable is a resource that DescribeX filters apply to. values returns false for filters it does not know.
+type filterable interface {
+	values(name string) ([]string, bool)
+}
+
+var indexedParam = regexp.MustCompile(`^Filter\.(\d+)\.(Name|Value\.(\d+))$`)
+
+// parseFilters reads the Filter.N.Name and Filter.N.Value.M parameters of a request.
+func parseFilters(form url.Values) []filter {
+	byIndex := map[int]*filter{}
+	for key, vs := range form {
+		m := indexedParam.FindStringSubmatch(key)
+		if m == nil || len(vs) == 0 {
+			continue
+		}
+		i, _ := strconv.Atoi(m[1])
+		f := byIndex[i]
+		if f == nil {
+			f = &filter{}
+			byIndex[i] = f
+		}
+		if m[2] == "Name" {
+			f.Name = vs[0]
+		} else {
+			f.Values = append(f.Values, vs[0])
+		}
+	}
+	indices := make([]int, 0, len(byIndex))
+	for i := range byIndex {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	filters := make([]filter, 0, len(indices))
+	for _, i := range indices {
+		f := byIndex[i]
+		sort.Strings(f.Values)
+		filters = append(filters, *f)
+	}
+	return filters
+}
+
+// listParam reads a list parameter such as ImageId.1, ImageId.2.
+func listParam(form url.Values, name string) []string {
+	var vs []string
+	for i := 1; ; i++ {
+		v := form.Get(fmt.Sprintf("%s.%d", name, i))
+		if v == "" {
+			return vs
+		}
+		vs = append(vs, v)
+	}
+}
+
+// matches applies filters to a resource. It returns an error for a filter the resource does not know.
+func matches(r filterable, filters []filter) (bool, error) {
+	for _, f := range filters {
+		have, ok := r.values(f.Name)
+		if !ok {
+			return false, fmt.Errorf("The filter '%s' is invalid", f.Name)
+		}
+		if !slices.ContainsFunc(have, func(h string) bool {
+			return slices.ContainsFunc(f.Values, func(pattern string) bool {
+				ok, err := path.Match(pattern, h)
+				return err == nil && ok
+			})
+		}) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// selectResources returns the resources with one of the ids, if any are given, that match the filters.
+func selectResources[T filterable](all []T, id func(T) string, ids []string, filters []filter) ([]T, error) {
+	var selected []T
+	for _, r := range all {
+		if len(ids) > 0 && !slices.Contains(ids, id(r)) {
+			continue
+		}
+		ok, err := matches(r, filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+func (i Image) values(name string) ([]string, bool) {
+	switch name {
+	case "image-id":
+		return []string{i.ID}, true
+	case "name":
+		return []string{i.Name}, true
+	case "owner-id":
+		return []string{i.OwnerID}, true
+	case "owner-alias":
+		return []string{i.OwnerAlias}, true
+	case "architecture":
+		return []string{i.architecture()}, true
+	case "platform":
+		return []string{i.Platform}, true
+	case "state":
+		return []string{"available"}, true
+	case "image-type":
+		return []string{"machine"}, true
+	case "virtualization-type":
+		return []string{"hvm"}, true
+	case "root-device-type":
+		return []string{"ebs"}, true
+	case "is-public":
+		return []string{"true"}, true
+	}
+	return tagValues(i.Tags, name)
+}
+
+func (i Image) architecture() string {
+	if i.Architecture == "" {
+		return "x86_64"
+	}
+	return i.Architecture
+}
+
+func (z AvailabilityZone) values(name string) ([]string, bool) {
+	switch name {
+	case "zone-name":
+		return []string{z.Name}, true
+	case "zone-id":
+		return []string{z.ID}, true
+	case "region-name":
+		return []string{z.Region}, true
+	case "state":
+		return []string{z.state()}, true
+	case "zone-type":
+		return []string{"availability-zone"}, true
+	case "opt-in-status":
+		return []string{"opt-in-not-required"}, true
+	}
+	return nil, false
+}
+
+func (z AvailabilityZone) state() string {
+	if z.State == "" {
+		return "available"
+	}
+	return z.State
+}
+
+func (v Vpc) values(name string) ([]string, bool) {
+	switch name {
+	case "vpc-id":
+		return []string{v.ID}, true
+	case "cidr", "cidr-block-association.cidr-block":
+		return []string{v.CidrBlock}, true
+	case "is-default", "isDefault":
+		return []string{strconv.FormatBool(v.IsDefault)}, true
+	case "owner-id":
+		return []string{v.OwnerID}, true
+	case "state":
+		return []string{"available"}, true
+	}
+	return tagValues(v.Tags, name)
+}
+
+func (s Subnet) values(name string) ([]string, bool) {
+	switch name {
+	case "subnet-id":
+		return []string{s.ID}, true
+	case "vpc-id":
+		return []string{s.VpcID}, true
+	case "availability-zone":
+		return []string{s.AvailabilityZone}, true
+	case "availability-zone-id":
+		return []string{s.AvailabilityZoneID}, true
+	case "cidr-block":
+		return []string{s.CidrBlock}, true
+	case "map-public-ip-on-launch":
+		return []string{strconv.FormatBool(s.MapPublicIPOnLaunch)}, true
+	case "state":
+		return []string{"available"}, true
+	}
+	return tagValues(s.Tags, name)
+}
+
+func (t RouteTable) values(name string) ([]string, bool) {
+	switch name {
+	case "route-table-id":
+		return []string{t.ID}, true
+	case "vpc-id":
+		return []string{t.VpcID}, true
+	case "association.subnet-id":
+		return t.Subnets, true
+	case "association.main":
+		return []string{strconv.FormatBool(t.Main)}, true
+	}
+	return tagValues(t.Tags, name)
+}
+
+func (g VpnGateway) values(name string) ([]string, bool) {
+	switch name {
+	case "vpn-gateway-id":
+		return []string{g.ID}, true
+	case "attachment.vpc-id":
+		return g.VpcIDs, true
+	case "attachment.state":
+		if len(g.VpcIDs) > 0 {
+			return []string{"attached"}, true
+		}
+		return nil, true
+	case "state":
+		return []string{"available"}, true
+	case "type":
+		return []string{"ipsec.1"}, true
+	}
+	return tagValues(g.Tags, name)
+}
+
+func (g SecurityGroup) values(name string) ([]string, bool) {
+	switch name {
+	case "group-id":
+		return []string{g.ID}, true
+	case "group-name":
+		return []string{g.Name}, true
+	case "vpc-id":
+		return []string{g.VpcID}, true
+	case "description":
+		return []string{g.Description}, true
+	case "egress.ip-permission.cidr", "egress.ip-permission.ipv6-cidr":
+		return g.Egress, true
+	}
+	return tagValues(g.Tags, name)
+}
+
+type imageXML struct {
+	ID                 string   `xml:"imageId"`
+	Location           string   `xml:"imageLocation"`
+	State              string   `xml:"imageState"`
+	OwnerID            string   `xml:"imageOwnerId"`
+	OwnerAlias         string   `xml:"imageOwnerAlias,omitempty"`
+	CreationDate       string   `xml:"creationDate,omitempty"`
+	IsPublic           bool     `xml:"isPublic"`
+	Architecture       string   `xml:"architecture"`
+	ImageType          string   `xml:"imageType"`
+	Platform           string   `xml:"platform,omitempty"`
+	Name               string   `xml:"name"`
+	RootDeviceType     string   `xml:"rootDeviceType"`
+	RootDeviceName     string   `xml:"rootDeviceName"`
+	VirtualizationType string   `xml:"virtualizationType"`
+	Tags               []tagXML `xml:"tagSet>item,omitempty"`
+}
+
+type availabilityZoneXML struct {
+	Name        string `xml:"zoneName"`
+	ID          string `xml:"zoneId,omitempty"`
+	State       string `xml:"zoneState"`
+	Region      string `xml:"regionName"`
+	Type        string `xml:"zoneType"`
+	OptInStatus string `xml:"optInStatus"`
+}
+
+type vpcXML struct {
+	ID        string   `xml:"vpcId"`
+	OwnerID   string   `xml:"ownerId"`
+	State     string   `xml:"state"`
+	CidrBlock string   `xml:"cidrBlock"`
+	IsDefault bool     `xml:"isDefault"`
+	Tags      []tagXML `xml:"tagSet>item,omitempty"`
+}
+
+type subnetXML struct {
+	ID                  string   `xml:"subnetId"`
+	Arn                 string   `xml:"subnetArn"`
+	OwnerID             string   `xml:"ownerId"`
+	State               string   `xml:"state"`
+	VpcID               string   `xml:"vpcId"`
+	CidrBlock           string   `xml:"cidrBlock"`
+	AvailabilityZone    string   `xml:"availabilityZone"`
+	AvailabilityZoneID  string   `xml:"availabilityZoneId,omitempty"`
+	MapPublicIPOnLaunch bool     `xml:"mapPublicIpOnLaunch"`
+	Tags                []tagXML `xml:"tagSet>item,omitempty"`
+}
+
+type routeTableAssociationXML struct {
+	ID           string `xml:"routeTableAssociationId"`
+	RouteTableID string `xml:"routeTableId"`
+	SubnetID     string `xml:"subnetId,omitempty"`
+	Main         bool   `xml:"main"`
+	State        string `xml:"associationState>state"`
+}
+
+type routeTableXML struct {
+	ID           string                     `xml:"routeTableId"`
+	VpcID        string                     `xml:"vpcId"`
+	OwnerID      string                     `xml:"ownerId"`
+	Routes       []Route                    `xml:"routeSet>item"`
+	Associations []routeTableAssociationXML `xml:"associationSet>item"`
+	Tags         []tagXML                   `xml:"tagSet>item,omitempty"`
+}
+
+type vpnGatewayAttachmentXML struct {
+	VpcID string `xml:"vpcId"`
+	State string `xml:"state"`
+}
+
+type vpnGatewayXML struct {
+	ID          string                    `xml:"vpnGatewayId"`
+	State       string                    `xml:"state"`
+	Type        string                    `xml:"type"`
+	Attachments []vpnGatewayAttachmentXML `xml:"attachments>item"`
+	Tags        []tagXML                  `xml:"tagSet>item,omitempty"`
+}
+
+type ipRangeXML struct {
+	CidrIP string `xml:"cidrIp"`
+}
+
+type ipv6RangeXML struct {
+	CidrIPv6 string `xml:"cidrIpv6"`
+}
+
+type ipPermissionXML struct {
+	Protocol   string         `xml:"ipProtocol"`
+	IPRanges   []ipRangeXML   `xml:"ipRanges>item"`
+	IPv6Ranges []ipv6RangeXML `xml:"ipv6Ranges>item"`
+}
+
+type securityGroupXML struct {
+	ID          string            `xml:"groupId"`
+	Name        string            `xml:"groupName"`
+	OwnerID     string            `xml:"ownerId"`
+	VpcID       string            `xml:"vpcId,omitempty"`
+	Description string            `xml:"groupDescription"`
+	Egress      []ipPermissionXML `xml:"ipPermissionsEgress>item"`
+	Tags        []tagXML          `xml:"tagSet>item,omitempty"`
+}
+
+// describeResponse is the response of a DescribeX call. Items are marshalled as <set><item>...</item></set>.
+type describeResponse struct {
+	XMLName   xml.Name
+	Xmlns     string `xml:"xmlns,attr"`
+	RequestID string `xml:"requestId"`
+	Set       struct {
+		XMLName xml.Name
+		Items   any `xml:"item"`
+	}
+}
+
+func writeDescribe(w http.ResponseWriter, action, set string, items any) {
+	resp := describeResponse{XMLName: xml.Name{Local: action + "Response"}, Xmlns: ec2Namespace, RequestID: requestID}
+	resp.Set.XMLName = xml.Name{Local: set}
+	resp.Set.Items = items
+	writeXML(w, http.StatusOK, resp)
+}
+
+type ec2Error struct {
+	XMLName   xml.Name `xml:"Response"`
+	Code      string   `xml:"Errors>Error>Code"`
+	Message   string   `xml:"Errors>Error>Message"`
+	RequestID string   `xml:"RequestID"`
+}
+
+func writeEC2Error(w http.ResponseWriter, code, message string) {
+	writeXML(w, http.StatusBadRequest, ec2Error{Code: code, Message: message, RequestID: requestID})
+}
+
+// signingRegion returns the region in the SigV4 credential scope of a request, e.g.
+// Credential=AKID/20240131/us-east-2/ec2/aws4_request.
+func signingRegion(r *http.Request) string {
+	_, scope, ok := strings.Cut(r.Header.Get("Authorization"), "Credential=")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(strings.SplitN(scope, ",", 2)[0], "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[2]
+}
+
+func (s *Server) serveEC2(w http.ResponseWriter, r *http.Request) {
+	action := r.Form.Get("Action")
+	s.record("ec2", action)
+	filters := parseFilters(r.Form)
+	s.mu.Lock()
+	f := s.fixtures
+	s.mu.Unlock()
+
+	var err error
+	switch action {
+	case "DescribeImages":
+		var images []Image
+		if images, err = selectResources(f.Images, func(i Image) string { return i.ID }, listParam(r.Form, "ImageId"),
+			filters); err != nil {
+			break
+		}
+		owners := listParam(r.Form, "Owner")
+		items := []imageXML{}
+		for _, i := range images {
+			if len(owners) > 0 && !slices.ContainsFunc(owners, func(o string) bool {
+				return o == i.OwnerID || o == i.OwnerAlias || (o == "self" && i.OwnerID == f.Account)
+			}) {
+				continue
+			}
+			items = append(items, imageXML{
+				ID:                 i.ID,
+				Location:           i.OwnerID + "/" + i.Name,
+				State:              "available",
+				OwnerID:            i.OwnerID,
+				OwnerAlias:         i.OwnerAlias,
+				CreationDate:       i.CreationDate,
+				IsPublic:           true,
+				Architecture:       i.architecture(),
+				ImageType:          "machine",
+				Platform:           i.Platform,
+				Name:               i.Name,
+				RootDeviceType:     "ebs",
+				RootDeviceName:     "/dev/xvda",
+				VirtualizationType: "hvm",
+				Tags:               tagSet(i.Tags),
+			})
+		}
+		writeDescribe(w, action, "imagesSet", items)
+	case "DescribeAvailabilityZones":
+		zones := f.AvailabilityZones
+		if len(zones) == 0 {
+			region := signingRegion(r)
+			for _, suffix := range []string{"a", "b", "c"} {
+				zones = append(zones, AvailabilityZone{Name: region + suffix, Region: region})
+			}
+		}
+		if zones, err = selectResources(zones, func(z AvailabilityZone) string { return z.Name },
+			listParam(r.Form, "ZoneName"), filters); err != nil {
+			break
+		}
+		items := []availabilityZoneXML{}
+		for _, z := range zones {
+			items = append(items, availabilityZoneXML{
+				Name:        z.Name,
+				ID:          z.ID,
+				State:       z.state(),
+				Region:      z.Region,
+				Type:        "availability-zone",
+				OptInStatus: "opt-in-not-required",
+			})
+		}
+		writeDescribe(w, action, "availabilityZoneInfo", items)
+	case "DescribeVpcs":
+		var vpcs []Vpc
+		if vpcs, err = selectResources(f.Vpcs, func(v Vpc) string { return v.ID }, listParam(r.Form, "VpcId"),
+			filters); err != nil {
+			break
+		}
+		items := []vpcXML{}
+		for _, v := range vpcs {
+			items = append(items, vpcXML{
+				ID:        v.ID,
+				OwnerID:   ownerOr(v.OwnerID, f.Account),
+				State:     "available",
+				CidrBlock: v.CidrBlock,
+				IsDefault: v.IsDefault,
+				Tags:      tagSet(v.Tags),
+			})
+		}
+		writeDescribe(w, action, "vpcSet", items)
+	case "DescribeSubnets":
+		var subnets []Subnet
+		if subnets, err = selectResources(f.Subnets, func(s Subnet) string { return s.ID },
+			listParam(r.Form, "SubnetId"), filters); err != nil {
+			break
+		}
+		items := []subnetXML{}
+		for _, sn := range subnets {
+			items = append(items, subnetXML{
+				ID:                  sn.ID,
+				Arn:                 fmt.Sprintf("arn:%s:ec2:%s:%s:subnet/%s", f.partition(r), signingRegion(r), f.Account, sn.ID),
+				OwnerID:             f.Account,
+				State:               "available",
+				VpcID:               sn.VpcID,
+				CidrBlock:           sn.CidrBlock,
+				AvailabilityZone:    sn.AvailabilityZone,
+				AvailabilityZoneID:  sn.AvailabilityZoneID,
+				MapPublicIPOnLaunch: sn.MapPublicIPOnLaunch,
+				Tags:                tagSet(sn.Tags),
+			})
+		}
+		writeDescribe(w, action, "subnetSet", items)
+	case "DescribeRouteTables":
+		var tables []RouteTable
+		if tables, err = selectResources(f.RouteTables, func(t RouteTable) string { return t.ID },
+			listParam(r.Form, "RouteTableId"), filters); err != nil {
+			break
+		}
+		items := []routeTableXML{}
+		for _, t := range tables {
+			item := routeTableXML{ID: t.ID, VpcID: t.VpcID, OwnerID: f.Account, Routes: t.Routes, Tags: tagSet(t.Tags)}
+			if t.Main {
+				item.Associations = append(item.Associations, routeTableAssociationXML{
+					ID: "rtbassoc-main-" + t.ID, RouteTableID: t.ID, Main: true, State: "associated",
+				})
+			}
+			for _, subnet := range t.Subnets {
+				item.Associations = append(item.Associations, routeTableAssociationXML{
+					ID: "rtbassoc-" + subnet, RouteTableID: t.ID, SubnetID: subnet, State: "associated",
+				})
+			}
+			items = append(items, item)
+		}
+		writeDescribe(w, action, "routeTableSet", items)
+	case "DescribeVpnGateways":
+		var gateways []VpnGateway
+		if gateways, err = selectResources(f.VpnGateways, func(g VpnGateway) string { return g.ID },
+			listParam(r.Form, "VpnGatewayId"), filters); err != nil {
+			break
+		}
+		items := []vpnGatewayXML{}
+		for _, g := range gateways {
+			item := vpnGatewayXML{ID: g.ID, State: "available", Type: "ipsec.1", Tags: tagSet(g.Tags)}
+			for _, vpc := range g.VpcIDs {
+				item.Attachments = append(item.Attachments, vpnGatewayAttachmentXML{VpcID: vpc, State: "attached"})
+			}
+			items = append(items, item)
+		}
+		writeDescribe(w, action, "vpnGatewaySet", items)
+	case "DescribeSecurityGroups":
+		var groups []SecurityGroup
+		if groups, err = selectResources(f.SecurityGroups, func(g SecurityGroup) string { return g.ID },
+			listParam(r.Form, "GroupId"), filters); err != nil {
+			break
+		}
+		items := []securityGroupXML{}
+		for _, g := range groups {
+			item := securityGroupXML{
+				ID:          g.ID,
+				Name:        g.Name,
+				OwnerID:     f.Account,
+				VpcID:       g.VpcID,
+				Description: g.Description,
+				Tags:        tagSet(g.Tags),
+			}
+			if len(g.Egress) > 0 {
+				all := ipPermissionXML{Protocol: "-1"}
+				for _, cidr := range g.Egress {
+					if strings.Contains(cidr, ":") {
+						all.IPv6Ranges = append(all.IPv6Ranges, ipv6RangeXML{CidrIPv6: cidr})
+					} else {
+						all.IPRanges = append(all.IPRanges, ipRangeXML{CidrIP: cidr})
+					}
+				}
+				item.Egress = []ipPermissionXML{all}
+			}
+			items = append(items, item)
+		}
+		writeDescribe(w, action, "securityGroupInfo", items)
+	default:
+		writeEC2Error(w, "InvalidAction", "fakeaws does not implement ec2:"+action)
+		return
+	}
+	if err != nil {
+		writeEC2Error(w, "InvalidParameterValue", err.Error())
+	}
+}
+
+func ownerOr(owner, account string) string {
+	if owner == "" {
+		return account
+	}
+	return owner
+}
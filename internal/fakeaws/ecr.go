@@ -0,0 +1,101 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// Repository is an ECR repository with the tags of the images pushed to it.
+type Repository struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+type listImagesRequest struct {
+	RepositoryName string `json:"repositoryName"`
+	MaxResults     int    `json:"maxResults"`
+	NextToken      string `json:"nextToken"`
+	Filter         struct {
+		TagStatus string `json:"tagStatus"`
+	} `json:"filter"`
+}
+
+type imageIDJSON struct {
+	ImageDigest string `json:"imageDigest"`
+	ImageTag    string `json:"imageTag"`
+}
+
+type listImagesResponse struct {
+	ImageIDs  []imageIDJSON `json:"imageIds"`
+	NextToken string        `json:"nextToken,omitempty"`
+}
+
+func (s *Server) serveECR(w http.ResponseWriter, r *http.Request, operation string) {
+	s.record("ecr", operation)
+	if operation != "ListImages" {
+		writeJSONError(w, http.StatusBadRequest, "UnknownOperationException", "fakeaws does not implement ecr:"+operation)
+		return
+	}
+	var req listImagesRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	limit := req.MaxResults
+	if limit == 0 {
+		limit = 100
+	}
+	// The token is the index of the next tag.
+	start := 0
+	if req.NextToken != "" {
+		var err error
+		if start, err = strconv.Atoi(req.NextToken); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "InvalidParameterException", "invalid token "+req.NextToken)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	f := s.fixtures
+	s.mu.Unlock()
+	i := slices.IndexFunc(f.Repositories, func(repo Repository) bool { return repo.Name == req.RepositoryName })
+	if i < 0 {
+		writeJSONError(w, http.StatusBadRequest, "RepositoryNotFoundException",
+			fmt.Sprintf("The repository with name '%s' does not exist in the registry with id '%s'",
+				req.RepositoryName, f.Account))
+		return
+	}
+	// Every image of the fake is tagged, so the UNTAGGED filter finds nothing.
+	tags := f.Repositories[i].Tags
+	if req.Filter.TagStatus == "UNTAGGED" {
+		tags = nil
+	}
+	resp := listImagesResponse{ImageIDs: []imageIDJSON{}}
+	for i := start; i < len(tags); i++ {
+		if len(resp.ImageIDs) == limit {
+			resp.NextToken = strconv.Itoa(i)
+			break
+		}
+		resp.ImageIDs = append(resp.ImageIDs, imageIDJSON{
+			ImageDigest: fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(req.RepositoryName+":"+tags[i]))),
+			ImageTag:    tags[i],
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,161 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/xml"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// LoadBalancer is an Elastic Load Balancing v2 load balancer.
+type LoadBalancer struct {
+	Arn  string `json:"loadBalancerArn"`
+	Name string `json:"loadBalancerName"`
+	// Type is application by default.
+	Type                  string   `json:"type,omitempty"`
+	DNSName               string   `json:"dnsName"`
+	CanonicalHostedZoneID string   `json:"canonicalHostedZoneId"`
+	VpcID                 string   `json:"vpcId"`
+	SecurityGroups        []string `json:"securityGroups,omitempty"`
+	// IPAddressType is ipv4 by default.
+	IPAddressType string            `json:"ipAddressType,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+const elbv2Namespace = "http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/"
+
+type loadBalancerXML struct {
+	Arn                   string   `xml:"LoadBalancerArn"`
+	Name                  string   `xml:"LoadBalancerName"`
+	DNSName               string   `xml:"DNSName"`
+	CanonicalHostedZoneID string   `xml:"CanonicalHostedZoneId"`
+	Scheme                string   `xml:"Scheme"`
+	VpcID                 string   `xml:"VpcId"`
+	State                 string   `xml:"State>Code"`
+	Type                  string   `xml:"Type"`
+	SecurityGroups        []string `xml:"SecurityGroups>member"`
+	IPAddressType         string   `xml:"IpAddressType"`
+}
+
+type describeLoadBalancersResponse struct {
+	XMLName       xml.Name          `xml:"DescribeLoadBalancersResponse"`
+	Xmlns         string            `xml:"xmlns,attr"`
+	LoadBalancers []loadBalancerXML `xml:"DescribeLoadBalancersResult>LoadBalancers>member"`
+	NextMarker    string            `xml:"DescribeLoadBalancersResult>NextMarker,omitempty"`
+	RequestID     string            `xml:"ResponseMetadata>RequestId"`
+}
+
+type elbv2TagXML struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type tagDescriptionXML struct {
+	ResourceArn string        `xml:"ResourceArn"`
+	Tags        []elbv2TagXML `xml:"Tags>member"`
+}
+
+type describeTagsResponse struct {
+	XMLName         xml.Name            `xml:"DescribeTagsResponse"`
+	Xmlns           string              `xml:"xmlns,attr"`
+	TagDescriptions []tagDescriptionXML `xml:"DescribeTagsResult>TagDescriptions>member"`
+	RequestID       string              `xml:"ResponseMetadata>RequestId"`
+}
+
+func (lb LoadBalancer) xml() loadBalancerXML {
+	x := loadBalancerXML{
+		Arn:                   lb.Arn,
+		Name:                  lb.Name,
+		DNSName:               lb.DNSName,
+		CanonicalHostedZoneID: lb.CanonicalHostedZoneID,
+		Scheme:                "internet-facing",
+		VpcID:                 lb.VpcID,
+		State:                 "active",
+		Type:                  lb.Type,
+		SecurityGroups:        lb.SecurityGroups,
+		IPAddressType:         lb.IPAddressType,
+	}
+	if x.Type == "" {
+		x.Type = "application"
+	}
+	if x.IPAddressType == "" {
+		x.IPAddressType = "ipv4"
+	}
+	return x
+}
+
+func (s *Server) serveELBv2(w http.ResponseWriter, r *http.Request) {
+	action := r.Form.Get("Action")
+	s.record("elasticloadbalancing", action)
+	s.mu.Lock()
+	f := s.fixtures
+	s.mu.Unlock()
+
+	switch action {
+	case "DescribeLoadBalancers":
+		arns := listParam(r.Form, "LoadBalancerArns.member")
+		names := listParam(r.Form, "Names.member")
+		var lbs []LoadBalancer
+		for _, lb := range f.LoadBalancers {
+			if (len(arns) == 0 || slices.Contains(arns, lb.Arn)) && (len(names) == 0 || slices.Contains(names, lb.Name)) {
+				lbs = append(lbs, lb)
+			}
+		}
+		if len(lbs) < len(arns)+len(names) {
+			writeQueryError(w, http.StatusBadRequest, "LoadBalancerNotFound",
+				"One or more load balancers not found")
+			return
+		}
+		pageSize := 400
+		if v := r.Form.Get("PageSize"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				writeQueryError(w, http.StatusBadRequest, "ValidationError", "invalid PageSize "+v)
+				return
+			}
+			pageSize = n
+		}
+		// The marker is the index of the next load balancer.
+		start, _ := strconv.Atoi(r.Form.Get("Marker"))
+		resp := describeLoadBalancersResponse{Xmlns: elbv2Namespace, RequestID: requestID}
+		for i := start; i < len(lbs); i++ {
+			if len(resp.LoadBalancers) == pageSize {
+				resp.NextMarker = strconv.Itoa(i)
+				break
+			}
+			resp.LoadBalancers = append(resp.LoadBalancers, lbs[i].xml())
+		}
+		writeXML(w, http.StatusOK, resp)
+	case "DescribeTags":
+		resp := describeTagsResponse{Xmlns: elbv2Namespace, RequestID: requestID}
+		for _, arn := range listParam(r.Form, "ResourceArns.member") {
+			i := slices.IndexFunc(f.LoadBalancers, func(lb LoadBalancer) bool { return lb.Arn == arn })
+			if i < 0 {
+				writeQueryError(w, http.StatusBadRequest, "LoadBalancerNotFound", "Load balancer '"+arn+"' not found")
+				return
+			}
+			d := tagDescriptionXML{ResourceArn: arn}
+			for _, t := range tagSet(f.LoadBalancers[i].Tags) {
+				d.Tags = append(d.Tags, elbv2TagXML(t))
+			}
+			resp.TagDescriptions = append(resp.TagDescriptions, d)
+		}
+		writeXML(w, http.StatusOK, resp)
+	default:
+		writeQueryError(w, http.StatusBadRequest, "InvalidAction", "fakeaws does not implement elasticloadbalancing:"+action)
+	}
+}
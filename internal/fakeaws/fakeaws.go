@@ -0,0 +1,279 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeaws is an in-process fake of the AWS calls that CDK context lookups and the lookup tests make:
+// GetCallerIdentity and AssumeRole of STS; ListHostedZonesByName, GetHostedZone, CreateHostedZone and
+// DeleteHostedZone of Route53; the EC2 describe calls of the AMI, VPC, security group and availability zone lookups;
+// ListAliases of KMS; GetParameter of SSM; and DescribeLoadBalancers and DescribeTags of Elastic Load Balancing. It is
+// seeded from fixtures and serves all services on one endpoint, which both the Go SDK and the AWS SDK for JavaScript
+// that the program runs are pointed at. Requests are not authenticated.
+//
+// The asset checks of internal/assets also list the staging bucket with ListObjectsV2 of S3 and the staging
+// repositories with ListImages of ECR. The program never calls those on the fake, so Env leaves them out.
+package fakeaws
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/pulumi/pulumi-cdk/internal/partitions"
+)
+
+// EnvVar enables the fake in the acceptance tests, with the fixtures at the path it is set to.
+const EnvVar = "PULUMI_CDK_TEST_FAKE_AWS"
+
+// Fixtures seed the fake.
+type Fixtures struct {
+	// Account is the account of the caller, 123456789012 by default.
+	Account string `json:"account,omitempty"`
+	// Partition is the partition of the ARNs the fake returns. By default it is the partition of the region that a
+	// request is signed for, so that one fake serves programs in aws-cn and aws-us-gov as well.
+	Partition         string             `json:"partition,omitempty"`
+	HostedZones       []HostedZone       `json:"hostedZones,omitempty"`
+	Images            []Image            `json:"images,omitempty"`
+	AvailabilityZones []AvailabilityZone `json:"availabilityZones,omitempty"`
+	Vpcs              []Vpc              `json:"vpcs,omitempty"`
+	Subnets           []Subnet           `json:"subnets,omitempty"`
+	RouteTables       []RouteTable       `json:"routeTables,omitempty"`
+	VpnGateways       []VpnGateway       `json:"vpnGateways,omitempty"`
+	SecurityGroups    []SecurityGroup    `json:"securityGroups,omitempty"`
+	KeyAliases        []KeyAlias         `json:"keyAliases,omitempty"`
+	Parameters        []Parameter        `json:"parameters,omitempty"`
+	LoadBalancers     []LoadBalancer     `json:"loadBalancers,omitempty"`
+	Buckets           []Bucket           `json:"buckets,omitempty"`
+	Repositories      []Repository       `json:"repositories,omitempty"`
+}
+
+// Load reads fixtures from a JSON file.
+func Load(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, err
+	}
+	var f Fixtures
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Fixtures{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Server is a running fake.
+type Server struct {
+	// URL is the endpoint of every service.
+	URL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	fixtures Fixtures
+	nextZone int
+	calls    []string
+}
+
+// Start starts a fake seeded with the fixtures. Close stops it.
+func Start(f Fixtures) *Server {
+	if f.Account == "" {
+		f.Account = "123456789012"
+	}
+	// Created and deleted zones must not change the caller's fixtures.
+	f.HostedZones = slices.Clone(f.HostedZones)
+	s := &Server{fixtures: f}
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Account is the account of the caller.
+func (s *Server) Account() string {
+	return s.fixtures.Account
+}
+
+// Calls returns the operations served so far, as "<service>:<operation>", e.g. "ec2:DescribeImages".
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func (s *Server) record(service, operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, service+":"+operation)
+}
+
+// partition returns the partition of the ARNs in the response to a request.
+func (f Fixtures) partition(r *http.Request) string {
+	if f.Partition != "" {
+		return f.Partition
+	}
+	return partitions.ForRegion(signingRegion(r)).ID
+}
+
+const (
+	accessKeyID     = "AKIAFAKEAWSLOOKUPS00"
+	secretAccessKey = "fake-secret-access-key"
+	sessionToken    = "fake-session-token"
+)
+
+// Env points the AWS SDKs of a process at the fake and gives them credentials, which the fake does not check.
+func (s *Server) Env() []string {
+	return []string{
+		"AWS_ENDPOINT_URL_STS=" + s.URL,
+		"AWS_ENDPOINT_URL_ROUTE_53=" + s.URL,
+		"AWS_ENDPOINT_URL_EC2=" + s.URL,
+		"AWS_ENDPOINT_URL_KMS=" + s.URL,
+		"AWS_ENDPOINT_URL_SSM=" + s.URL,
+		"AWS_ENDPOINT_URL_ELASTIC_LOAD_BALANCING_V2=" + s.URL,
+		"AWS_ACCESS_KEY_ID=" + accessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + secretAccessKey,
+		"AWS_SESSION_TOKEN=" + sessionToken,
+	}
+}
+
+// Config is an AWS SDK config whose clients call the fake.
+func (s *Server) Config(region string) aws.Config {
+	return aws.Config{
+		Region: region,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				AccessKeyID:     accessKeyID,
+				SecretAccessKey: secretAccessKey,
+				SessionToken:    sessionToken,
+				Source:          "fakeaws",
+			}, nil
+		}),
+		BaseEndpoint: aws.String(s.URL),
+	}
+}
+
+// API versions of the query protocol services, which tell their requests apart.
+const (
+	stsVersion   = "2011-06-15"
+	ec2Version   = "2016-11-15"
+	elbv2Version = "2015-12-01"
+)
+
+// Target prefixes of the JSON protocol services, whose requests name the operation in X-Amz-Target.
+const (
+	kmsTarget = "TrentService."
+	ssmTarget = "AmazonSSM."
+	ecrTarget = "AmazonEC2ContainerRegistry_V20150921."
+)
+
+// route53Prefix is the path prefix of the Route53 REST API.
+const route53Prefix = "/2013-04-01/"
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, route53Prefix) {
+		s.serveRoute53(w, r)
+		return
+	}
+	if isS3(r) {
+		s.serveS3(w, r)
+		return
+	}
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		if operation, ok := strings.CutPrefix(target, kmsTarget); ok {
+			s.serveKMS(w, r, operation)
+		} else if operation, ok := strings.CutPrefix(target, ssmTarget); ok {
+			s.serveSSM(w, r, operation)
+		} else if operation, ok := strings.CutPrefix(target, ecrTarget); ok {
+			s.serveECR(w, r, operation)
+		} else {
+			writeJSONError(w, http.StatusBadRequest, "UnknownOperationException", "fakeaws does not implement "+target)
+		}
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeQueryError(w, http.StatusBadRequest, "MalformedQueryString", err.Error())
+		return
+	}
+	switch r.Form.Get("Version") {
+	case stsVersion:
+		s.serveSTS(w, r)
+	case ec2Version:
+		s.serveEC2(w, r)
+	case elbv2Version:
+		s.serveELBv2(w, r)
+	default:
+		writeQueryError(w, http.StatusBadRequest, "InvalidAction",
+			fmt.Sprintf("no fake for API version %q", r.Form.Get("Version")))
+	}
+}
+
+const requestID = "00000000-0000-0000-0000-000000000000"
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(data)
+}
+
+// queryError is the error of the STS query protocol, which the fake also uses for requests it cannot route.
+type queryError struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+func writeQueryError(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, queryError{Type: "Sender", Code: code, Message: message, RequestID: requestID})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// writeJSONError writes an error of the JSON protocol, which the SDKs read the code of from __type.
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"__type": code, "message": message})
+}
+
+// readJSON decodes the body of a JSON protocol request, and writes the error if it cannot.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "SerializationException", err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,538 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fixtures = Fixtures{
+	HostedZones: []HostedZone{
+		{ID: "ZSEEDED", Name: "coolcompany.io"},
+		{ID: "ZPRIVATE", Name: "internal.coolcompany.io", Private: true,
+			Vpcs: []ZoneVpc{{VpcID: "vpc-1", VpcRegion: "us-east-2"}}},
+	},
+	Images: []Image{
+		{ID: "ami-old", Name: "al2023-ami-2023.1.20230101.0-kernel-6.1-arm64", OwnerID: "137112412989",
+			OwnerAlias: "amazon", Architecture: "arm64", CreationDate: "2023-01-01T00:00:00.000Z"},
+		{ID: "ami-new", Name: "al2023-ami-2023.6.20241010.0-kernel-6.1-arm64", OwnerID: "137112412989",
+			OwnerAlias: "amazon", Architecture: "arm64", CreationDate: "2024-10-10T00:00:00.000Z"},
+		{ID: "ami-x86", Name: "al2023-ami-2023.6.20241010.0-kernel-6.1-x86_64", OwnerID: "137112412989",
+			OwnerAlias: "amazon", CreationDate: "2024-10-10T00:00:00.000Z"},
+		{ID: "ami-mine", Name: "al2023-ami-custom-arm64", OwnerID: "123456789012"},
+	},
+	Vpcs: []Vpc{
+		{ID: "vpc-1", CidrBlock: "10.0.0.0/16", IsDefault: true},
+		{ID: "vpc-2", CidrBlock: "10.1.0.0/16", Tags: map[string]string{"Name": "shared"}},
+	},
+	Subnets: []Subnet{
+		{ID: "subnet-1", VpcID: "vpc-2", AvailabilityZone: "us-east-2a", CidrBlock: "10.1.0.0/24"},
+		{ID: "subnet-2", VpcID: "vpc-2", AvailabilityZone: "us-east-2b", CidrBlock: "10.1.1.0/24"},
+		{ID: "subnet-3", VpcID: "vpc-1", AvailabilityZone: "us-east-2a", CidrBlock: "10.0.0.0/24"},
+	},
+	RouteTables: []RouteTable{
+		{ID: "rtb-main", VpcID: "vpc-2", Main: true,
+			Routes: []Route{{DestinationCidrBlock: "0.0.0.0/0", GatewayID: "igw-1"}}},
+		{ID: "rtb-private", VpcID: "vpc-2", Subnets: []string{"subnet-2"}},
+	},
+	VpnGateways: []VpnGateway{{ID: "vgw-1", VpcIDs: []string{"vpc-2"}}},
+	SecurityGroups: []SecurityGroup{
+		{ID: "sg-open", Name: "open", VpcID: "vpc-2", Egress: []string{"0.0.0.0/0", "::/0"}},
+		{ID: "sg-closed", Name: "closed", VpcID: "vpc-2"},
+	},
+	KeyAliases: []KeyAlias{
+		{Name: "alias/one", TargetKeyID: "key-1"},
+		{Name: "alias/two", TargetKeyID: "key-2"},
+		{Name: "alias/three", TargetKeyID: "key-1"},
+	},
+	Parameters: []Parameter{{Name: "/app/greeting", Value: "hello"}},
+	LoadBalancers: []LoadBalancer{
+		{Arn: "arn:aws:elasticloadbalancing:us-east-2:123456789012:loadbalancer/app/web/1", Name: "web",
+			DNSName: "web-1.us-east-2.elb.amazonaws.com", VpcID: "vpc-2", SecurityGroups: []string{"sg-open"},
+			Tags: map[string]string{"team": "web"}},
+		{Arn: "arn:aws:elasticloadbalancing:us-east-2:123456789012:loadbalancer/net/db/2", Name: "db",
+			Type: "network", VpcID: "vpc-2"},
+	},
+	Buckets: []Bucket{{Name: "staging", Objects: map[string]string{
+		"deploy-time/b.zip": "zip",
+		"a.json":            "{}",
+		"c.txt":             "hello",
+	}}},
+	Repositories: []Repository{{Name: "app/image", Tags: []string{"one", "two", "three"}}},
+}
+
+func TestSTS(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+	client := sts.NewFromConfig(s.Config("us-east-2"))
+
+	id, err := client.GetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", aws.ToString(id.Account))
+	assert.Equal(t, "arn:aws:iam::123456789012:user/fakeaws", aws.ToString(id.Arn))
+
+	role, err := client.AssumeRole(context.Background(), &sts.AssumeRoleInput{
+		RoleArn:         aws.String("arn:aws:iam::123456789012:role/cdk-hnb659fds-lookup-role-123456789012-us-east-2"),
+		RoleSessionName: aws.String("lookup"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"arn:aws:sts::123456789012:assumed-role/cdk-hnb659fds-lookup-role-123456789012-us-east-2/lookup",
+		aws.ToString(role.AssumedRoleUser.Arn))
+	assert.NotEmpty(t, aws.ToString(role.Credentials.SessionToken))
+
+	assert.Equal(t, []string{"sts:GetCallerIdentity", "sts:AssumeRole"}, s.Calls())
+}
+
+func TestPartition(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+
+	for region, arn := range map[string]string{
+		"cn-north-1":    "arn:aws-cn:iam::123456789012:user/fakeaws",
+		"us-gov-west-1": "arn:aws-us-gov:iam::123456789012:user/fakeaws",
+	} {
+		id, err := sts.NewFromConfig(s.Config(region)).GetCallerIdentity(context.Background(),
+			&sts.GetCallerIdentityInput{})
+		require.NoError(t, err)
+		assert.Equal(t, arn, aws.ToString(id.Arn), region)
+	}
+
+	pinned := fixtures
+	pinned.Partition = "aws-iso"
+	p := Start(pinned)
+	defer p.Close()
+	id, err := sts.NewFromConfig(p.Config("cn-north-1")).GetCallerIdentity(context.Background(),
+		&sts.GetCallerIdentityInput{})
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws-iso:iam::123456789012:user/fakeaws", aws.ToString(id.Arn))
+}
+
+func TestRoute53(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+	ctx := context.Background()
+	client := route53.NewFromConfig(s.Config("us-east-2"))
+
+	created, err := client.CreateHostedZone(ctx, &route53.CreateHostedZoneInput{
+		Name:            aws.String("cdkexample-42.com"),
+		CallerReference: aws.String("cdkexample-42.com"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cdkexample-42.com.", aws.ToString(created.HostedZone.Name))
+	assert.Equal(t, "/hostedzone/ZFAKE00000001", aws.ToString(created.HostedZone.Id))
+	assert.NotEmpty(t, created.DelegationSet.NameServers)
+
+	_, err = client.CreateHostedZone(ctx, &route53.CreateHostedZoneInput{
+		Name:            aws.String("cdkexample-43.com"),
+		CallerReference: aws.String("cdkexample-42.com"),
+	})
+	assert.ErrorContains(t, err, "HostedZoneAlreadyExists")
+
+	list, err := client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{
+		DNSName: aws.String("cdkexample-42.com"),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, list.HostedZones)
+	assert.Equal(t, "cdkexample-42.com.", aws.ToString(list.HostedZones[0].Name))
+
+	list, err = client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{})
+	require.NoError(t, err)
+	var names []string
+	for _, z := range list.HostedZones {
+		names = append(names, aws.ToString(z.Name))
+	}
+	assert.Equal(t, []string{"cdkexample-42.com.", "coolcompany.io.", "internal.coolcompany.io."}, names,
+		"zones are listed by their reversed labels")
+
+	private, err := client.GetHostedZone(ctx, &route53.GetHostedZoneInput{Id: aws.String("ZPRIVATE")})
+	require.NoError(t, err)
+	assert.True(t, private.HostedZone.Config.PrivateZone)
+	require.Len(t, private.VPCs, 1)
+	assert.Equal(t, "vpc-1", aws.ToString(private.VPCs[0].VPCId))
+
+	_, err = client.DeleteHostedZone(ctx, &route53.DeleteHostedZoneInput{Id: created.HostedZone.Id})
+	require.NoError(t, err)
+	_, err = client.GetHostedZone(ctx, &route53.GetHostedZoneInput{Id: created.HostedZone.Id})
+	assert.ErrorContains(t, err, "NoSuchHostedZone")
+	assert.Len(t, fixtures.HostedZones, 2, "the fixtures are not changed")
+}
+
+// call calls an EC2 action in us-east-2 and returns its response.
+func call(t *testing.T, s *Server, action string, params url.Values) []byte {
+	return callQuery(t, s, ec2Version, action, params)
+}
+
+// callQuery calls an action of a query protocol service in us-east-2 and returns its response.
+func callQuery(t *testing.T, s *Server, version, action string, params url.Values) []byte {
+	params.Set("Action", action)
+	params.Set("Version", version)
+	req, err := http.NewRequest(http.MethodPost, s.URL, strings.NewReader(params.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(t, req, http.StatusOK)
+}
+
+// callJSON calls an operation of a JSON protocol service in us-east-2, e.g. TrentService.ListAliases, and returns
+// its response.
+func callJSON(t *testing.T, s *Server, target, body string, status int) []byte {
+	req, err := http.NewRequest(http.MethodPost, s.URL, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	return do(t, req, status)
+}
+
+func do(t *testing.T, req *http.Request, status int) []byte {
+	req.Header.Set("Authorization",
+		"AWS4-HMAC-SHA256 Credential=AKID/20241018/us-east-2/ec2/aws4_request, SignedHeaders=host, Signature=x")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, status, resp.StatusCode, string(body))
+	return body
+}
+
+// describe calls an EC2 describe action and decodes the items of its set.
+func describe[T any](t *testing.T, s *Server, action string, params url.Values) []T {
+	var out struct {
+		Set struct {
+			Items []T `xml:"item"`
+		} `xml:",any"`
+	}
+	require.NoError(t, xml.Unmarshal(call(t, s, action, params), &out))
+	return out.Set.Items
+}
+
+// ids calls an EC2 describe action and returns the text of every element with the given name.
+func ids(t *testing.T, s *Server, action, element string, params url.Values) []string {
+	var ids []string
+	d := xml.NewDecoder(bytes.NewReader(call(t, s, action, params)))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return ids
+		}
+		require.NoError(t, err)
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == element {
+			var id string
+			require.NoError(t, d.DecodeElement(&id, &start))
+			ids = append(ids, id)
+		}
+	}
+}
+
+func TestEC2(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+
+	// The filters of ec2.LookupMachineImage.
+	images := url.Values{
+		"Owner.1":          {"amazon"},
+		"Filter.1.Name":    {"name"},
+		"Filter.1.Value.1": {"al2023-ami-2023.*.*.*.*-arm64"},
+		"Filter.2.Name":    {"state"},
+		"Filter.2.Value.1": {"available"},
+		"Filter.3.Name":    {"image-type"},
+		"Filter.3.Value.1": {"machine"},
+	}
+	assert.Equal(t, []string{"ami-old", "ami-new"}, ids(t, s, "DescribeImages", "imageId", images))
+	images.Set("Owner.1", "self")
+	assert.Empty(t, ids(t, s, "DescribeImages", "imageId", images))
+	assert.Equal(t, []string{"ami-mine"}, ids(t, s, "DescribeImages", "imageId", url.Values{"Owner.1": {"self"}}))
+
+	type zone struct {
+		Name   string `xml:"zoneName"`
+		State  string `xml:"zoneState"`
+		Region string `xml:"regionName"`
+	}
+	assert.Equal(t, []zone{
+		{"us-east-2a", "available", "us-east-2"},
+		{"us-east-2b", "available", "us-east-2"},
+		{"us-east-2c", "available", "us-east-2"},
+	}, describe[zone](t, s, "DescribeAvailabilityZones", url.Values{}), "zones default to the request's region")
+
+	// The calls of ec2.Vpc.fromLookup.
+	assert.Equal(t, []string{"vpc-2"}, ids(t, s, "DescribeVpcs", "vpcId", url.Values{
+		"Filter.1.Name": {"tag:Name"}, "Filter.1.Value.1": {"shared"},
+	}))
+	assert.Equal(t, []string{"vpc-1"}, ids(t, s, "DescribeVpcs", "vpcId", url.Values{
+		"Filter.1.Name": {"isDefault"}, "Filter.1.Value.1": {"true"},
+	}))
+	assert.Equal(t, []string{"subnet-1", "subnet-2"}, ids(t, s, "DescribeSubnets", "subnetId", url.Values{
+		"Filter.1.Name": {"vpc-id"}, "Filter.1.Value.1": {"vpc-2"},
+	}))
+	type association struct {
+		SubnetID string `xml:"subnetId"`
+		Main     bool   `xml:"main"`
+	}
+	type routeTable struct {
+		ID           string        `xml:"routeTableId"`
+		Routes       []Route       `xml:"routeSet>item"`
+		Associations []association `xml:"associationSet>item"`
+	}
+	tables := describe[routeTable](t, s, "DescribeRouteTables", url.Values{
+		"Filter.1.Name": {"vpc-id"}, "Filter.1.Value.1": {"vpc-2"},
+	})
+	assert.Equal(t, []routeTable{
+		{ID: "rtb-main", Routes: []Route{{DestinationCidrBlock: "0.0.0.0/0", GatewayID: "igw-1"}},
+			Associations: []association{{Main: true}}},
+		{ID: "rtb-private", Associations: []association{{SubnetID: "subnet-2"}}},
+	}, tables)
+	assert.Equal(t, []string{"vgw-1"}, ids(t, s, "DescribeVpnGateways", "vpnGatewayId", url.Values{
+		"Filter.1.Name": {"attachment.vpc-id"}, "Filter.1.Value.1": {"vpc-2"},
+		"Filter.2.Name": {"attachment.state"}, "Filter.2.Value.1": {"attached"},
+	}))
+	assert.Empty(t, ids(t, s, "DescribeVpnGateways", "vpnGatewayId", url.Values{
+		"Filter.1.Name": {"attachment.vpc-id"}, "Filter.1.Value.1": {"vpc-1"},
+	}))
+
+	// The call of ec2.SecurityGroup.fromLookupById, which reads allowAllOutbound off the egress rules.
+	type securityGroup struct {
+		ID     string `xml:"groupId"`
+		Egress []struct {
+			Protocol string   `xml:"ipProtocol"`
+			IPv4     []string `xml:"ipRanges>item>cidrIp"`
+			IPv6     []string `xml:"ipv6Ranges>item>cidrIpv6"`
+		} `xml:"ipPermissionsEgress>item"`
+	}
+	groups := describe[securityGroup](t, s, "DescribeSecurityGroups", url.Values{"GroupId.1": {"sg-open"}})
+	require.Len(t, groups, 1)
+	require.Len(t, groups[0].Egress, 1)
+	assert.Equal(t, "-1", groups[0].Egress[0].Protocol)
+	assert.Equal(t, []string{"0.0.0.0/0"}, groups[0].Egress[0].IPv4)
+	assert.Equal(t, []string{"::/0"}, groups[0].Egress[0].IPv6)
+	assert.Equal(t, []string{"sg-closed"}, ids(t, s, "DescribeSecurityGroups", "groupId", url.Values{
+		"Filter.1.Name": {"vpc-id"}, "Filter.1.Value.1": {"vpc-2"},
+		"Filter.2.Name": {"group-name"}, "Filter.2.Value.1": {"closed"},
+	}))
+}
+
+func TestEC2Errors(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+	post := func(params url.Values) string {
+		resp, err := http.PostForm(s.URL, params)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		return string(body)
+	}
+	assert.Contains(t, post(url.Values{
+		"Action": {"DescribeVpcs"}, "Version": {ec2Version}, "Filter.1.Name": {"colour"}, "Filter.1.Value.1": {"red"},
+	}), "<Code>InvalidParameterValue</Code>")
+	assert.Contains(t, post(url.Values{"Action": {"RunInstances"}, "Version": {ec2Version}}),
+		"<Code>InvalidAction</Code>")
+	assert.Contains(t, post(url.Values{"Action": {"ListBuckets"}}), "<Code>InvalidAction</Code>")
+}
+
+func TestKMS(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+
+	type page struct {
+		Aliases []struct {
+			AliasName   string
+			AliasArn    string
+			TargetKeyID string `json:"TargetKeyId"`
+		}
+		Truncated  bool
+		NextMarker string
+	}
+	list := func(body string) page {
+		var p page
+		require.NoError(t, json.Unmarshal(callJSON(t, s, "TrentService.ListAliases", body, http.StatusOK), &p))
+		return p
+	}
+	first := list(`{"Limit": 2}`)
+	require.Len(t, first.Aliases, 2)
+	assert.Equal(t, "alias/one", first.Aliases[0].AliasName)
+	assert.Equal(t, "arn:aws:kms:us-east-2:123456789012:alias/one", first.Aliases[0].AliasArn)
+	assert.Equal(t, "key-1", first.Aliases[0].TargetKeyID)
+	require.True(t, first.Truncated)
+	rest := list(`{"Limit": 2, "Marker": "` + first.NextMarker + `"}`)
+	require.Len(t, rest.Aliases, 1)
+	assert.Equal(t, "alias/three", rest.Aliases[0].AliasName)
+	assert.False(t, rest.Truncated)
+	assert.Len(t, list(`{"KeyId": "key-1"}`).Aliases, 2)
+
+	assert.Contains(t, string(callJSON(t, s, "TrentService.DescribeKey", `{}`, http.StatusBadRequest)),
+		"UnknownOperationException")
+}
+
+func TestSSM(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+
+	var resp struct {
+		Parameter struct {
+			Name, Type, Value, ARN string
+		}
+	}
+	require.NoError(t, json.Unmarshal(
+		callJSON(t, s, "AmazonSSM.GetParameter", `{"Name": "/app/greeting"}`, http.StatusOK), &resp))
+	assert.Equal(t, "hello", resp.Parameter.Value)
+	assert.Equal(t, "String", resp.Parameter.Type)
+	assert.Equal(t, "arn:aws:ssm:us-east-2:123456789012:parameter/app/greeting", resp.Parameter.ARN)
+
+	assert.Contains(t, string(callJSON(t, s, "AmazonSSM.GetParameter", `{"Name": "/app/missing"}`,
+		http.StatusBadRequest)), `"__type":"ParameterNotFound"`)
+}
+
+func TestELBv2(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+	web := fixtures.LoadBalancers[0].Arn
+
+	// types returns the type of every load balancer in the response.
+	types := func(params url.Values) []string {
+		var types []string
+		d := xml.NewDecoder(bytes.NewReader(callQuery(t, s, elbv2Version, "DescribeLoadBalancers", params)))
+		for {
+			tok, err := d.Token()
+			if err == io.EOF {
+				return types
+			}
+			require.NoError(t, err)
+			if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "Type" {
+				var typ string
+				require.NoError(t, d.DecodeElement(&typ, &start))
+				types = append(types, typ)
+			}
+		}
+	}
+	assert.Equal(t, []string{"application", "network"}, types(url.Values{}))
+	assert.Equal(t, []string{"application"}, types(url.Values{"LoadBalancerArns.member.1": {web}}))
+	assert.Equal(t, []string{"network"}, types(url.Values{"Names.member.1": {"db"}}))
+
+	var tags struct {
+		Descriptions []struct {
+			ResourceArn string   `xml:"ResourceArn"`
+			Keys        []string `xml:"Tags>member>Key"`
+		} `xml:"DescribeTagsResult>TagDescriptions>member"`
+	}
+	require.NoError(t, xml.Unmarshal(callQuery(t, s, elbv2Version, "DescribeTags", url.Values{
+		"ResourceArns.member.1": {web},
+	}), &tags))
+	require.Len(t, tags.Descriptions, 1)
+	assert.Equal(t, web, tags.Descriptions[0].ResourceArn)
+	assert.Equal(t, []string{"team"}, tags.Descriptions[0].Keys)
+
+	resp, err := http.PostForm(s.URL, url.Values{
+		"Action": {"DescribeLoadBalancers"}, "Version": {elbv2Version}, "LoadBalancerArns.member.1": {web + "0"},
+	})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, string(body), "<Code>LoadBalancerNotFound</Code>")
+}
+
+func TestS3(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+	ctx := context.Background()
+	client := s3.NewFromConfig(s.Config("us-east-2"), func(o *s3.Options) { o.UsePathStyle = true })
+
+	first, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("staging"), MaxKeys: aws.Int32(2)})
+	require.NoError(t, err)
+	require.Len(t, first.Contents, 2)
+	assert.Equal(t, "a.json", aws.ToString(first.Contents[0].Key))
+	assert.Equal(t, `"99914b932bd37a50b983c5e7c90ae93b"`, aws.ToString(first.Contents[0].ETag))
+	assert.Equal(t, int64(2), aws.ToInt64(first.Contents[0].Size))
+	require.True(t, aws.ToBool(first.IsTruncated))
+	rest, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:            aws.String("staging"),
+		ContinuationToken: first.NextContinuationToken,
+	})
+	require.NoError(t, err)
+	require.Len(t, rest.Contents, 1)
+	assert.Equal(t, "deploy-time/b.zip", aws.ToString(rest.Contents[0].Key))
+	assert.False(t, aws.ToBool(rest.IsTruncated))
+
+	prefixed, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String("staging"),
+		Prefix: aws.String("deploy-time/"),
+	})
+	require.NoError(t, err)
+	assert.Len(t, prefixed.Contents, 1)
+
+	_, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("missing")})
+	assert.ErrorContains(t, err, "NoSuchBucket")
+	assert.Equal(t, []string{"s3:ListObjectsV2", "s3:ListObjectsV2", "s3:ListObjectsV2", "s3:ListObjectsV2"}, s.Calls())
+}
+
+func TestECR(t *testing.T) {
+	s := Start(fixtures)
+	defer s.Close()
+
+	type page struct {
+		ImageIDs []struct {
+			ImageDigest string
+			ImageTag    string
+		} `json:"imageIds"`
+		NextToken string `json:"nextToken"`
+	}
+	const target = "AmazonEC2ContainerRegistry_V20150921.ListImages"
+	list := func(body string) page {
+		var p page
+		require.NoError(t, json.Unmarshal(callJSON(t, s, target, body, http.StatusOK), &p))
+		return p
+	}
+	first := list(`{"repositoryName": "app/image", "maxResults": 2}`)
+	require.Len(t, first.ImageIDs, 2)
+	assert.Equal(t, "one", first.ImageIDs[0].ImageTag)
+	assert.True(t, strings.HasPrefix(first.ImageIDs[0].ImageDigest, "sha256:"))
+	require.NotEmpty(t, first.NextToken)
+	rest := list(`{"repositoryName": "app/image", "maxResults": 2, "nextToken": "` + first.NextToken + `"}`)
+	require.Len(t, rest.ImageIDs, 1)
+	assert.Equal(t, "three", rest.ImageIDs[0].ImageTag)
+	assert.Empty(t, rest.NextToken)
+	assert.Empty(t, list(`{"repositoryName": "app/image", "filter": {"tagStatus": "UNTAGGED"}}`).ImageIDs)
+
+	assert.Contains(t, string(callJSON(t, s, target, `{"repositoryName": "missing"}`, http.StatusBadRequest)),
+		"RepositoryNotFoundException")
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fakeaws.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"account": "111111111111", "images": [{"imageId": "ami-1"}]}`), 0o600))
+	f, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "111111111111", f.Account)
+	assert.Equal(t, []Image{{ID: "ami-1"}}, f.Images)
+}
@@ -0,0 +1,96 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// KeyAlias is a KMS alias, which the key lookup resolves to the ID of its key.
+type KeyAlias struct {
+	// Name includes the alias/ prefix.
+	Name        string `json:"aliasName"`
+	TargetKeyID string `json:"targetKeyId"`
+}
+
+type listAliasesRequest struct {
+	KeyID  string `json:"KeyId"`
+	Limit  int    `json:"Limit"`
+	Marker string `json:"Marker"`
+}
+
+type aliasJSON struct {
+	AliasName   string `json:"AliasName"`
+	AliasArn    string `json:"AliasArn"`
+	TargetKeyID string `json:"TargetKeyId"`
+}
+
+type listAliasesResponse struct {
+	Aliases    []aliasJSON `json:"Aliases"`
+	Truncated  bool        `json:"Truncated"`
+	NextMarker string      `json:"NextMarker,omitempty"`
+}
+
+func (s *Server) serveKMS(w http.ResponseWriter, r *http.Request, operation string) {
+	s.record("kms", operation)
+	if operation != "ListAliases" {
+		writeJSONError(w, http.StatusBadRequest, "UnknownOperationException", "fakeaws does not implement kms:"+operation)
+		return
+	}
+	var req listAliasesRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	// The marker is the index of the next alias.
+	start := 0
+	if req.Marker != "" {
+		var err error
+		if start, err = strconv.Atoi(req.Marker); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "InvalidMarkerException", "invalid marker "+req.Marker)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	f := s.fixtures
+	s.mu.Unlock()
+	var aliases []KeyAlias
+	for _, a := range f.KeyAliases {
+		if req.KeyID == "" || req.KeyID == a.TargetKeyID {
+			aliases = append(aliases, a)
+		}
+	}
+	resp := listAliasesResponse{Aliases: []aliasJSON{}}
+	for i := start; i < len(aliases); i++ {
+		if len(resp.Aliases) == limit {
+			resp.Truncated = true
+			resp.NextMarker = strconv.Itoa(i)
+			break
+		}
+		a := aliases[i]
+		resp.Aliases = append(resp.Aliases, aliasJSON{
+			AliasName:   a.Name,
+			AliasArn:    fmt.Sprintf("arn:%s:kms:%s:%s:%s", f.partition(r), signingRegion(r), f.Account, a.Name),
+			TargetKeyID: a.TargetKeyID,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
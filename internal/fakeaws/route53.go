@@ -0,0 +1,294 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostedZone is a Route53 hosted zone.
+type HostedZone struct {
+	// ID is the zone ID without the /hostedzone/ prefix. Zones created through the fake get one.
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	CallerReference string    `json:"callerReference,omitempty"`
+	Private         bool      `json:"private,omitempty"`
+	Vpcs            []ZoneVpc `json:"vpcs,omitempty"`
+}
+
+// ZoneVpc is a VPC associated with a private hosted zone.
+type ZoneVpc struct {
+	VpcID     string `json:"vpcId" xml:"VPCId"`
+	VpcRegion string `json:"vpcRegion" xml:"VPCRegion"`
+}
+
+const route53Namespace = "https://route53.amazonaws.com/doc/2013-04-01/"
+
+// fqdn returns the name with the trailing dot that Route53 returns.
+func fqdn(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".") + "."
+}
+
+// reversedLabels is the order Route53 lists zones by name in: com.example.www.
+func reversedLabels(name string) string {
+	labels := strings.Split(strings.TrimSuffix(fqdn(name), "."), ".")
+	slices.Reverse(labels)
+	return strings.Join(labels, ".")
+}
+
+type hostedZoneXML struct {
+	ID                     string `xml:"Id"`
+	Name                   string `xml:"Name"`
+	CallerReference        string `xml:"CallerReference"`
+	PrivateZone            bool   `xml:"Config>PrivateZone"`
+	ResourceRecordSetCount int    `xml:"ResourceRecordSetCount"`
+}
+
+func (z HostedZone) xml() hostedZoneXML {
+	return hostedZoneXML{
+		ID:                     "/hostedzone/" + z.ID,
+		Name:                   fqdn(z.Name),
+		CallerReference:        z.CallerReference,
+		PrivateZone:            z.Private,
+		ResourceRecordSetCount: 2,
+	}
+}
+
+type changeInfoXML struct {
+	ID          string `xml:"Id"`
+	Status      string `xml:"Status"`
+	SubmittedAt string `xml:"SubmittedAt"`
+}
+
+func changeInfo(zone string) changeInfoXML {
+	return changeInfoXML{
+		ID:          "/change/C" + zone,
+		Status:      "INSYNC",
+		SubmittedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+var nameServers = []string{"ns-1.awsdns-00.com", "ns-2.awsdns-00.net"}
+
+type listHostedZonesByNameResponse struct {
+	XMLName          xml.Name        `xml:"ListHostedZonesByNameResponse"`
+	Xmlns            string          `xml:"xmlns,attr"`
+	HostedZones      []hostedZoneXML `xml:"HostedZones>HostedZone"`
+	DNSName          string          `xml:"DNSName,omitempty"`
+	HostedZoneID     string          `xml:"HostedZoneId,omitempty"`
+	IsTruncated      bool            `xml:"IsTruncated"`
+	NextDNSName      string          `xml:"NextDNSName,omitempty"`
+	NextHostedZoneID string          `xml:"NextHostedZoneId,omitempty"`
+	MaxItems         int             `xml:"MaxItems"`
+}
+
+type getHostedZoneResponse struct {
+	XMLName     xml.Name      `xml:"GetHostedZoneResponse"`
+	Xmlns       string        `xml:"xmlns,attr"`
+	HostedZone  hostedZoneXML `xml:"HostedZone"`
+	NameServers []string      `xml:"DelegationSet>NameServers>NameServer,omitempty"`
+	Vpcs        []ZoneVpc     `xml:"VPCs>VPC,omitempty"`
+}
+
+type createHostedZoneRequest struct {
+	Name            string   `xml:"Name"`
+	CallerReference string   `xml:"CallerReference"`
+	Vpc             *ZoneVpc `xml:"VPC"`
+	PrivateZone     bool     `xml:"HostedZoneConfig>PrivateZone"`
+}
+
+type createHostedZoneResponse struct {
+	XMLName     xml.Name      `xml:"CreateHostedZoneResponse"`
+	Xmlns       string        `xml:"xmlns,attr"`
+	HostedZone  hostedZoneXML `xml:"HostedZone"`
+	ChangeInfo  changeInfoXML `xml:"ChangeInfo"`
+	NameServers []string      `xml:"DelegationSet>NameServers>NameServer"`
+	Vpc         *ZoneVpc      `xml:"VPC,omitempty"`
+}
+
+type deleteHostedZoneResponse struct {
+	XMLName    xml.Name      `xml:"DeleteHostedZoneResponse"`
+	Xmlns      string        `xml:"xmlns,attr"`
+	ChangeInfo changeInfoXML `xml:"ChangeInfo"`
+}
+
+type route53Error struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+func writeRoute53Error(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, route53Error{
+		Xmlns:     route53Namespace,
+		Type:      "Sender",
+		Code:      code,
+		Message:   message,
+		RequestID: requestID,
+	})
+}
+
+func (s *Server) serveRoute53(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, route53Prefix)
+	id, hasID := strings.CutPrefix(path, "hostedzone/")
+	// IDs may come with their /hostedzone/ prefix.
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "/"), "hostedzone/")
+	switch {
+	case r.Method == http.MethodGet && path == "hostedzonesbyname":
+		s.record("route53", "ListHostedZonesByName")
+		s.listHostedZonesByName(w, r)
+	case r.Method == http.MethodPost && path == "hostedzone":
+		s.record("route53", "CreateHostedZone")
+		s.createHostedZone(w, r)
+	case r.Method == http.MethodGet && hasID:
+		s.record("route53", "GetHostedZone")
+		s.getHostedZone(w, id)
+	case r.Method == http.MethodDelete && hasID:
+		s.record("route53", "DeleteHostedZone")
+		s.deleteHostedZone(w, id)
+	default:
+		writeRoute53Error(w, http.StatusBadRequest, "InvalidInput",
+			fmt.Sprintf("fakeaws does not implement %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) listHostedZonesByName(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	maxItems := 100
+	if v := q.Get("maxitems"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeRoute53Error(w, http.StatusBadRequest, "InvalidInput", "invalid maxitems "+v)
+			return
+		}
+		maxItems = n
+	}
+
+	s.mu.Lock()
+	zones := slices.Clone(s.fixtures.HostedZones)
+	s.mu.Unlock()
+	sort.SliceStable(zones, func(i, j int) bool {
+		return reversedLabels(zones[i].Name) < reversedLabels(zones[j].Name)
+	})
+	resp := listHostedZonesByNameResponse{Xmlns: route53Namespace, MaxItems: maxItems}
+	if dnsName := q.Get("dnsname"); dnsName != "" {
+		resp.DNSName = fqdn(dnsName)
+		start := reversedLabels(dnsName)
+		zones = slices.DeleteFunc(zones, func(z HostedZone) bool { return reversedLabels(z.Name) < start })
+	}
+	if startID := q.Get("hostedzoneid"); startID != "" {
+		resp.HostedZoneID = startID
+		if i := slices.IndexFunc(zones, func(z HostedZone) bool { return z.ID == startID }); i >= 0 {
+			zones = zones[i:]
+		}
+	}
+	if len(zones) > maxItems {
+		resp.IsTruncated = true
+		resp.NextDNSName = fqdn(zones[maxItems].Name)
+		resp.NextHostedZoneID = zones[maxItems].ID
+		zones = zones[:maxItems]
+	}
+	for _, z := range zones {
+		resp.HostedZones = append(resp.HostedZones, z.xml())
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+func (s *Server) findZone(id string) (HostedZone, int) {
+	i := slices.IndexFunc(s.fixtures.HostedZones, func(z HostedZone) bool { return z.ID == id })
+	if i < 0 {
+		return HostedZone{}, -1
+	}
+	return s.fixtures.HostedZones[i], i
+}
+
+func (s *Server) getHostedZone(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	z, i := s.findZone(id)
+	s.mu.Unlock()
+	if i < 0 {
+		writeRoute53Error(w, http.StatusNotFound, "NoSuchHostedZone", "No hosted zone found with ID: "+id)
+		return
+	}
+	resp := getHostedZoneResponse{Xmlns: route53Namespace, HostedZone: z.xml(), Vpcs: z.Vpcs}
+	if !z.Private {
+		resp.NameServers = nameServers
+	}
+	writeXML(w, http.StatusOK, resp)
+}
+
+func (s *Server) createHostedZone(w http.ResponseWriter, r *http.Request) {
+	var req createHostedZoneRequest
+	if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRoute53Error(w, http.StatusBadRequest, "InvalidInput", err.Error())
+		return
+	}
+	if req.Name == "" || req.CallerReference == "" {
+		writeRoute53Error(w, http.StatusBadRequest, "InvalidInput", "Name and CallerReference are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, z := range s.fixtures.HostedZones {
+		if z.CallerReference == req.CallerReference {
+			writeRoute53Error(w, http.StatusConflict, "HostedZoneAlreadyExists",
+				"A hosted zone has already been created with the specified caller reference.")
+			return
+		}
+	}
+	s.nextZone++
+	z := HostedZone{
+		ID:              fmt.Sprintf("ZFAKE%08d", s.nextZone),
+		Name:            fqdn(req.Name),
+		CallerReference: req.CallerReference,
+		Private:         req.PrivateZone || req.Vpc != nil,
+	}
+	if req.Vpc != nil {
+		z.Vpcs = []ZoneVpc{*req.Vpc}
+	}
+	s.fixtures.HostedZones = append(s.fixtures.HostedZones, z)
+
+	w.Header().Set("Location", "https://route53.amazonaws.com/2013-04-01/hostedzone/"+z.ID)
+	writeXML(w, http.StatusCreated, createHostedZoneResponse{
+		Xmlns:       route53Namespace,
+		HostedZone:  z.xml(),
+		ChangeInfo:  changeInfo(z.ID),
+		NameServers: nameServers,
+		Vpc:         req.Vpc,
+	})
+}
+
+func (s *Server) deleteHostedZone(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, i := s.findZone(id)
+	if i < 0 {
+		writeRoute53Error(w, http.StatusNotFound, "NoSuchHostedZone", "No hosted zone found with ID: "+id)
+		return
+	}
+	s.fixtures.HostedZones = slices.Delete(s.fixtures.HostedZones, i, i+1)
+	writeXML(w, http.StatusOK, deleteHostedZoneResponse{Xmlns: route53Namespace, ChangeInfo: changeInfo(id)})
+}
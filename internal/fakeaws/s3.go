@@ -0,0 +1,141 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Bucket is an S3 bucket with the content of its objects by key.
+type Bucket struct {
+	Name    string            `json:"name"`
+	Objects map[string]string `json:"objects,omitempty"`
+}
+
+// lastModified is the modification time of every object of the fake.
+const lastModified = "2026-01-01T00:00:00.000Z"
+
+type listBucketResult struct {
+	XMLName               xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	KeyCount              int            `xml:"KeyCount"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	Contents              []objectResult `xml:"Contents"`
+}
+
+type objectResult struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// s3Error is the error of the S3 REST API, which has no envelope.
+type s3Error struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, s3Error{Code: code, Message: message, RequestID: requestID})
+}
+
+// isS3 tells S3 requests apart from those of the query protocol. The fake only serves ListObjectsV2, which is the one
+// GET with a list-type parameter; clients must use path-style addressing.
+func isS3(r *http.Request) bool {
+	return r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2"
+}
+
+func (s *Server) serveS3(w http.ResponseWriter, r *http.Request) {
+	s.record("s3", "ListObjectsV2")
+	name := strings.Trim(r.URL.Path, "/")
+	if name == "" || strings.Contains(name, "/") {
+		writeS3Error(w, http.StatusBadRequest, "InvalidRequest", "fakeaws only serves path-style ListObjectsV2")
+		return
+	}
+	q := r.URL.Query()
+	limit := 1000
+	if v := q.Get("max-keys"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeS3Error(w, http.StatusBadRequest, "InvalidArgument", "invalid max-keys "+v)
+			return
+		}
+	}
+	// The token is the index of the next key.
+	start := 0
+	if token := q.Get("continuation-token"); token != "" {
+		var err error
+		if start, err = strconv.Atoi(token); err != nil {
+			writeS3Error(w, http.StatusBadRequest, "InvalidArgument", "invalid continuation token "+token)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	f := s.fixtures
+	s.mu.Unlock()
+	i := slices.IndexFunc(f.Buckets, func(b Bucket) bool { return b.Name == name })
+	if i < 0 {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	bucket := f.Buckets[i]
+	prefix := q.Get("prefix")
+	var keys []string
+	for key := range bucket.Objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	resp := listBucketResult{
+		Name:              name,
+		Prefix:            prefix,
+		MaxKeys:           limit,
+		ContinuationToken: q.Get("continuation-token"),
+	}
+	for i := start; i < len(keys); i++ {
+		if len(resp.Contents) == limit {
+			resp.IsTruncated = true
+			resp.NextContinuationToken = strconv.Itoa(i)
+			break
+		}
+		body := bucket.Objects[keys[i]]
+		resp.Contents = append(resp.Contents, objectResult{
+			Key:          keys[i],
+			LastModified: lastModified,
+			ETag:         fmt.Sprintf("%q", fmt.Sprintf("%x", md5.Sum([]byte(body)))),
+			Size:         len(body),
+			StorageClass: "STANDARD",
+		})
+	}
+	resp.KeyCount = len(resp.Contents)
+	writeXML(w, http.StatusOK, resp)
+}
@@ -0,0 +1,82 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Parameter is an SSM parameter.
+type Parameter struct {
+	Name string `json:"name"`
+	// Type is String by default.
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+}
+
+type getParameterRequest struct {
+	Name           string `json:"Name"`
+	WithDecryption bool   `json:"WithDecryption"`
+}
+
+type parameterJSON struct {
+	Name     string `json:"Name"`
+	Type     string `json:"Type"`
+	Value    string `json:"Value"`
+	Version  int    `json:"Version"`
+	ARN      string `json:"ARN"`
+	DataType string `json:"DataType"`
+}
+
+type getParameterResponse struct {
+	Parameter parameterJSON `json:"Parameter"`
+}
+
+func (s *Server) serveSSM(w http.ResponseWriter, r *http.Request, operation string) {
+	s.record("ssm", operation)
+	if operation != "GetParameter" {
+		writeJSONError(w, http.StatusBadRequest, "UnknownOperationException", "fakeaws does not implement ssm:"+operation)
+		return
+	}
+	var req getParameterRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	f := s.fixtures
+	s.mu.Unlock()
+	i := slices.IndexFunc(f.Parameters, func(p Parameter) bool { return p.Name == req.Name })
+	if i < 0 {
+		writeJSONError(w, http.StatusBadRequest, "ParameterNotFound", "Parameter "+req.Name+" not found.")
+		return
+	}
+	p := f.Parameters[i]
+	typ := p.Type
+	if typ == "" {
+		typ = "String"
+	}
+	writeJSON(w, http.StatusOK, getParameterResponse{Parameter: parameterJSON{
+		Name:     p.Name,
+		Type:     typ,
+		Value:    p.Value,
+		Version:  1,
+		ARN:      fmt.Sprintf("arn:%s:ssm:%s:%s:parameter/%s", f.partition(r), signingRegion(r), f.Account, strings.TrimPrefix(p.Name, "/")),
+		DataType: "text",
+	}})
+}
@@ -0,0 +1,79 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeaws
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const stsNamespace = "https://sts.amazonaws.com/doc/2011-06-15/"
+
+type getCallerIdentityResponse struct {
+	XMLName   xml.Name `xml:"GetCallerIdentityResponse"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Arn       string   `xml:"GetCallerIdentityResult>Arn"`
+	UserID    string   `xml:"GetCallerIdentityResult>UserId"`
+	Account   string   `xml:"GetCallerIdentityResult>Account"`
+	RequestID string   `xml:"ResponseMetadata>RequestId"`
+}
+
+type assumeRoleResponse struct {
+	XMLName         xml.Name `xml:"AssumeRoleResponse"`
+	Xmlns           string   `xml:"xmlns,attr"`
+	AccessKeyID     string   `xml:"AssumeRoleResult>Credentials>AccessKeyId"`
+	SecretAccessKey string   `xml:"AssumeRoleResult>Credentials>SecretAccessKey"`
+	SessionToken    string   `xml:"AssumeRoleResult>Credentials>SessionToken"`
+	Expiration      string   `xml:"AssumeRoleResult>Credentials>Expiration"`
+	Arn             string   `xml:"AssumeRoleResult>AssumedRoleUser>Arn"`
+	AssumedRoleID   string   `xml:"AssumeRoleResult>AssumedRoleUser>AssumedRoleId"`
+	RequestID       string   `xml:"ResponseMetadata>RequestId"`
+}
+
+func (s *Server) serveSTS(w http.ResponseWriter, r *http.Request) {
+	action := r.Form.Get("Action")
+	s.record("sts", action)
+	f := s.fixtures
+	switch action {
+	case "GetCallerIdentity":
+		writeXML(w, http.StatusOK, getCallerIdentityResponse{
+			Xmlns:     stsNamespace,
+			Arn:       fmt.Sprintf("arn:%s:iam::%s:user/fakeaws", f.partition(r), f.Account),
+			UserID:    "AIDAFAKEAWS",
+			Account:   f.Account,
+			RequestID: requestID,
+		})
+	case "AssumeRole":
+		// CDK lookups try the lookup role of the bootstrap stack first.
+		role := r.Form.Get("RoleArn")
+		name := role[strings.LastIndex(role, "/")+1:]
+		session := r.Form.Get("RoleSessionName")
+		writeXML(w, http.StatusOK, assumeRoleResponse{
+			Xmlns:           stsNamespace,
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+			Expiration:      time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			Arn:             fmt.Sprintf("arn:%s:sts::%s:assumed-role/%s/%s", f.partition(r), f.Account, name, session),
+			AssumedRoleID:   "AROAFAKEAWS:" + session,
+			RequestID:       requestID,
+		})
+	default:
+		writeQueryError(w, http.StatusBadRequest, "InvalidAction", "fakeaws does not implement sts:"+action)
+	}
+}
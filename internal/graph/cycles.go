@@ -0,0 +1,174 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+)
+
+// Cycle is a chain of dependencies that leads back to where it started. The first entry is repeated at the end, and
+// Kinds[i] is the kind of the edge from Paths[i] to Paths[i+1].
+type Cycle struct {
+	Paths []string   `json:"paths"`
+	Kinds []EdgeKind `json:"kinds"`
+}
+
+func (c Cycle) String() string {
+	var b strings.Builder
+	for i, p := range c.Paths {
+		if i > 0 {
+			b.WriteString(" -(" + string(c.Kinds[i-1]) + ")-> ")
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// Cycles reports one cycle for every strongly connected component of the graph that has one. The topological sort in
+// src/graph.ts silently tolerates cycles, which shows up as creation ordering problems at deploy time.
+func (g *Graph) Cycles() []Cycle {
+	var cycles []Cycle
+	for _, scc := range g.stronglyConnected() {
+		if len(scc) == 1 {
+			n := scc[0]
+			if _, self := n.out[n]; !self {
+				continue
+			}
+		}
+		cycles = append(cycles, cycleWithin(scc))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Paths[0] < cycles[j].Paths[0] })
+	return cycles
+}
+
+// CheckAssembly builds the graph of every stack in the cloud assembly at dir and returns the cycles found, keyed by
+// stack artifact ID. Stacks without cycles are omitted.
+func CheckAssembly(dir string) (map[string][]Cycle, error) {
+	a, err := assembly.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string][]Cycle{}
+	for _, s := range a.Stacks {
+		g, err := Build(s)
+		if err != nil {
+			return nil, fmt.Errorf("building graph for stack %s: %w", s.ID, err)
+		}
+		if cycles := g.Cycles(); len(cycles) > 0 {
+			found[s.ID] = cycles
+		}
+	}
+	return found, nil
+}
+
+// stronglyConnected implements Tarjan's algorithm.
+func (g *Graph) stronglyConnected() [][]*Node {
+	index := map[*Node]int{}
+	low := map[*Node]int{}
+	onStack := map[*Node]bool{}
+	var stack []*Node
+	var sccs [][]*Node
+	next := 0
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		index[n], low[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, d := range n.Dependencies() {
+			if _, seen := index[d]; !seen {
+				visit(d)
+				low[n] = min(low[n], low[d])
+			} else if onStack[d] {
+				low[n] = min(low[n], index[d])
+			}
+		}
+
+		if low[n] == index[n] {
+			var scc []*Node
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				scc = append(scc, top)
+				if top == n {
+					break
+				}
+			}
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+	return sccs
+}
+
+// cycleWithin finds the shortest cycle through the lexically smallest node of a strongly connected component.
+func cycleWithin(scc []*Node) Cycle {
+	members := map[*Node]bool{}
+	for _, n := range scc {
+		members[n] = true
+	}
+	sort.Slice(scc, func(i, j int) bool { return scc[i].Path < scc[j].Path })
+	start := scc[0]
+
+	// Breadth-first search from start back to start, staying inside the component.
+	prev := map[*Node]*Node{}
+	queue := []*Node{start}
+	var last *Node
+	for len(queue) > 0 && last == nil {
+		n := queue[0]
+		queue = queue[1:]
+		for _, d := range n.Dependencies() {
+			if d == start {
+				last = n
+				break
+			}
+			if _, seen := prev[d]; !seen && members[d] {
+				prev[d] = n
+				queue = append(queue, d)
+			}
+		}
+	}
+
+	chain := []*Node{start}
+	for n := last; n != start; n = prev[n] {
+		chain = append(chain, n)
+	}
+	// chain is start followed by the path in reverse; flip everything after start.
+	for i, j := 1, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	chain = append(chain, start)
+
+	c := Cycle{}
+	for i, n := range chain {
+		c.Paths = append(c.Paths, n.Path)
+		if i < len(chain)-1 {
+			c.Kinds = append(c.Kinds, n.out[chain[i+1]])
+		}
+	}
+	return c
+}
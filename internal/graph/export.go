@@ -0,0 +1,81 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes the nodes, edges and cycles of the graph as a single JSON document.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	cycles := g.Cycles()
+	if cycles == nil {
+		cycles = []Cycle{}
+	}
+	doc := struct {
+		Stack  string  `json:"stack"`
+		Nodes  []*Node `json:"nodes"`
+		Edges  []Edge  `json:"edges"`
+		Cycles []Cycle `json:"cycles"`
+	}{g.StackID, g.Nodes, g.Edges(), cycles}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Resource nodes are drawn as boxes, wrapper constructs as
+// ellipses, and edges that are part of a cycle are drawn in red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	inCycle := map[[2]string]bool{}
+	for _, c := range g.Cycles() {
+		for i := 0; i < len(c.Paths)-1; i++ {
+			inCycle[[2]string{c.Paths[i], c.Paths[i+1]}] = true
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "digraph %s {\n  rankdir=LR;\n", strconv.Quote(g.StackID)); err != nil {
+		return err
+	}
+	for _, n := range g.Nodes {
+		shape := "ellipse"
+		label := n.Path + "\\n" + n.Type
+		if n.Address != nil {
+			shape = "box"
+			label += "\\n" + n.Address.ID
+		}
+		label = strings.ReplaceAll(label, `"`, `\"`)
+		if _, err := fmt.Fprintf(w, "  %s [shape=%s, label=\"%s\"];\n", strconv.Quote(n.Path), shape, label); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges() {
+		attrs := fmt.Sprintf("label=%q", string(e.Kind))
+		if e.Kind == EdgeParent {
+			attrs += ", style=dashed"
+		}
+		if inCycle[[2]string{e.From, e.To}] {
+			attrs += ", color=red"
+		}
+		if _, err := fmt.Fprintf(w, "  %s -> %s [%s];\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,384 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graph builds the construct dependency graph of a synthesized stack the same way `GraphBuilder` in
+// src/graph.ts does, so that ordering problems and cycles can be inspected without running a Pulumi program.
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+	"github.com/pulumi/pulumi-cdk/internal/cfn"
+)
+
+// EdgeKind describes why one node depends on another.
+type EdgeKind string
+
+const (
+	// EdgeParent links a construct to its scope.
+	EdgeParent EdgeKind = "parent"
+	// EdgeRef is a Ref, Fn::GetAtt or Fn::Sub reference.
+	EdgeRef EdgeKind = "ref"
+	// EdgeDependsOn is an explicit DependsOn.
+	EdgeDependsOn EdgeKind = "dependsOn"
+)
+
+// Node is a construct in the graph. Nodes that represent a CloudFormation resource have an Address.
+type Node struct {
+	Path    string            `json:"path"`
+	Type    string            `json:"type"`
+	Address *assembly.Address `json:"address,omitempty"`
+	out     map[*Node]EdgeKind
+}
+
+// Edge is a dependency of From on To.
+type Edge struct {
+	From string   `json:"from"`
+	To   string   `json:"to"`
+	Kind EdgeKind `json:"kind"`
+}
+
+// Graph is the dependency graph of one stack, including its nested stacks.
+type Graph struct {
+	StackID string
+	Nodes   []*Node
+
+	byAddress map[assembly.Address]*Node
+}
+
+// Build constructs the dependency graph of the stack.
+func Build(s *assembly.Stack) (*Graph, error) {
+	b := &builder{
+		stack: s,
+		g: &Graph{
+			StackID:   s.ID,
+			byAddress: map[assembly.Address]*Node{},
+		},
+		parents: map[*Node]*Node{},
+		vpcs:    map[assembly.Address]*Node{},
+	}
+	if err := b.parseTree(s.Tree, nil); err != nil {
+		return nil, err
+	}
+	if err := b.linkVPCCidrBlocks(); err != nil {
+		return nil, err
+	}
+	for _, n := range b.g.Nodes {
+		if p := b.parents[n]; p != nil {
+			n.addEdge(p, EdgeParent)
+		}
+		if n.Address == nil {
+			continue
+		}
+		r, _ := s.Resource(*n.Address)
+		b.addEdgesForValue(r.Properties, n, n.Address.StackPath)
+		for _, target := range r.DependsOnList() {
+			b.addEdgeForRef(target, n, n.Address.StackPath, EdgeDependsOn)
+		}
+	}
+	return b.g, nil
+}
+
+func (n *Node) addEdge(to *Node, kind EdgeKind) {
+	if n.out == nil {
+		n.out = map[*Node]EdgeKind{}
+	}
+	// Parent edges are the weakest explanation, so a ref or DependsOn to the same node wins.
+	if existing, ok := n.out[to]; ok && existing != EdgeParent {
+		return
+	}
+	n.out[to] = kind
+}
+
+// Dependencies returns the nodes this node depends on, ordered by path.
+func (n *Node) Dependencies() []*Node {
+	deps := make([]*Node, 0, len(n.out))
+	for d := range n.out {
+		deps = append(deps, d)
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	return deps
+}
+
+// Edges returns every edge of the graph in a stable order.
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, n := range g.Nodes {
+		for _, d := range n.Dependencies() {
+			edges = append(edges, Edge{From: n.Path, To: d.Path, Kind: n.out[d]})
+		}
+	}
+	return edges
+}
+
+// NodeForAddress returns the node of the resource at the given address, or nil.
+func (g *Graph) NodeForAddress(a assembly.Address) *Node {
+	return g.byAddress[a]
+}
+
+type builder struct {
+	stack   *assembly.Stack
+	g       *Graph
+	parents map[*Node]*Node
+	// vpcs maps the address of every AWS::EC2::VPC to the node of its AWS::EC2::VPCCidrBlock, or nil.
+	vpcs       map[assembly.Address]*Node
+	cidrBlocks []*Node
+}
+
+func (b *builder) newNode(path, typ string, parent *Node) *Node {
+	n := &Node{Path: path, Type: typ}
+	b.g.Nodes = append(b.g.Nodes, n)
+	b.parents[n] = parent
+	return n
+}
+
+func (b *builder) setResource(n *Node, path string) (cfn.Resource, error) {
+	addr, err := b.stack.ResourceAddressForPath(path)
+	if err != nil {
+		return cfn.Resource{}, err
+	}
+	r, ok := b.stack.Resource(addr)
+	if !ok {
+		return cfn.Resource{}, fmt.Errorf("resource %s not found in stack %s", addr.ID, addr.StackPath)
+	}
+	n.Address = &addr
+	b.g.byAddress[addr] = n
+	return r, nil
+}
+
+func (b *builder) parseTree(tree *assembly.TreeNode, parent *Node) error {
+	typ := tree.ID
+	if fqn := tree.Fqn(); fqn != "" {
+		typ = typeFromFqn(fqn)
+	}
+	n := b.newNode(tree.Path, typ, parent)
+
+	if cfnType := tree.CfnType(); cfnType != "" {
+		r, err := b.setResource(n, tree.Path)
+		if err != nil {
+			return err
+		}
+		if r.Type != cfnType {
+			return fmt.Errorf("Something went wrong: resourceType %s does not equal CfnType %s", r.Type, cfnType)
+		}
+		n.Type = typeFromCfn(cfnType)
+		switch r.Type {
+		case "AWS::EC2::VPCCidrBlock":
+			b.cidrBlocks = append(b.cidrBlocks, n)
+		case "AWS::EC2::VPC":
+			b.vpcs[*n.Address] = nil
+		}
+	} else if tree.Fqn() == "aws-cdk-lib.CfnResource" {
+		r, err := b.setResource(n, tree.Path)
+		if err != nil {
+			return err
+		}
+		// Custom Resources do not map to types. E.g. Custom::Bucket should not map to Bucket
+		if !(tree.ID == "Default" && parent != nil && parent.Type == "aws-cdk-lib:CustomResource") {
+			n.Type = typeFromCfn(r.Type)
+		}
+	}
+
+	nestedIDs := map[string]bool{}
+	for _, ns := range b.findNestedStacks(tree) {
+		nestedIDs[ns.node.ID] = true
+		nestedIDs[ns.node.ID+".NestedStack"] = true
+
+		nsNode := b.newNode(ns.node.Path, "Stack", n)
+		if _, err := b.setResource(nsNode, ns.resourcePath); err != nil {
+			return err
+		}
+		for _, child := range ns.node.SortedChildren() {
+			if err := b.parseTree(child, nsNode); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, child := range tree.SortedChildren() {
+		if nestedIDs[child.ID] {
+			continue
+		}
+		if err := b.parseTree(child, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkVPCCidrBlocks pairs every VPCCidrBlock with the VPC it refers to. It runs after parseTree, which may visit a
+// block before its VPC.
+func (b *builder) linkVPCCidrBlocks() error {
+	for _, n := range b.cidrBlocks {
+		r, _ := b.stack.Resource(*n.Address)
+		ref, _ := r.Properties["VpcId"].(map[string]any)
+		id, ok := ref["Ref"].(string)
+		if !ok {
+			continue
+		}
+		vpc := assembly.Address{StackPath: n.Address.StackPath, ID: id}
+		block, ok := b.vpcs[vpc]
+		if !ok {
+			return fmt.Errorf("VPC resource %s not found for VPCCidrBlock %s in stack %s", id, n.Address.ID,
+				n.Address.StackPath)
+		}
+		// The CDK VPC only supports a single VPCCidrBlock per VPC.
+		if block != nil {
+			return fmt.Errorf("VPC %s in stack %s already has a VPCCidrBlock", id, n.Address.StackPath)
+		}
+		b.vpcs[vpc] = n
+	}
+	return nil
+}
+
+type nestedStackData struct {
+	resourcePath string
+	node         *assembly.TreeNode
+}
+
+// findNestedStacks matches `NAME.NestedStack` children holding an AWS::CloudFormation::Stack resource with the sibling
+// `NAME` node that holds the nested stack's constructs.
+func (b *builder) findNestedStacks(tree *assembly.TreeNode) []nestedStackData {
+	var out []nestedStackData
+	for _, child := range tree.SortedChildren() {
+		if !strings.HasSuffix(child.ID, ".NestedStack") {
+			continue
+		}
+		for _, res := range child.SortedChildren() {
+			if res.CfnType() != "AWS::CloudFormation::Stack" {
+				continue
+			}
+			nestedPath, err := assembly.NestedStackPath(res.Path, res.ID)
+			if err != nil {
+				continue
+			}
+			for _, sibling := range tree.SortedChildren() {
+				if sibling.Path == nestedPath {
+					out = append(out, nestedStackData{resourcePath: res.Path, node: sibling})
+				}
+			}
+			break
+		}
+	}
+	return out
+}
+
+func (b *builder) addEdgesForValue(obj any, source *Node, stackPath string) {
+	switch x := obj.(type) {
+	case []any:
+		for _, v := range x {
+			b.addEdgesForValue(v, source, stackPath)
+		}
+	case map[string]any:
+		if ref, ok := x["Ref"]; ok && ref != nil {
+			b.addEdgeForRef(ref, source, stackPath, EdgeRef)
+			return
+		}
+		if len(x) == 1 {
+			for k, v := range x {
+				if strings.HasPrefix(k, "Fn::") {
+					b.addEdgesForIntrinsic(k, v, source, stackPath)
+					return
+				}
+			}
+		}
+		for _, k := range sortedKeys(x) {
+			b.addEdgesForValue(x[k], source, stackPath)
+		}
+	}
+}
+
+func (b *builder) addEdgeForRef(arg any, source *Node, stackPath string, kind EdgeKind) {
+	id, ok := arg.(string)
+	if !ok || strings.HasPrefix(id, "AWS::") {
+		// Either malformed references, Pulumi outputs or pseudo-parameters.
+		return
+	}
+	if target := b.g.byAddress[assembly.Address{StackPath: stackPath, ID: id}]; target != nil {
+		source.addEdge(target, kind)
+	}
+}
+
+func (b *builder) addEdgesForIntrinsic(fn string, params any, source *Node, stackPath string) {
+	switch fn {
+	case "Fn::GetAtt":
+		args, ok := params.([]any)
+		if !ok || len(args) != 2 {
+			return
+		}
+		logicalID, _ := args[0].(string)
+		attr, _ := args[1].(string)
+		target := b.g.byAddress[assembly.Address{StackPath: stackPath, ID: logicalID}]
+		// A GetAtt on a nested stack reads one of its outputs, which depends on resources in the nested stack.
+		if target != nil {
+			if ns, ok := b.stack.NestedStacks[target.Path]; ok {
+				if out, ok := ns.Template.Outputs[strings.TrimPrefix(attr, "Outputs.")]; ok {
+					b.addEdgesForValue(out.Value, source, target.Path)
+				}
+			}
+		}
+		// Due to pulumi/pulumi-aws-native#1798 the Ipv6CidrBlocks attribute of a VPC is always empty, so the converter
+		// reads the Ipv6CidrBlock attribute of its VPCCidrBlock instead, which depends on the VPC in turn.
+		if attr == "Ipv6CidrBlocks" {
+			if block := b.vpcs[assembly.Address{StackPath: stackPath, ID: logicalID}]; block != nil {
+				logicalID = block.Address.ID
+			}
+		}
+		b.addEdgeForRef(logicalID, source, stackPath, EdgeRef)
+	case "Fn::Sub":
+		var template string
+		switch p := params.(type) {
+		case string:
+			template = p
+		case []any:
+			if len(p) > 0 {
+				template, _ = p[0].(string)
+			}
+			if len(p) > 1 {
+				b.addEdgesForValue(p[1], source, stackPath)
+			}
+		}
+		for _, part := range cfn.ParseSub(template) {
+			if part.Ref != nil {
+				b.addEdgeForRef(part.Ref.ID, source, stackPath, EdgeRef)
+			}
+		}
+	default:
+		b.addEdgesForValue(params, source, stackPath)
+	}
+}
+
+// typeFromCfn turns `AWS::S3::Bucket` into `Bucket`.
+func typeFromCfn(cfnType string) string {
+	parts := strings.Split(cfnType, "::")
+	return parts[len(parts)-1]
+}
+
+// typeFromFqn turns `aws-cdk-lib.aws_s3.Bucket` into `aws-cdk-lib/aws_s3:Bucket`.
+func typeFromFqn(fqn string) string {
+	parts := strings.Split(fqn, ".")
+	return strings.Join(parts[:len(parts)-1], "/") + ":" + parts[len(parts)-1]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,143 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nestedStackAssembly = "../../tests/test-data/nested-stack"
+
+func buildNestedStackGraph(t *testing.T) *Graph {
+	t.Helper()
+	a, err := assembly.Load(nestedStackAssembly)
+	require.NoError(t, err)
+	s := a.Stack("teststack")
+	require.NotNil(t, s)
+	g, err := Build(s)
+	require.NoError(t, err)
+	return g
+}
+
+func dependsOn(g *Graph, from, to string) EdgeKind {
+	for _, e := range g.Edges() {
+		if e.From == from && e.To == to {
+			return e.Kind
+		}
+	}
+	return ""
+}
+
+func TestBuildNestedStack(t *testing.T) {
+	g := buildNestedStackGraph(t)
+
+	nested := g.NodeForAddress(assembly.Address{StackPath: "teststack", ID: "nestyNestedStacknestyNestedStackResource"})
+	require.NotNil(t, nested)
+	assert.Equal(t, "teststack/nesty", nested.Path)
+	assert.Equal(t, "Stack", nested.Type)
+
+	// Resources inside the nested stack are parented to the nested stack node.
+	assert.Equal(t, EdgeParent, dependsOn(g, "teststack/nesty/bucket", "teststack/nesty"))
+	// The nested stack passes a Ref to the parent bucket as a parameter.
+	assert.Equal(t, EdgeRef, dependsOn(g, "teststack/nesty", "teststack/bucket/Resource"))
+	// Refs inside the nested stack resolve within the nested stack.
+	assert.Equal(t, EdgeRef, dependsOn(g, "teststack/nesty/bucket/Policy/Resource", "teststack/nesty/bucket/Resource"))
+
+	assert.Empty(t, g.Cycles())
+}
+
+func TestVPCIpv6CidrBlocks(t *testing.T) {
+	a, err := assembly.Load("testdata/vpc-ipv6")
+	require.NoError(t, err)
+
+	g, err := Build(a.Stack("stack"))
+	require.NoError(t, err)
+	// The Ipv6CidrBlocks of the VPC are read from its VPCCidrBlock, so the dependency moves to the block.
+	assert.Equal(t, EdgeRef, dependsOn(g, "stack/other", "stack/cidr"))
+	assert.Equal(t, EdgeRef, dependsOn(g, "stack/cidr", "stack/vpc"))
+	assert.Equal(t, EdgeKind(""), dependsOn(g, "stack/other", "stack/vpc"))
+
+	_, err = Build(a.Stack("twocidrs"))
+	assert.EqualError(t, err, "VPC vpc in stack twocidrs already has a VPCCidrBlock")
+}
+
+func TestCycleDetection(t *testing.T) {
+	g := buildNestedStackGraph(t)
+	assert.Empty(t, g.Cycles())
+
+	bucket := g.NodeForAddress(assembly.Address{StackPath: "teststack/nesty", ID: "bucket43879C71"})
+	policy := g.NodeForAddress(assembly.Address{StackPath: "teststack/nesty", ID: "bucketPolicy638F945D"})
+	require.NotNil(t, bucket)
+	require.NotNil(t, policy)
+	bucket.addEdge(policy, EdgeDependsOn)
+
+	cycles := g.Cycles()
+	require.Len(t, cycles, 1)
+	assert.Equal(t, []string{
+		"teststack/nesty/bucket/Policy/Resource",
+		"teststack/nesty/bucket/Resource",
+		"teststack/nesty/bucket/Policy/Resource",
+	}, cycles[0].Paths)
+	assert.Equal(t, []EdgeKind{EdgeRef, EdgeDependsOn}, cycles[0].Kinds)
+	assert.Equal(t,
+		"teststack/nesty/bucket/Policy/Resource -(ref)-> teststack/nesty/bucket/Resource -(dependsOn)-> teststack/nesty/bucket/Policy/Resource",
+		cycles[0].String())
+
+	var dot bytes.Buffer
+	require.NoError(t, g.WriteDOT(&dot))
+	assert.Contains(t, dot.String(),
+		`"teststack/nesty/bucket/Resource" -> "teststack/nesty/bucket/Policy/Resource" [label="dependsOn", color=red];`)
+}
+
+func TestSelfCycle(t *testing.T) {
+	g := buildNestedStackGraph(t)
+	bucket := g.NodeForAddress(assembly.Address{StackPath: "teststack", ID: "bucket"})
+	bucket.addEdge(bucket, EdgeDependsOn)
+
+	cycles := g.Cycles()
+	require.Len(t, cycles, 1)
+	assert.Equal(t, []string{"teststack/bucket/Resource", "teststack/bucket/Resource"}, cycles[0].Paths)
+}
+
+func TestWriteJSON(t *testing.T) {
+	g := buildNestedStackGraph(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteJSON(&buf))
+
+	var doc struct {
+		Stack  string  `json:"stack"`
+		Nodes  []Node  `json:"nodes"`
+		Edges  []Edge  `json:"edges"`
+		Cycles []Cycle `json:"cycles"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "teststack", doc.Stack)
+	assert.Len(t, doc.Nodes, len(g.Nodes))
+	assert.NotEmpty(t, doc.Edges)
+	assert.Empty(t, doc.Cycles)
+}
+
+func TestCheckAssembly(t *testing.T) {
+	cycles, err := CheckAssembly(nestedStackAssembly)
+	require.NoError(t, err)
+	assert.Empty(t, cycles)
+}
@@ -0,0 +1,344 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pulumi/pulumi-cdk/internal/assets"
+	"github.com/pulumi/pulumi-cdk/internal/fakeaws"
+	"github.com/pulumi/pulumi-cdk/internal/graph"
+	"github.com/pulumi/pulumi-cdk/internal/partitions"
+	"github.com/pulumi/pulumi-cdk/internal/secrets"
+	"github.com/pulumi/pulumi-cdk/internal/state"
+	"github.com/pulumi/pulumi-cdk/internal/urns"
+	"github.com/pulumi/pulumi-cdk/internal/zipdiff"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/require"
+)
+
+// previewAssembly previews the program in a temp project of its own, removes the stack again and returns the
+// directory of the cloud assembly that it synthesized.
+func previewAssembly(t *testing.T, opts integration.ProgramTestOptions) string {
+	outdir := t.TempDir()
+	test := WithArtifacts(t, WithNodeModules(t, opts.With(integration.ProgramTestOptions{
+		Env: []string{"PULUMI_CDK_OUTDIR=" + outdir},
+	})))
+
+	pt := integration.ProgramTestManualLifeCycle(t, &test)
+	require.NoError(t, pt.TestLifeCyclePrepare(), "copying test to temp dir")
+	defer pt.TestCleanUp()
+	require.NoError(t, pt.TestLifeCycleInitialize(), "initializing test project")
+	defer func() {
+		// Only a preview ran, so this just removes the stack.
+		if err := pt.TestLifeCycleDestroy(); err != nil {
+			t.Logf("removing stack: %v", err)
+		}
+	}()
+	require.NoError(t, pt.RunPulumiCommand("preview"), "previewing")
+	return outdir
+}
+
+// AssertNoDependencyCycles previews the program with the cloud assembly written to a temp directory and checks the
+// dependency graph of every stack in it for cycles, so that ordering problems are caught before anything is deployed.
+func AssertNoDependencyCycles(t *testing.T, opts integration.ProgramTestOptions) {
+	outdir := previewAssembly(t, opts)
+
+	cycles, err := graph.CheckAssembly(outdir)
+	require.NoError(t, err)
+	for stack, cs := range cycles {
+		for _, c := range cs {
+			t.Errorf("dependency cycle in stack %s: %s", stack, c)
+		}
+	}
+}
+
+// FakeAWS starts the fake STS, Route53 and EC2 of internal/fakeaws, seeded with the fixtures that fakeaws.EnvVar
+// points to, e.g. fakeaws.json. It returns nil if the variable is not set.
+func FakeAWS(t *testing.T) *fakeaws.Server {
+	path := os.Getenv(fakeaws.EnvVar)
+	if path == "" {
+		return nil
+	}
+	fixtures, err := fakeaws.Load(path)
+	require.NoError(t, err)
+	fake := fakeaws.Start(fixtures)
+	t.Cleanup(fake.Close)
+	t.Logf("AWS lookups go to the fake at %s", fake.URL)
+	return fake
+}
+
+// AWSConfig returns the config of the AWS SDK clients of a test, which call the fake if there is one.
+func AWSConfig(t *testing.T, fake *fakeaws.Server) aws.Config {
+	if fake != nil {
+		return fake.Config(EnvRegion(t))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(EnvRegion(t)))
+	require.NoError(t, err)
+	return cfg
+}
+
+// WithFakeAWS points the AWS SDKs of the program, and with them its CDK context lookups, at the fake.
+func WithFakeAWS(opts integration.ProgramTestOptions, fake *fakeaws.Server) integration.ProgramTestOptions {
+	if fake == nil {
+		return opts
+	}
+	return opts.With(integration.ProgramTestOptions{Env: fake.Env()})
+}
+
+// AssertReproducibleZips previews the program twice, each time copied to another temp directory, and checks that the
+// zip archives of its ZIP_DIRECTORY assets are byte-identical, see internal/zipdiff. Archives that differ between
+// runs update the resources that use them on every `pulumi up`.
+func AssertReproducibleZips(t *testing.T, opts integration.ProgramTestOptions) {
+	// Programs name their stacks after the Pulumi stack, so both runs use the same one.
+	opts.StackName = opts.GetStackName().String()
+
+	first := previewAssembly(t, opts)
+	n, err := zipdiff.ZipAssets(first)
+	require.NoError(t, err)
+	if n == 0 {
+		t.Skip("the program has no zip assets")
+	}
+	second := previewAssembly(t, opts)
+
+	diffs, err := zipdiff.CompareAssemblies(first, second)
+	require.NoError(t, err)
+	for _, d := range diffs {
+		t.Errorf("zip asset differs between synth runs: %s", d)
+	}
+}
+
+// WithStateInvariants writes the cloud assembly to a temp directory and checks the stack after the initial update and
+// after every edit with assertStateInvariants.
+func WithStateInvariants(t *testing.T, opts integration.ProgramTestOptions, checks state.Options) integration.ProgramTestOptions {
+	opts, outdir := withAssemblyDir(t, opts)
+	return WithValidation(opts, func(t *testing.T, _ int, stack integration.RuntimeValidationStackInfo) {
+		assertStateInvariants(t, stack, outdir, checks)
+	})
+}
+
+// WithPartitionChecks checks the stack after the initial update and after every edit with assertPartition, for the
+// partition of the region in the options.
+func WithPartitionChecks(opts integration.ProgramTestOptions) integration.ProgramTestOptions {
+	partition := partitions.ForRegion(opts.Config["aws-native:region"])
+	return WithValidation(opts, func(t *testing.T, _ int, stack integration.RuntimeValidationStackInfo) {
+		assertPartition(t, stack, partition)
+	})
+}
+
+// WithAssetChecks checks after the initial update and after every edit that the assets of the cloud assembly are
+// published, see assertAssetsPublished. It calls S3 and ECR, so programs with file or Docker image assets opt in.
+func WithAssetChecks(t *testing.T, opts integration.ProgramTestOptions) integration.ProgramTestOptions {
+	opts, outdir := withAssemblyDir(t, opts)
+	// The assets of earlier steps stay published, so each step expects those of the steps before it.
+	published := &assets.Expected{}
+	return WithValidation(opts, func(t *testing.T, _ int, stack integration.RuntimeValidationStackInfo) {
+		assertAssetsPublished(t, stack, outdir, published)
+	})
+}
+
+// withAssemblyDir makes the program write its cloud assembly to a temp directory and returns the directory. Options
+// that an earlier call applied keep their directory, so that the checks share one assembly.
+func withAssemblyDir(t *testing.T, opts integration.ProgramTestOptions) (integration.ProgramTestOptions, string) {
+	for _, env := range opts.Env {
+		if outdir, ok := strings.CutPrefix(env, "PULUMI_CDK_OUTDIR="); ok {
+			return opts, outdir
+		}
+	}
+	outdir := t.TempDir()
+	opts.Env = append(append([]string{}, opts.Env...), "PULUMI_CDK_OUTDIR="+outdir)
+	return opts, outdir
+}
+
+// assertStateInvariants checks the exported checkpoint against what the converter promises about the resources it
+// registers, see internal/state.
+func assertStateInvariants(t *testing.T, stack integration.RuntimeValidationStackInfo, outdir string, checks state.Options) {
+	violations, err := state.CheckWithAssemblyDir(stack.Deployment, outdir, checks)
+	require.NoError(t, err)
+	for _, v := range violations {
+		t.Errorf("state invariant violated: %s", v)
+	}
+}
+
+// assertPartition checks that the resources and the outputs of the stack refer to the partition of the region that the
+// program runs in only, see internal/partitions.
+func assertPartition(t *testing.T, stack integration.RuntimeValidationStackInfo, p partitions.Partition) {
+	for _, v := range partitions.CheckDeployment(stack.Deployment, p) {
+		t.Errorf("refers to another partition than %s: %s", p.ID, v)
+	}
+}
+
+// assertAssetsPublished checks that the staging bucket and the staging repositories of the app hold the assets of the
+// cloud assembly, and of the earlier steps, and nothing else, see internal/assets.
+func assertAssetsPublished(t *testing.T, stack integration.RuntimeValidationStackInfo, outdir string,
+	published *assets.Expected,
+) {
+	if _, err := os.Stat(filepath.Join(outdir, "manifest.json")); err != nil {
+		return
+	}
+	e, err := assets.Load(outdir)
+	require.NoError(t, err)
+	published.Merge(e)
+
+	var target assets.Target
+	bucket, repositories := assets.Staging(stack.Deployment)
+	if bucket != "" || len(repositories) > 0 {
+		cfg := AWSConfig(t, nil)
+		if bucket != "" {
+			target.Bucket = assets.S3Bucket{Client: s3.NewFromConfig(cfg), Name: bucket}
+		}
+		target.Registry = assets.ECRRegistry{Config: cfg}
+		target.Repositories = repositories
+	}
+	problems, err := assets.Verify(context.Background(), published, target)
+	require.NoError(t, err)
+	for _, p := range problems {
+		t.Errorf("asset not published as its manifest says: %s", p)
+	}
+}
+
+// URNBaselineVersion returns the published version of @pulumi/cdk to compare URNs against, set with
+// PULUMI_CDK_URN_BASELINE, and skips the test when it is not set.
+func URNBaselineVersion(t *testing.T) string {
+	version := os.Getenv("PULUMI_CDK_URN_BASELINE")
+	if version == "" {
+		t.Skip("Skipping URN stability check, set PULUMI_CDK_URN_BASELINE to a published @pulumi/cdk version")
+	}
+	return version
+}
+
+// AssertStableURNs previews the program with the baseline version of @pulumi/cdk and with the local build and fails if
+// any URN changed, unless the change is listed in `urns.ack.json` next to the program. Proposed aliases are logged so
+// that the upgrade can be made replacement-free.
+func AssertStableURNs(t *testing.T, opts integration.ProgramTestOptions, baselineVersion string) {
+	// Both runs must use the same stack name for the URNs to be comparable.
+	opts.StackName = opts.GetStackName().String()
+
+	baselineOpts := opts
+	baselineOpts.Dependencies = nil
+	baselineOpts.Overrides = maps.Clone(opts.Overrides)
+	delete(baselineOpts.Overrides, "@pulumi/cdk")
+	baselineOpts.PrePrepareProject = func(p *engine.Projinfo) error {
+		return urns.PinDependency(filepath.Join(p.Root, "package.json"), "@pulumi/cdk", baselineVersion)
+	}
+	before := previewURNs(t, baselineOpts)
+	after := previewURNs(t, opts)
+
+	d := urns.Compare(before, after)
+	ack, err := urns.LoadAck(filepath.Join(opts.Dir, "urns.ack.json"))
+	require.NoError(t, err)
+	unacked := ack.Unacknowledged(d)
+
+	var report bytes.Buffer
+	require.NoError(t, urns.WriteReport(&report, d, urns.ProposeAliases(d), unacked))
+	t.Logf("URNs of @pulumi/cdk@%s compared to the local build:\n%s", baselineVersion, report.String())
+	if !unacked.Empty() {
+		t.Errorf("URNs changed since @pulumi/cdk@%s; add aliases or acknowledge the changes in %s", baselineVersion,
+			filepath.Join(opts.Dir, "urns.ack.json"))
+	}
+}
+
+// previewURNs returns the URNs that a preview of the program would register.
+func previewURNs(t *testing.T, opts integration.ProgramTestOptions) []resource.URN {
+	found, err := urns.LoadPlan(previewPlan(t, opts))
+	require.NoError(t, err)
+	return found
+}
+
+// previewPlan previews the program in a temp project of its own, removes the stack again and returns the plan file
+// that the preview saved.
+func previewPlan(t *testing.T, opts integration.ProgramTestOptions) string {
+	opts = WithArtifacts(t, WithNodeModules(t, opts))
+	pt := integration.ProgramTestManualLifeCycle(t, &opts)
+	require.NoError(t, pt.TestLifeCyclePrepare(), "copying test to temp dir")
+	defer pt.TestCleanUp()
+	require.NoError(t, pt.TestLifeCycleInitialize(), "initializing test project")
+	defer func() {
+		// Only a preview ran, so this just removes the stack.
+		if err := pt.TestLifeCycleDestroy(); err != nil {
+			t.Logf("removing stack: %v", err)
+		}
+	}()
+
+	plan := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, pt.RunPulumiCommand("preview", "--save-plan", plan), "previewing")
+	return plan
+}
+
+// AssertPartitionPreview previews the program with every AWS call of the program and of the providers going to a
+// fake, which answers in the partition of the region the request is signed for, and checks that the resources the
+// preview would register refer to partition p only, see internal/partitions. Values that are only known after an
+// update are checked by WithPartitionChecks instead, when the region matrix includes a region of p.
+func AssertPartitionPreview(t *testing.T, opts integration.ProgramTestOptions, p partitions.Partition) {
+	fake := fakeaws.Start(fakeaws.Fixtures{})
+	t.Cleanup(fake.Close)
+	opts = opts.With(integration.ProgramTestOptions{Env: fake.Env()})
+
+	violations, err := partitions.CheckPlan(previewPlan(t, opts), p)
+	require.NoError(t, err)
+	for _, v := range violations {
+		t.Errorf("refers to another partition than %s: %s", p.ID, v)
+	}
+}
+
+// WithSecretScan fails the test when a secret known to the scanner shows up in plaintext in the checkpoint, the stack
+// outputs, the engine events or the output of the Pulumi CLI. The CLI output only reaches Stdout and Stderr in verbose
+// mode, so this turns it on; the output itself is kept by WithArtifacts.
+func WithSecretScan(t *testing.T, opts integration.ProgramTestOptions, scanner *secrets.Scanner) integration.ProgramTestOptions {
+	var stdout, stderr bytes.Buffer
+	opts.Verbose = true
+	opts.Stdout = io.MultiWriter(&stdout, writerOr(opts.Stdout, os.Stdout))
+	opts.Stderr = io.MultiWriter(&stderr, writerOr(opts.Stderr, os.Stderr))
+
+	reported := map[secrets.Finding]bool{}
+	scan := func(t *testing.T, stack *integration.RuntimeValidationStackInfo) {
+		var all []secrets.Finding
+		collect := func(findings []secrets.Finding, err error) {
+			require.NoError(t, err)
+			all = append(all, findings...)
+		}
+		if stack != nil {
+			collect(scanner.ScanDeployment(stack.Deployment))
+			collect(scanner.ScanValue("outputs", "outputs", map[string]any(stack.Outputs)))
+			collect(scanner.ScanEvents(stack.Events))
+		}
+		collect(scanner.ScanText("stdout", stdout.Bytes()))
+		collect(scanner.ScanText("stderr", stderr.Bytes()))
+		for _, f := range all {
+			if !reported[f] {
+				reported[f] = true
+				t.Errorf("%s", f)
+			}
+		}
+	}
+	opts = WithValidation(opts, func(t *testing.T, _ int, stack integration.RuntimeValidationStackInfo) {
+		scan(t, &stack)
+	})
+	// The destroy step runs after the last validation.
+	t.Cleanup(func() { scan(t, nil) })
+	return opts
+}
@@ -0,0 +1,267 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package harness is the shared harness of the acceptance test packages, examples/ and integration/. It wraps
+// integration.ProgramTest with what every test gets: a packed @pulumi/cdk, cached node_modules, a per-run backend,
+// captured artifacts, a reproducer bundle, teardown journaling, the region matrix and the post-update checks of the
+// internal packages. Main runs the steps that precede and follow m.Run in each package's TestMain.
+package harness
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/artifacts"
+	"github.com/pulumi/pulumi-cdk/internal/backend"
+	"github.com/pulumi/pulumi-cdk/internal/doctor"
+	"github.com/pulumi/pulumi-cdk/internal/quarantine"
+	"github.com/pulumi/pulumi-cdk/internal/runreport"
+	"github.com/pulumi/pulumi-cdk/internal/shard"
+	"github.com/pulumi/pulumi-cdk/internal/teardown"
+	"github.com/stretchr/testify/require"
+)
+
+// runReport records the steps of every test in the package; Main writes it out.
+var runReport = runreport.New("")
+
+// runBackend is the Pulumi backend of every test in this run, or nil to keep the backend and secrets provider the
+// environment configures, see internal/backend.
+var runBackend *backend.Local
+
+// Main runs the tests of the acceptance test package pkg, e.g. "examples", from its TestMain and returns the exit
+// code. It applies the quarantine and the shard, runs the doctor, creates the backend of the run and writes the run
+// report once the tests are done.
+func Main(m *testing.M, pkg string) int {
+	// Pulumi commands run through this binary, see WithTeardown.
+	teardown.RunShim()
+	flag.Parse()
+	runReport = runreport.New(pkg)
+	if err := applyQuarantine(); err != nil {
+		fmt.Fprintf(os.Stderr, "applying quarantine: %v\n", err)
+		return 2
+	}
+	if err := applyShard(); err != nil {
+		fmt.Fprintf(os.Stderr, "applying %s: %v\n", shard.EnvVar, err)
+		return 2
+	}
+	if !runDoctor() {
+		return 1
+	}
+	if err := setupBackend(); err != nil {
+		fmt.Fprintf(os.Stderr, "creating the Pulumi backend: %v\n", err)
+		return 2
+	}
+	code := m.Run()
+	if err := writeRunReport(runReport); err != nil {
+		fmt.Fprintf(os.Stderr, "writing run report: %v\n", err)
+	}
+	removeBackend()
+	return code
+}
+
+// quarantineFile lists the tests of the package that are taken out of the blocking run, see internal/quarantine.
+const quarantineFile = "quarantine.json"
+
+// quarantineRun is set for the non-blocking run of the quarantined tests, which records their outcomes in the
+// quarantine history.
+var quarantineRun = os.Getenv("PULUMI_CDK_QUARANTINE_RUN") != ""
+
+// applyQuarantine narrows down the tests m.Run runs: the blocking run skips every quarantined test and the quarantine
+// run only runs those. Expired quarantines no longer apply and fail CheckQuarantine. Set
+// PULUMI_CDK_IGNORE_QUARANTINE to run quarantined tests by hand.
+func applyQuarantine() error {
+	if os.Getenv("PULUMI_CDK_IGNORE_QUARANTINE") != "" {
+		return nil
+	}
+	q, err := quarantine.Load(quarantineFile)
+	if err != nil {
+		return err
+	}
+	if quarantineRun {
+		active := q.Active(time.Now())
+		for _, e := range active {
+			fmt.Fprintf(os.Stderr, "running %s\n", e)
+		}
+		return flag.Set("test.run", quarantine.Pattern(active))
+	}
+	active := q.Active(time.Now())
+	if len(active) == 0 {
+		return nil
+	}
+	for _, e := range active {
+		fmt.Fprintf(os.Stderr, "skipping %s\n", e)
+	}
+	pattern := quarantine.Pattern(active)
+	if skip := flag.Lookup("test.skip").Value.String(); skip != "" {
+		pattern = skip + "|" + pattern
+	}
+	return flag.Set("test.skip", pattern)
+}
+
+// timingsFile holds the duration of every test of the package in earlier runs; update it with
+// `go run ./cmd/cdk-test-timings`.
+const timingsFile = "timings.json"
+
+// applyShard restricts m.Run to the tests of the shard selected by CDK_TEST_SHARD and writes the shard report,
+// `<package>-shard-<index>-of-<total>.json` in the artifact root. The quarantine run is not sharded.
+func applyShard() error {
+	env := os.Getenv(shard.EnvVar)
+	if env == "" || quarantineRun {
+		return nil
+	}
+	sh, err := shard.Parse(env)
+	if err != nil {
+		return err
+	}
+	if run := flag.Lookup("test.run").Value.String(); run != "" {
+		return fmt.Errorf("cannot be combined with -run %q", run)
+	}
+	tests, err := shard.ListTests(".")
+	if err != nil {
+		return err
+	}
+	timings, err := shard.LoadTimings(timingsFile)
+	if err != nil {
+		return err
+	}
+	report := shard.Report{
+		Package: runReport.Package,
+		Shard:   sh.Index,
+		Total:   sh.Total,
+		Plan:    shard.Plan(tests, timings, sh.Total),
+	}
+	runReport.Shard = sh.String()
+	fmt.Fprintf(os.Stderr, "shard %s runs %d of %d tests: %s\n", sh, len(report.Tests()), len(tests),
+		strings.Join(report.Tests(), ", "))
+
+	root := artifacts.Root()
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(root, fmt.Sprintf("%s-shard-%d-of-%d.json", report.Package, sh.Index, sh.Total))
+	if err := report.Write(path); err != nil {
+		return err
+	}
+	return flag.Set("test.run", shard.Pattern(report.Tests()))
+}
+
+// quarantineHistory is the file the quarantine run appends the outcomes of quarantined tests to.
+func quarantineHistory() string {
+	if path := os.Getenv("PULUMI_CDK_QUARANTINE_HISTORY"); path != "" {
+		return path
+	}
+	return filepath.Join(artifacts.Root(), "quarantine-history.jsonl")
+}
+
+// recordQuarantined appends the outcome of a quarantined test to the quarantine history during the quarantine run.
+func recordQuarantined(t *testing.T) {
+	if !quarantineRun || t.Name() != strings.SplitN(t.Name(), "/", 2)[0] {
+		return
+	}
+	t.Cleanup(func() {
+		if t.Skipped() {
+			return
+		}
+		path := quarantineHistory()
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = quarantine.Record(path, quarantine.Result{
+				Test:   t.Name(),
+				Time:   time.Now().UTC(),
+				Passed: !t.Failed(),
+				Commit: os.Getenv("GITHUB_SHA"),
+			})
+		}
+		if err != nil {
+			t.Logf("recording quarantine history: %v", err)
+		}
+	})
+}
+
+// CheckQuarantine fails when a quarantine of the package expired, so that skipped tests are either fixed or their
+// quarantine is renewed deliberately, and logs the pass rate of the quarantined tests.
+func CheckQuarantine(t *testing.T) {
+	q, err := quarantine.Load(quarantineFile)
+	require.NoError(t, err)
+	for _, e := range q.Expired(time.Now()) {
+		t.Errorf("quarantine expired, fix the test or extend the quarantine in %s: %s", quarantineFile, e)
+	}
+	history, err := quarantine.LoadHistory(quarantineHistory())
+	require.NoError(t, err)
+	for _, s := range quarantine.Summarize(history) {
+		t.Log(s)
+	}
+}
+
+// runDoctor runs the checks of `go run ./cmd/cdk-test-doctor` and prints the problems it finds.
+// PULUMI_CDK_TEST_DOCTOR=off turns the checks off and PULUMI_CDK_TEST_DOCTOR=strict makes failed checks stop the
+// tests; runDoctor then returns false.
+func runDoctor() bool {
+	mode := os.Getenv("PULUMI_CDK_TEST_DOCTOR")
+	if mode == "off" {
+		return true
+	}
+	root, err := doctor.FindRoot(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "doctor: %v\n", err)
+		return mode != "strict"
+	}
+	results := doctor.Run(context.Background(), doctor.DefaultEnv(root))
+	if doctor.Failed(results) || mode == "strict" {
+		doctor.Print(os.Stderr, results, true)
+	}
+	return !(doctor.Failed(results) && mode == "strict")
+}
+
+// setupBackend creates the local backend of the run unless backend.AmbientEnvVar is set.
+func setupBackend() error {
+	if backend.Ambient() {
+		return nil
+	}
+	// The Pulumi CLI would use this over the generated passphrase.
+	if err := os.Unsetenv("PULUMI_CONFIG_PASSPHRASE"); err != nil {
+		return err
+	}
+	var err error
+	runBackend, err = backend.NewLocal("")
+	return err
+}
+
+// removeBackend removes the local backend of the run, unless the teardown journal lists stacks in it that were not
+// destroyed: `go run ./cmd/cdk-teardown` needs their state.
+func removeBackend() {
+	if runBackend == nil {
+		return
+	}
+	entries, err := teardown.Load(teardownJournal())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keeping the Pulumi backend %s: %v\n", runBackend.Dir, err)
+		return
+	}
+	for _, e := range teardown.Leftovers(entries) {
+		if e.Backend == runBackend.URL {
+			fmt.Fprintf(os.Stderr, "keeping the Pulumi backend %s for stacks that were not destroyed\n", runBackend.Dir)
+			return
+		}
+	}
+	if err := runBackend.Remove(); err != nil {
+		fmt.Fprintf(os.Stderr, "removing the Pulumi backend: %v\n", err)
+	}
+}
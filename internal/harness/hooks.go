@@ -0,0 +1,93 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+)
+
+// Validation checks the stack after an update. Step is 0 for the initial update and i for the i-th edit.
+type Validation func(t *testing.T, step int, stack integration.RuntimeValidationStackInfo)
+
+// WithValidation runs v after the runtime validation that the test sets for the initial update and for every edit.
+func WithValidation(opts integration.ProgramTestOptions, v Validation) integration.ProgramTestOptions {
+	return chainValidation(opts, v, false)
+}
+
+// chainValidation runs v before or after the runtime validation of every step. Bookkeeping runs first, so that it
+// happens even when the test's own validation stops the test.
+func chainValidation(opts integration.ProgramTestOptions, v Validation, first bool) integration.ProgramTestOptions {
+	chain := func(step int, validate func(*testing.T, integration.RuntimeValidationStackInfo)) func(*testing.T, integration.RuntimeValidationStackInfo) {
+		return func(t *testing.T, stack integration.RuntimeValidationStackInfo) {
+			if first {
+				v(t, step, stack)
+			}
+			if validate != nil {
+				validate(t, stack)
+			}
+			if !first {
+				v(t, step, stack)
+			}
+		}
+	}
+	opts.ExtraRuntimeValidation = chain(0, opts.ExtraRuntimeValidation)
+	editDirs := make([]integration.EditDir, len(opts.EditDirs))
+	for i, edit := range opts.EditDirs {
+		edit.ExtraRuntimeValidation = chain(i+1, edit.ExtraRuntimeValidation)
+		editDirs[i] = edit
+	}
+	opts.EditDirs = editDirs
+	return opts
+}
+
+// commandHook runs before a Pulumi command, e.g. "up". The function it returns, which may be nil, runs after the
+// command with its error.
+type commandHook func(verb string) (func(error) error, error)
+
+// withCommandHook runs hook around every Pulumi command, inside the hooks the options have already. When hook fails,
+// the command does not run and the outer hooks see its error as the command's.
+func withCommandHook(opts integration.ProgramTestOptions, hook commandHook) integration.ProgramTestOptions {
+	pre := opts.PrePulumiCommand
+	opts.PrePulumiCommand = func(verb string) (func(error) error, error) {
+		var post func(error) error
+		if pre != nil {
+			var err error
+			if post, err = pre(verb); err != nil {
+				return nil, err
+			}
+		}
+		after, err := hook(verb)
+		if err != nil {
+			if post != nil {
+				err = errors.Join(err, post(err))
+			}
+			return nil, err
+		}
+		return func(cmdErr error) error {
+			var err error
+			if after != nil {
+				err = after(cmdErr)
+			}
+			if post != nil {
+				err = errors.Join(post(cmdErr), err)
+			}
+			return err
+		}, nil
+	}
+	return opts
+}
@@ -0,0 +1,137 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithValidation(t *testing.T) {
+	var calls []string
+	record := func(name string) func(*testing.T, integration.RuntimeValidationStackInfo) {
+		return func(*testing.T, integration.RuntimeValidationStackInfo) { calls = append(calls, name) }
+	}
+	opts := integration.ProgramTestOptions{
+		ExtraRuntimeValidation: record("test"),
+		EditDirs: []integration.EditDir{
+			{Dir: "step1", ExtraRuntimeValidation: record("test1")},
+			{Dir: "step2"},
+		},
+	}
+	opts = WithValidation(opts, func(_ *testing.T, step int, _ integration.RuntimeValidationStackInfo) {
+		calls = append(calls, fmt.Sprintf("after%d", step))
+	})
+	opts = chainValidation(opts, func(_ *testing.T, step int, _ integration.RuntimeValidationStackInfo) {
+		calls = append(calls, fmt.Sprintf("before%d", step))
+	}, true)
+
+	opts.ExtraRuntimeValidation(t, integration.RuntimeValidationStackInfo{})
+	for _, edit := range opts.EditDirs {
+		edit.ExtraRuntimeValidation(t, integration.RuntimeValidationStackInfo{})
+	}
+	assert.Equal(t, []string{
+		"before0", "test", "after0",
+		"before1", "test1", "after1",
+		"before2", "after2",
+	}, calls)
+}
+
+func TestWithValidationKeepsEditDirs(t *testing.T) {
+	edits := []integration.EditDir{{Dir: "step1"}}
+	opts := WithValidation(integration.ProgramTestOptions{EditDirs: edits},
+		func(*testing.T, int, integration.RuntimeValidationStackInfo) {})
+	assert.Nil(t, edits[0].ExtraRuntimeValidation, "the caller's edit dirs must not change")
+	assert.NotNil(t, opts.EditDirs[0].ExtraRuntimeValidation)
+}
+
+func TestWithCommandHook(t *testing.T) {
+	var calls []string
+	hook := func(name string) commandHook {
+		return func(verb string) (func(error) error, error) {
+			calls = append(calls, name+" pre "+verb)
+			return func(err error) error {
+				calls = append(calls, fmt.Sprintf("%s post %v", name, err))
+				return nil
+			}, nil
+		}
+	}
+	opts := withCommandHook(integration.ProgramTestOptions{}, hook("outer"))
+	opts = withCommandHook(opts, hook("inner"))
+
+	post, err := opts.PrePulumiCommand("up")
+	require.NoError(t, err)
+	require.NoError(t, post(errors.New("boom")))
+	assert.Equal(t, []string{
+		"outer pre up",
+		"inner pre up",
+		"inner post boom",
+		"outer post boom",
+	}, calls)
+}
+
+func TestWithCommandHookNilPost(t *testing.T) {
+	opts := withCommandHook(integration.ProgramTestOptions{}, func(string) (func(error) error, error) {
+		return nil, nil
+	})
+	post, err := opts.PrePulumiCommand("preview")
+	require.NoError(t, err)
+	assert.NoError(t, post(nil))
+}
+
+func TestWithCommandHookFails(t *testing.T) {
+	released := false
+	opts := withCommandHook(integration.ProgramTestOptions{}, func(string) (func(error) error, error) {
+		return func(error) error {
+			released = true
+			return nil
+		}, nil
+	})
+	refused := errors.New("refused")
+	innerRan := false
+	opts = withCommandHook(opts, func(string) (func(error) error, error) {
+		return nil, refused
+	})
+	opts = withCommandHook(opts, func(string) (func(error) error, error) {
+		innerRan = true
+		return nil, nil
+	})
+
+	_, err := opts.PrePulumiCommand("up")
+	assert.ErrorIs(t, err, refused)
+	assert.True(t, released, "the outer hooks must see the failure")
+	assert.False(t, innerRan)
+}
+
+func TestWithCommandHookJoinsErrors(t *testing.T) {
+	outerErr, innerErr := errors.New("outer"), errors.New("inner")
+	opts := withCommandHook(integration.ProgramTestOptions{}, func(string) (func(error) error, error) {
+		return func(error) error { return outerErr }, nil
+	})
+	opts = withCommandHook(opts, func(string) (func(error) error, error) {
+		return func(error) error { return innerErr }, nil
+	})
+
+	post, err := opts.PrePulumiCommand("destroy")
+	require.NoError(t, err)
+	err = post(nil)
+	assert.ErrorIs(t, err, outerErr)
+	assert.ErrorIs(t, err, innerErr)
+}
@@ -0,0 +1,439 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/artifacts"
+	"github.com/pulumi/pulumi-cdk/internal/nodecache"
+	"github.com/pulumi/pulumi-cdk/internal/repro"
+	"github.com/pulumi/pulumi-cdk/internal/runreport"
+	"github.com/pulumi/pulumi-cdk/internal/state"
+	"github.com/pulumi/pulumi-cdk/internal/teardown"
+	"github.com/pulumi/pulumi-cdk/internal/throttle"
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ProgramTest runs the test like integration.ProgramTest with the checks of WithStateInvariants after every update, in
+// each region of the region matrix.
+func ProgramTest(t *testing.T, opts *integration.ProgramTestOptions) {
+	inEachRegion(t, *opts, func(t *testing.T, opts integration.ProgramTestOptions) {
+		test := WithStateInvariants(t, opts, state.Options{})
+		RunProgramTest(t, &test, false)
+	})
+}
+
+// ProgramTestIgnoreDestroyErrors is ProgramTest for programs whose destroy is known to fail.
+func ProgramTestIgnoreDestroyErrors(t *testing.T, opts *integration.ProgramTestOptions) {
+	inEachRegion(t, *opts, func(t *testing.T, opts integration.ProgramTestOptions) {
+		test := WithStateInvariants(t, opts, state.Options{})
+		RunProgramTest(t, &test, true)
+	})
+}
+
+// RunProgramTest runs the test like integration.ProgramTest, through the manual lifecycle so that a reproducer bundle
+// can be written to the artifact directory before a failed test's stack is destroyed. With ignoreDestroyErrors set, a
+// failing destroy is logged instead of failing the test.
+func RunProgramTest(t *testing.T, opts *integration.ProgramTestOptions, ignoreDestroyErrors bool) {
+	test, r := withReproducer(withRunReport(t, WithArtifacts(t, WithNodeModules(t, *opts))))
+	test, td := WithTeardown(t, test)
+	defer td.Stop()
+	pt := integration.ProgramTestManualLifeCycle(t, &test)
+
+	// Inlined pt.TestLifeCycleInitAndDestroy()
+	testLifeCycleInitAndDestroy := func() (err error) {
+		err = pt.TestLifeCyclePrepare()
+		if err != nil {
+			return fmt.Errorf("copying test to temp dir: %w", err)
+		}
+
+		pt.TestFinished = false
+		if test.DestroyOnCleanup {
+			t.Cleanup(pt.TestCleanUp)
+		} else {
+			defer pt.TestCleanUp()
+		}
+
+		err = pt.TestLifeCycleInitialize()
+		if err != nil {
+			return fmt.Errorf("initializing test project: %w", err)
+		}
+		RecordTeardown(t, pt, &test, teardown.Created, nil)
+
+		destroyStack := func() {
+			if err != nil || t.Failed() {
+				r.write(t, pt, &test)
+			}
+			if reason, canceled := td.Canceled(); canceled {
+				t.Logf("Tearing down early, %s", reason)
+			}
+			destroyErr := pt.TestLifeCycleDestroy()
+			if destroyErr != nil {
+				RecordTeardown(t, pt, &test, teardown.Failed, destroyErr)
+			} else {
+				RecordTeardown(t, pt, &test, teardown.Destroyed, nil)
+			}
+			if destroyErr != nil && ignoreDestroyErrors {
+				t.Logf("IgnoreDestroyErrors: ignoring %v", destroyErr)
+			} else {
+				assert.NoError(t, destroyErr)
+			}
+		}
+		if test.DestroyOnCleanup {
+			// Allow other tests to refer to this stack until the test is complete.
+			t.Cleanup(destroyStack)
+		} else {
+			// Ensure that before we exit, we attempt to destroy and remove the stack.
+			defer destroyStack()
+		}
+
+		if err = pt.TestPreviewUpdateAndEdits(); err != nil {
+			return fmt.Errorf("running test preview, update, and edits: %w", err)
+		}
+
+		if test.RunUpdateTest {
+			if err = linkDependencies(t, pt, &test); err != nil {
+				return fmt.Errorf("upgrading project dependencies: %w", err)
+			}
+			if err = pt.TestPreviewUpdateAndEdits(); err != nil {
+				return fmt.Errorf("running test preview, update, and edits (updateTest): %w", err)
+			}
+		}
+
+		pt.TestFinished = true
+		return nil
+	}
+
+	err := testLifeCycleInitAndDestroy()
+	if !errors.Is(err, integration.ErrTestFailed) {
+		require.NoError(t, err)
+	}
+}
+
+// linkDependencies switches the project from the published packages it was deployed with to the linked local ones,
+// for the second pass of RunUpdateTest, and checks that the packed @pulumi/cdk is the one installed.
+func linkDependencies(t *testing.T, pt *integration.ProgramTester, opts *integration.ProgramTestOptions) error {
+	yarn := opts.YarnBin
+	if yarn == "" {
+		var err error
+		if yarn, err = exec.LookPath("yarn"); err != nil {
+			return err
+		}
+	}
+	projdir := filepath.Join(pt.GetTmpDir(), opts.RelativeWorkDir)
+	if !linkCDK {
+		build, err := packedCDK()
+		if err != nil {
+			return err
+		}
+		// The first pass pins @pulumi/cdk with a yarn resolution, which would win over the tarball.
+		if err := nodecache.RemoveOverride(projdir, "@pulumi/cdk"); err != nil {
+			return err
+		}
+		err = integration.RunCommand(t, "yarn-add", []string{yarn, "add", "@pulumi/cdk@" + build.Dependency()},
+			projdir, opts)
+		if err != nil {
+			return err
+		}
+		installed, err := nodecache.InstalledVersion(projdir, "@pulumi/cdk")
+		if err != nil {
+			return err
+		}
+		if installed != build.Version {
+			return fmt.Errorf("yarn add installed @pulumi/cdk@%s instead of %s", installed, build)
+		}
+		return nil
+	}
+	for _, dep := range opts.Dependencies {
+		if err := integration.RunCommand(t, "yarn-link", []string{yarn, "link", dep}, projdir, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withRunReport records the step timings, retries and resource counts of the test in runReport.
+func withRunReport(t *testing.T, opts integration.ProgramTestOptions) integration.ProgramTestOptions {
+	rec := runReport.Start(t.Name(), filepath.Base(opts.Dir))
+	t.Cleanup(func() {
+		switch {
+		case t.Failed():
+			rec.Finish(runreport.Fail)
+		case t.Skipped():
+			rec.Finish(runreport.Skip)
+		default:
+			rec.Finish(runreport.Pass)
+		}
+	})
+	opts.ReportStats = rec
+
+	return chainValidation(opts, func(_ *testing.T, _ int, stack integration.RuntimeValidationStackInfo) {
+		if stack.Deployment != nil {
+			rec.SetResources(len(stack.Deployment.Resources))
+		}
+	}, true)
+}
+
+// writeRunReport writes `<package>-report.json` and `<package>-report.xml` to the artifact root. When
+// PULUMI_CDK_TEST_REPORT_BASELINE points to the JSON report of an earlier run, tests whose deploy time grew by more
+// than PULUMI_CDK_TEST_REPORT_THRESHOLD (a fraction, 0.5 by default) are listed as regressions.
+func writeRunReport(report *runreport.Report) error {
+	if len(report.Tests) == 0 {
+		return nil
+	}
+	if baseline := os.Getenv("PULUMI_CDK_TEST_REPORT_BASELINE"); baseline != "" {
+		previous, err := runreport.Load(baseline)
+		if err != nil {
+			return err
+		}
+		threshold := 0.5
+		if s := os.Getenv("PULUMI_CDK_TEST_REPORT_THRESHOLD"); s != "" {
+			if threshold, err = strconv.ParseFloat(s, 64); err != nil {
+				return fmt.Errorf("PULUMI_CDK_TEST_REPORT_THRESHOLD: %w", err)
+			}
+		}
+		report.Regressions = runreport.Compare(previous, report, threshold)
+		for _, r := range report.Regressions {
+			fmt.Fprintf(os.Stderr, "deploy time regression: %s\n", r)
+		}
+	}
+
+	root := artifacts.Root()
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return err
+	}
+	for ext, write := range map[string]func(io.Writer) error{".json": report.WriteJSON, ".xml": report.WriteJUnit} {
+		f, err := os.Create(filepath.Join(root, report.Package+"-report"+ext))
+		if err != nil {
+			return err
+		}
+		err = write(f)
+		if err := errors.Join(err, f.Close()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reproducer tracks how far a test got, so that the bundle written when it fails reruns it up to the failing step.
+type reproducer struct {
+	// validated is the index of the last step whose runtime validation ran, -1 before the first.
+	validated int
+	// failedCommand is the Pulumi command that failed last, if it was not retried successfully.
+	failedCommand string
+}
+
+// withReproducer chains the step tracking onto the runtime validation of every step and onto every Pulumi command.
+func withReproducer(opts integration.ProgramTestOptions) (integration.ProgramTestOptions, *reproducer) {
+	r := &reproducer{validated: -1}
+	opts = chainValidation(opts, func(_ *testing.T, step int, _ integration.RuntimeValidationStackInfo) {
+		r.validated = step
+	}, true)
+	opts = withCommandHook(opts, func(verb string) (func(error) error, error) {
+		return func(cmdErr error) error {
+			r.failedCommand = ""
+			if cmdErr != nil {
+				r.failedCommand = verb
+			}
+			return nil
+		}, nil
+	})
+	return opts, r
+}
+
+func (r *reproducer) failedStep() repro.Step {
+	if r.failedCommand != "" {
+		return repro.Step{Index: r.validated + 1, Command: r.failedCommand}
+	}
+	return repro.Step{Index: max(r.validated, 0)}
+}
+
+// write writes `repro.tar.gz` to the test's artifact directory, see internal/repro. The stack still exists at this
+// point, so its state can be exported.
+func (r *reproducer) write(t *testing.T, pt *integration.ProgramTester, opts *integration.ProgramTestOptions) {
+	b := repro.Bundle{
+		TestName:   t.Name(),
+		Options:    repro.NewOptions(opts),
+		FailedStep: r.failedStep(),
+		ProjectDir: filepath.Join(pt.GetTmpDir(), opts.RelativeWorkDir),
+	}
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	if err := pt.RunPulumiCommand("stack", "export", "--file", stateFile); err != nil {
+		t.Logf("reproducer: exporting state: %v", err)
+	} else {
+		b.StateFile = stateFile
+	}
+	// The harness writes the event log of every update here.
+	eventsFile := filepath.Join(os.TempDir(), opts.GetStackName().String()+"-events.json")
+	if _, err := os.Stat(eventsFile); err == nil {
+		b.EventsFile = eventsFile
+	}
+
+	dir, err := artifacts.Dir(artifacts.Root(), t.Name())
+	if err == nil {
+		err = b.Write(filepath.Join(dir, "repro.tar.gz"))
+	}
+	if err != nil {
+		t.Logf("reproducer: %v", err)
+		return
+	}
+	t.Logf("reproducer for the %s: %s", b.FailedStep, filepath.Join(dir, "repro.tar.gz"))
+}
+
+// WithArtifacts captures the output of every command the test runs into its artifact directory, see
+// internal/artifacts, and points to the directory when the test fails. This needs verbose mode, in which the output
+// of the Pulumi CLI is streamed instead of printed once a command failed. The output still goes to the test's Stdout
+// and Stderr, by default those of the process, so that the CI log shows what a failed command printed.
+func WithArtifacts(t *testing.T, opts integration.ProgramTestOptions) integration.ProgramTestOptions {
+	dir, err := artifacts.Dir(artifacts.Root(), t.Name())
+	require.NoError(t, err)
+	capture, err := artifacts.NewCapture(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, capture.Close())
+		if t.Failed() {
+			t.Log(artifacts.Pointer(dir))
+		}
+	})
+
+	stdout, stderr := writerOr(opts.Stdout, os.Stdout), writerOr(opts.Stderr, os.Stderr)
+	if !opts.Verbose && opts.Stderr != nil {
+		// Outside of verbose mode a test's Stderr receives the combined output of failed commands, which tests grep
+		// for error messages. Keep giving it both streams.
+		stdout = io.MultiWriter(stdout, opts.Stderr)
+	}
+	opts.Verbose = true
+	opts.Stdout = io.MultiWriter(capture, stdout)
+	opts.Stderr = io.MultiWriter(capture, stderr)
+
+	return withCommandHook(opts, func(verb string) (func(error) error, error) {
+		if err := capture.Begin(verb); err != nil {
+			return nil, err
+		}
+		return func(error) error { return capture.End() }, nil
+	})
+}
+
+func writerOr(w io.Writer, fallback io.Writer) io.Writer {
+	if w == nil {
+		return fallback
+	}
+	return w
+}
+
+// WithTeardown cancels the test once only the teardown budget (see internal/teardown) is left before its deadline,
+// or when the process receives SIGINT or SIGTERM: the running Pulumi command is interrupted and the test skips ahead
+// to `pulumi destroy`. Pulumi commands run through the test binary as a shim so that they can be interrupted.
+func WithTeardown(t *testing.T, opts integration.ProgramTestOptions) (integration.ProgramTestOptions, *teardown.Watcher) {
+	budget, err := teardown.Budget()
+	require.NoError(t, err)
+	deadline, _ := t.Deadline()
+	pidFile := filepath.Join(t.TempDir(), "pulumi.pid")
+	w := teardown.Watch(deadline, budget, pidFile)
+
+	if teardown.ShimSupported {
+		bin := opts.Bin
+		if bin == "" {
+			bin, _ = exec.LookPath("pulumi")
+		}
+		if shim, err := os.Executable(); err == nil && bin != "" {
+			opts.Bin = shim
+			opts.Env = append(opts.Env, teardown.ShimEnv(bin, pidFile)...)
+		}
+	}
+
+	return withCommandHook(opts, func(verb string) (func(error) error, error) {
+		if reason, canceled := w.Canceled(); canceled && (verb == "preview" || verb == "up" || verb == "refresh") {
+			return nil, fmt.Errorf("not running pulumi %s, the test was canceled: %s", verb, reason)
+		}
+		w.Started()
+		return func(error) error {
+			w.Finished()
+			return nil
+		}, nil
+	}), w
+}
+
+// teardownJournal is the journal of the stacks the tests created, see `go run ./cmd/cdk-teardown`.
+func teardownJournal() string {
+	if path := os.Getenv("PULUMI_CDK_TEARDOWN_JOURNAL"); path != "" {
+		return path
+	}
+	return filepath.Join(artifacts.Root(), "teardown-journal.jsonl")
+}
+
+// RecordTeardown records the status of the test's stack in the teardown journal. RunProgramTest does this itself;
+// tests that drive the lifecycle by hand call it after initializing and destroying the stack.
+func RecordTeardown(t *testing.T, pt *integration.ProgramTester, opts *integration.ProgramTestOptions, status string,
+	destroyErr error,
+) {
+	e := teardown.Entry{
+		Time:       time.Now().UTC(),
+		Test:       t.Name(),
+		Stack:      string(opts.GetStackNameWithOwner()),
+		Status:     status,
+		Backend:    opts.CloudURL,
+		ProjectDir: filepath.Join(pt.GetTmpDir(), opts.RelativeWorkDir),
+		Region:     opts.Config["aws:region"],
+		Prefix:     opts.Config["prefix"],
+	}
+	if destroyErr != nil {
+		e.Error = destroyErr.Error()
+	}
+	path := teardownJournal()
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		err = teardown.Record(path, e)
+	}
+	if err != nil {
+		t.Logf("recording %s in the teardown journal: %v", e.Stack, err)
+	}
+}
+
+// throttleLocks limits the updates and destroys of tests that use a rate limited AWS API, across the tests of the
+// package and the other `go test` processes on the machine.
+var throttleLocks = throttle.New(throttle.Dir())
+
+// WithThrottle declares the rate limited services a test deploys, e.g. throttle.APIGateway. Its `pulumi up` and
+// `pulumi destroy` commands wait until no other test runs one for the same services.
+func WithThrottle(t *testing.T, opts integration.ProgramTestOptions, services ...string) integration.ProgramTestOptions {
+	return withCommandHook(opts, func(verb string) (func(error) error, error) {
+		if verb != "up" && verb != "destroy" {
+			return nil, nil
+		}
+		start := time.Now()
+		release, err := throttleLocks.Acquire(context.Background(), services...)
+		if err != nil {
+			return nil, err
+		}
+		if waited := time.Since(start); waited > time.Second {
+			t.Logf("waited %s for %s before pulumi %s", waited.Round(time.Second), strings.Join(services, ", "), verb)
+		}
+		return func(error) error { return release() }, nil
+	})
+}
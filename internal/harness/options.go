@@ -0,0 +1,204 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi-cdk/internal/backend"
+	"github.com/pulumi/pulumi-cdk/internal/doctor"
+	"github.com/pulumi/pulumi-cdk/internal/nodecache"
+	"github.com/pulumi/pulumi-cdk/internal/pack"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	ptesting "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// Cwd returns the directory of the test package, which holds the programs.
+func Cwd(t *testing.T) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.FailNow()
+	}
+
+	return cwd
+}
+
+// ProgramDirs lists the programs of the test package, i.e. every subdirectory with a Pulumi.yaml.
+func ProgramDirs(t *testing.T) []string {
+	entries, err := os.ReadDir(Cwd(t))
+	require.NoError(t, err)
+
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(Cwd(t), e.Name(), "Pulumi.yaml")); err == nil {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	return dirs
+}
+
+// EachProgram runs test in a subtest for each program of the test package, e.g. for the preview-only checks, and
+// skips the programs that skips lists with the reason.
+func EachProgram(t *testing.T, skips map[string]string, test func(t *testing.T, dir string)) {
+	for _, dir := range ProgramDirs(t) {
+		t.Run(dir, func(t *testing.T) {
+			if reason, ok := skips[dir]; ok {
+				t.Skip(reason)
+			}
+			test(t, dir)
+		})
+	}
+}
+
+func getPrefix() string {
+	prefix := os.Getenv("GITHUB_SHA")
+	if prefix == "" {
+		prefix = strconv.Itoa(rand.Intn(10000))
+	}
+	if len(prefix) > 5 {
+		prefix = prefix[:5]
+	}
+	// has to start with a letter
+	return fmt.Sprintf("a%s", prefix)
+}
+
+// BaseOptions are the options every test starts from: the region of the test, a resource name prefix and the
+// backend of the run.
+func BaseOptions(t *testing.T) integration.ProgramTestOptions {
+	recordQuarantined(t)
+	envRegion := EnvRegion(t)
+	prefix := getPrefix()
+	t.Logf("using prefix: %s", prefix)
+	opts := integration.ProgramTestOptions{
+		Config: map[string]string{
+			"aws:region":        envRegion,
+			"aws-native:region": envRegion,
+			"prefix":            prefix,
+		},
+		SkipRefresh:          true,
+		ExpectRefreshChanges: true,
+	}
+	if runBackend != nil {
+		opts.CloudURL = runBackend.URL
+		opts.SecretsProvider = backend.SecretsProvider
+		opts.Env = runBackend.Env()
+	}
+	opts.Env = append(opts.Env, "AWS_REGION="+envRegion)
+	return opts
+}
+
+// linkCDK makes the tests `yarn link` whatever is registered for @pulumi/cdk on the machine instead of installing a
+// packed build, which saves packing while iterating on a change.
+var linkCDK = os.Getenv(doctor.LinkEnvVar) != ""
+
+// packedCDK builds and packs @pulumi/cdk once per `go test` run, see internal/pack.
+var packedCDK = sync.OnceValues(func() (pack.Build, error) {
+	root, err := doctor.FindRoot(".")
+	if err != nil {
+		return pack.Build{}, err
+	}
+	p := &pack.Packer{Root: root, Dir: pack.Dir()}
+	return p.Pack(context.Background())
+})
+
+// WithCDK makes the program install the @pulumi/cdk packed for this run, and records the build in the run report.
+// Overrides of @pulumi/cdk that the test sets later, e.g. for an update test, take precedence.
+func WithCDK(t *testing.T, opts integration.ProgramTestOptions) integration.ProgramTestOptions {
+	if linkCDK {
+		opts.Dependencies = append(opts.Dependencies, "@pulumi/cdk")
+		return opts
+	}
+	build, err := packedCDK()
+	require.NoError(t, err, "packing @pulumi/cdk")
+	t.Logf("using %s", build)
+	runReport.SetBuild(build.String())
+	overrides := map[string]string{"@pulumi/cdk": build.Dependency()}
+	maps.Copy(overrides, opts.Overrides)
+	opts.Overrides = overrides
+	return opts
+}
+
+// nodeVersion is the version of Node.js on the PATH, which native modules in node_modules are built for.
+var nodeVersion = sync.OnceValue(func() string {
+	out, err := exec.Command("node", "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+})
+
+// WithNodeModules installs the program's dependencies from the shared node_modules cache, see internal/nodecache,
+// running `yarn install` only for a key that is not cached yet. Linked builds of @pulumi/cdk are not content-addressed,
+// so with them the harness installs as usual.
+func WithNodeModules(t *testing.T, opts integration.ProgramTestOptions) integration.ProgramTestOptions {
+	if linkCDK || opts.PrepareProject != nil {
+		return opts
+	}
+	cache := &nodecache.Cache{
+		Dir:     nodecache.Dir(),
+		Offline: nodecache.Offline(),
+		Salt:    nodeVersion(),
+		// The update test changes node_modules with `yarn add`, which must not reach the cache.
+		Copy: opts.RunUpdateTest,
+	}
+	opts.PrepareProject = func(p *engine.Projinfo) error {
+		if rt := p.Proj.Runtime.Name(); rt != integration.NodeJSRuntime {
+			return fmt.Errorf("no node_modules cache for %s programs", rt)
+		}
+		if err := ptesting.WriteYarnRCForTest(p.Root); err != nil {
+			return err
+		}
+		dir, _, err := p.GetPwdMain()
+		if err != nil {
+			return err
+		}
+		if err := nodecache.ApplyOverrides(dir, opts.Overrides); err != nil {
+			return err
+		}
+		yarn := opts.YarnBin
+		if yarn == "" {
+			if yarn, err = exec.LookPath("yarn"); err != nil {
+				return err
+			}
+		}
+		key, hit, err := cache.Install(context.Background(), dir, func() error {
+			return integration.RunCommand(t, "yarn-install", []string{yarn, "install"}, dir, &opts)
+		})
+		if err != nil {
+			return err
+		}
+		t.Logf("node_modules %s (hit: %t)", key[:16], hit)
+		if opts.RunBuild {
+			return integration.RunCommand(t, "yarn-build", []string{yarn, "run", "build"}, dir, &opts)
+		}
+		return nil
+	}
+	return opts
+}
@@ -0,0 +1,128 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package harness
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi-cdk/internal/partitions"
+	"github.com/pulumi/pulumi-cdk/internal/regions"
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	"github.com/stretchr/testify/require"
+)
+
+// EnvRegion returns the region the test runs in: the one ForEachRegion or InEachPartition picked for it, or else the
+// first region of the region matrix, which is AWS_REGION unless regions.EnvVar is set.
+func EnvRegion(t *testing.T) string {
+	if region, ok := testRegion(t); ok {
+		return region
+	}
+	matrix, err := regions.Matrix(os.Getenv)
+	require.NoError(t, err)
+	if len(matrix) == 0 {
+		t.Skipf("Skipping test due to missing AWS_REGION environment variable, see `go run ./cmd/cdk-test-doctor`")
+	}
+
+	return matrix[0]
+}
+
+// testRegions maps the names of the tests that ForEachRegion runs to their region, which their subtests inherit.
+var testRegions sync.Map
+
+// testRegion returns the region that ForEachRegion picked for the test or one of its parents.
+func testRegion(t *testing.T) (string, bool) {
+	name := t.Name()
+	for {
+		if region, ok := testRegions.Load(name); ok {
+			return region.(string), true
+		}
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return "", false
+		}
+		name = name[:i]
+	}
+}
+
+// inRegion runs test with region as the region of t and its subtests.
+func inRegion(t *testing.T, region string, test func(t *testing.T)) {
+	testRegions.Store(t.Name(), region)
+	t.Cleanup(func() { testRegions.Delete(t.Name()) })
+	test(t)
+}
+
+// ForEachRegion runs test in each region of the region matrix that the constraint allows, see internal/regions. A
+// test that runs in one region keeps its name, otherwise each region is a subtest named after it. Within test,
+// BaseOptions and the AWS SDK clients of the harness use the region, also in subtests.
+func ForEachRegion(t *testing.T, c regions.Constraint, test func(t *testing.T, region string)) {
+	matrix, err := regions.Matrix(os.Getenv)
+	require.NoError(t, err)
+	if len(matrix) == 0 {
+		t.Skipf("Skipping test due to missing AWS_REGION environment variable, see `go run ./cmd/cdk-test-doctor`")
+	}
+	run := func(t *testing.T, r regions.Run) {
+		if r.Skip != "" {
+			t.Skipf("Skipping test in %s: %s", r.Region, r.Skip)
+		}
+		inRegion(t, r.Region, func(t *testing.T) { test(t, r.Region) })
+	}
+	runs := c.Expand(matrix)
+	if len(runs) == 1 {
+		run(t, runs[0])
+		return
+	}
+	for _, r := range runs {
+		t.Run(r.Region, func(t *testing.T) { run(t, r) })
+	}
+}
+
+// inEachRegion runs a program test in each region of the region matrix, with its options moved to the region, unless
+// the test picked its region with ForEachRegion already.
+func inEachRegion(t *testing.T, opts integration.ProgramTestOptions,
+	test func(t *testing.T, opts integration.ProgramTestOptions),
+) {
+	if _, ok := testRegion(t); ok {
+		test(t, opts)
+		return
+	}
+	ForEachRegion(t, regions.Constraint{}, func(t *testing.T, region string) {
+		test(t, opts.With(integration.ProgramTestOptions{
+			Config: map[string]string{
+				"aws:region":        region,
+				"aws-native:region": region,
+			},
+			Env: []string{"AWS_REGION=" + region},
+		}))
+	})
+}
+
+// InEachPartition runs test in a subtest for each partition besides aws that partitions.EnvVar selects, in the region
+// of the partition. BaseOptions uses that region, for which the test needs neither AWS_REGION nor credentials, as it
+// only calls the fake of internal/fakeaws.
+func InEachPartition(t *testing.T, test func(t *testing.T, p partitions.Partition)) {
+	selected, err := partitions.Selected(os.Getenv)
+	require.NoError(t, err)
+	if len(selected) == 0 {
+		t.Skipf("Skipping partition check, set %s to e.g. aws-cn,aws-us-gov", partitions.EnvVar)
+	}
+	for _, p := range selected {
+		t.Run(p.ID, func(t *testing.T) {
+			inRegion(t, p.Region, func(t *testing.T) { test(t, p) })
+		})
+	}
+}
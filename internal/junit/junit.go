@@ -0,0 +1,129 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package junit writes JUnit XML reports in the dialect CI systems understand: one testsuite per Go package and
+// one testcase per test.
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// TestSuites is the root element.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     float64     `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite holds the tests of one Go package.
+type TestSuite struct {
+	Name       string      `xml:"name,attr"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Skipped    int         `xml:"skipped,attr"`
+	Time       float64     `xml:"time,attr"`
+	Timestamp  string      `xml:"timestamp,attr,omitempty"`
+	Properties *Properties `xml:"properties,omitempty"`
+	Cases      []TestCase  `xml:"testcase"`
+}
+
+// Properties are name/value pairs attached to a suite or test case.
+type Properties struct {
+	Items []Property `xml:"property"`
+}
+
+// Property is a name/value pair.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// TestCase is a single test. At most one of Failure and Skipped is set.
+type TestCase struct {
+	Name       string      `xml:"name,attr"`
+	Classname  string      `xml:"classname,attr"`
+	Time       float64     `xml:"time,attr"`
+	Properties *Properties `xml:"properties,omitempty"`
+	Failure    *Failure    `xml:"failure,omitempty"`
+	Skipped    *Skipped    `xml:"skipped,omitempty"`
+	SystemOut  string      `xml:"system-out,omitempty"`
+}
+
+// Failure describes why a test failed. Type is the failure category.
+type Failure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// Skipped marks a skipped test.
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// AddProperty attaches a property to the test case.
+func (c *TestCase) AddProperty(name, value string) {
+	if c.Properties == nil {
+		c.Properties = &Properties{}
+	}
+	c.Properties.Items = append(c.Properties.Items, Property{Name: name, Value: value})
+}
+
+// AddProperty attaches a property to the suite.
+func (s *TestSuite) AddProperty(name, value string) {
+	if s.Properties == nil {
+		s.Properties = &Properties{}
+	}
+	s.Properties.Items = append(s.Properties.Items, Property{Name: name, Value: value})
+}
+
+// Add appends a test case to the suite and updates the suite's counters.
+func (s *TestSuite) Add(c TestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	s.Time += c.Time
+	switch {
+	case c.Failure != nil:
+		s.Failures++
+	case c.Skipped != nil:
+		s.Skipped++
+	}
+}
+
+// Write writes the suites with totals computed from them.
+func Write(w io.Writer, name string, suites []TestSuite) error {
+	doc := TestSuites{Name: name, Suites: suites}
+	for _, s := range suites {
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Skipped += s.Skipped
+		doc.Time += s.Time
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
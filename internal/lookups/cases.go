@@ -0,0 +1,180 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lookups
+
+import "github.com/pulumi/pulumi-cdk/internal/fakeaws"
+
+// Case is the lookup of one context provider.
+type Case struct {
+	// Provider is the context provider, which prefixes the keys of its lookups, e.g. "ami".
+	Provider string
+	// Query is the `query` config of the program, which it passes to the lookup.
+	Query string
+	// Calls are the AWS operations the lookup makes, as fakeaws records them.
+	Calls []string
+	// Providers are the providers of all context the lookup stores, including the lookups it leads to.
+	Providers []string
+	// Passes is the number of synth passes of the first preview with lookups on: one per round of lookups and one
+	// with all context present.
+	Passes int
+	// Value is what the lookup stores for Provider, see Contains.
+	Value any
+	// Output is the `value` output of the program.
+	Output string
+}
+
+const (
+	vpcID           = "vpc-0c0f0c0f0c0f0c0f0"
+	securityGroup   = "sg-0c0f0c0f0c0f0c0f0"
+	keyID           = "1234abcd-12ab-34cd-56ef-1234567890ab"
+	loadBalancer    = "arn:aws:elasticloadbalancing:us-east-2:123456789012:loadbalancer/app/conformance/50dc6c495c0c9188"
+	loadBalancerDNS = "conformance-1234567890.us-east-2.elb.amazonaws.com"
+)
+
+// Fixtures are what the cases look up.
+var Fixtures = fakeaws.Fixtures{
+	HostedZones: []fakeaws.HostedZone{
+		{ID: "ZCONFORMANCE01", Name: "conformance.example.com"},
+		{ID: "ZCONFORMANCE02", Name: "internal.conformance.example.com", Private: true},
+	},
+	Images: []fakeaws.Image{
+		{ID: "ami-0c0f0c0f0c0f0c001", Name: "conformance-2024.01-x86_64", OwnerID: "137112412989",
+			OwnerAlias: "amazon", CreationDate: "2024-01-31T00:00:00.000Z"},
+		{ID: "ami-0c0f0c0f0c0f0c002", Name: "conformance-2024.06-x86_64", OwnerID: "137112412989",
+			OwnerAlias: "amazon", CreationDate: "2024-06-30T00:00:00.000Z"},
+		{ID: "ami-0c0f0c0f0c0f0c003", Name: "conformance-2024.06-arm64", OwnerID: "137112412989",
+			OwnerAlias: "amazon", Architecture: "arm64", CreationDate: "2024-07-31T00:00:00.000Z"},
+	},
+	AvailabilityZones: []fakeaws.AvailabilityZone{
+		{Name: "us-conformance-1a", ID: "usc1-az1", Region: "us-conformance-1"},
+		{Name: "us-conformance-1b", ID: "usc1-az2", Region: "us-conformance-1"},
+		{Name: "us-conformance-1c", ID: "usc1-az3", Region: "us-conformance-1", State: "impaired"},
+	},
+	Vpcs: []fakeaws.Vpc{{ID: vpcID, CidrBlock: "10.0.0.0/16"}},
+	Subnets: []fakeaws.Subnet{
+		{ID: "subnet-0c0f0c0f0c0f0c001", VpcID: vpcID, AvailabilityZone: "us-conformance-1a",
+			CidrBlock: "10.0.0.0/24", MapPublicIPOnLaunch: true},
+		{ID: "subnet-0c0f0c0f0c0f0c002", VpcID: vpcID, AvailabilityZone: "us-conformance-1b",
+			CidrBlock: "10.0.1.0/24", MapPublicIPOnLaunch: true},
+	},
+	RouteTables: []fakeaws.RouteTable{{
+		ID: "rtb-0c0f0c0f0c0f0c0f0", VpcID: vpcID, Main: true,
+		Routes: []fakeaws.Route{
+			{DestinationCidrBlock: "10.0.0.0/16", GatewayID: "local"},
+			{DestinationCidrBlock: "0.0.0.0/0", GatewayID: "igw-0c0f0c0f0c0f0c0f0"},
+		},
+	}},
+	SecurityGroups: []fakeaws.SecurityGroup{
+		{ID: securityGroup, Name: "conformance", VpcID: vpcID, Egress: []string{"0.0.0.0/0"}},
+	},
+	KeyAliases: []fakeaws.KeyAlias{
+		{Name: "alias/aws/s3", TargetKeyID: "0c0f0c0f-0c0f-0c0f-0c0f-0c0f0c0f0c0f"},
+		{Name: "alias/conformance", TargetKeyID: keyID},
+	},
+	Parameters: []fakeaws.Parameter{{Name: "/conformance/greeting", Value: "hello from ssm"}},
+	LoadBalancers: []fakeaws.LoadBalancer{{
+		Arn:                   loadBalancer,
+		Name:                  "conformance",
+		DNSName:               loadBalancerDNS,
+		CanonicalHostedZoneID: "Z3AADJGX6KTTL2",
+		VpcID:                 vpcID,
+		SecurityGroups:        []string{securityGroup},
+	}},
+}
+
+// Cases are the lookups of every context provider pulumi-cdk supports.
+var Cases = []Case{
+	{
+		Provider:  "ami",
+		Query:     "conformance-*-x86_64",
+		Calls:     []string{"ec2:DescribeImages"},
+		Providers: []string{"ami"},
+		Passes:    2,
+		// The newest image that matches.
+		Value:  "ami-0c0f0c0f0c0f0c002",
+		Output: "ami-0c0f0c0f0c0f0c002",
+	},
+	{
+		Provider:  "availability-zones",
+		Calls:     []string{"ec2:DescribeAvailabilityZones"},
+		Providers: []string{"availability-zones"},
+		Passes:    2,
+		// Only the zones that are available.
+		Value:  []string{"us-conformance-1a", "us-conformance-1b"},
+		Output: "us-conformance-1a,us-conformance-1b",
+	},
+	{
+		Provider:  "hosted-zone",
+		Query:     "conformance.example.com",
+		Calls:     []string{"route53:ListHostedZonesByName"},
+		Providers: []string{"hosted-zone"},
+		Passes:    2,
+		Value:     map[string]any{"Id": "/hostedzone/ZCONFORMANCE01", "Name": "conformance.example.com."},
+		Output:    "ZCONFORMANCE01",
+	},
+	{
+		Provider:  "key-provider",
+		Query:     "alias/conformance",
+		Calls:     []string{"kms:ListAliases"},
+		Providers: []string{"key-provider"},
+		Passes:    2,
+		Value:     map[string]any{"keyId": keyID},
+		Output:    keyID,
+	},
+	{
+		Provider:  "ssm",
+		Query:     "/conformance/greeting",
+		Calls:     []string{"ssm:GetParameter"},
+		Providers: []string{"ssm"},
+		Passes:    2,
+		Value:     "hello from ssm",
+		Output:    "hello from ssm",
+	},
+	{
+		Provider:  "security-group",
+		Query:     securityGroup,
+		Calls:     []string{"ec2:DescribeSecurityGroups"},
+		Providers: []string{"security-group"},
+		Passes:    2,
+		Value:     map[string]any{"securityGroupId": securityGroup, "allowAllOutbound": true},
+		Output:    securityGroup,
+	},
+	{
+		Provider: "vpc-provider",
+		Query:    vpcID,
+		Calls: []string{
+			"ec2:DescribeVpcs", "ec2:DescribeSubnets", "ec2:DescribeRouteTables", "ec2:DescribeVpnGateways",
+		},
+		Providers: []string{"vpc-provider"},
+		Passes:    2,
+		Value:     map[string]any{"vpcId": vpcID, "vpcCidrBlock": "10.0.0.0/16"},
+		Output:    vpcID,
+	},
+	{
+		Provider: "load-balancer",
+		Query:    loadBalancer,
+		Calls:    []string{"elasticloadbalancing:DescribeLoadBalancers", "ec2:DescribeVpcs"},
+		// A looked up load balancer looks up its VPC, which needs a round of lookups of its own.
+		Providers: []string{"load-balancer", "vpc-provider"},
+		Passes:    3,
+		Value: map[string]any{
+			"loadBalancerArn":     loadBalancer,
+			"loadBalancerDnsName": loadBalancerDNS,
+			"vpcId":               vpcID,
+			"securityGroupIds":    []string{securityGroup},
+		},
+		Output: loadBalancerDNS,
+	},
+}
@@ -0,0 +1,167 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lookups is the conformance suite of the CDK context lookups that PULUMI_CDK_EXPERIMENTAL_LOOKUPS enables,
+// see adr/cdk-cli-lib.md. Each case drives one context provider of examples/lookups-conformance against the fakes of
+// internal/fakeaws seeded with Fixtures, and says what the lookup leaves in cdk.context.json, how many synth passes it
+// takes and what the program outputs. The helpers here read back what a run left behind.
+package lookups
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// EnvVar is the environment variable that turns lookups on in the program.
+const EnvVar = "PULUMI_CDK_EXPERIMENTAL_LOOKUPS"
+
+// ContextFile is where the toolkit keeps the looked up context, in the root directory of the program.
+const ContextFile = "cdk.context.json"
+
+// SynthLogEnvVar is the file the program appends a line to on every synth pass, i.e. every call of its App callback.
+const SynthLogEnvVar = "PULUMI_CDK_TEST_SYNTH_LOG"
+
+// ReadContext reads the context file of the program in dir. A missing file is empty context.
+func ReadContext(dir string) (map[string]any, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ContextFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var context map[string]any
+	if err := json.Unmarshal(data, &context); err != nil {
+		return nil, fmt.Errorf("%s: %w", ContextFile, err)
+	}
+	return context, nil
+}
+
+// Provider returns the context provider of a context key, e.g. "ami" for
+// ami:account=123456789012:filters.image-type.0=machine:region=us-east-2. Keys that no lookup stored, such as
+// feature flags like @aws-cdk/core:newStyleStackSynthesis, have none.
+func Provider(key string) string {
+	provider, _, ok := strings.Cut(key, ":")
+	if !ok || !providerName.MatchString(provider) {
+		return ""
+	}
+	return provider
+}
+
+var providerName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Providers returns the sorted providers of the looked up context.
+func Providers(context map[string]any) []string {
+	seen := map[string]bool{}
+	for key := range context {
+		if p := Provider(key); p != "" {
+			seen[p] = true
+		}
+	}
+	providers := make([]string, 0, len(seen))
+	for p := range seen {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// Lookup returns the key and value of the only context of a provider.
+func Lookup(context map[string]any, provider string) (string, any, error) {
+	var keys []string
+	for key := range context {
+		if Provider(key) == provider {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 1 {
+		sort.Strings(keys)
+		return "", nil, fmt.Errorf("want one %s lookup in %s, got %q", provider, ContextFile, keys)
+	}
+	return keys[0], context[keys[0]], nil
+}
+
+// SynthPasses counts the synth passes logged to the file SynthLogEnvVar points to. A missing file is no passes.
+func SynthPasses(path string) (int, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	passes := 0
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		passes++
+	}
+	return passes, s.Err()
+}
+
+// Contains reports whether a looked up value has everything the case expects: objects may have more properties than
+// expected, everything else must be equal. Values are compared as JSON.
+func Contains(got, want any) bool {
+	return contains(normalize(got), normalize(want))
+}
+
+func contains(got, want any) bool {
+	switch want := want.(type) {
+	case map[string]any:
+		got, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range want {
+			if g, ok := got[k]; !ok || !contains(g, v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		got, ok := got.([]any)
+		if !ok || len(got) != len(want) {
+			return false
+		}
+		for i := range want {
+			if !contains(got[i], want[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// normalize turns a value into what decoding its JSON gives.
+func normalize(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n any
+	if err := json.Unmarshal(data, &n); err != nil {
+		return v
+	}
+	return n
+}
@@ -0,0 +1,94 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lookups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadContext(t *testing.T) {
+	dir := t.TempDir()
+	context, err := ReadContext(dir)
+	require.NoError(t, err)
+	assert.Empty(t, context)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ContextFile), []byte(`{
+  "ami:account=123456789012:filters.name.0=al2023-*:region=us-east-2": "ami-1",
+  "vpc-provider:account=123456789012:filter.vpc-id=vpc-1:region=us-east-2": {"vpcId": "vpc-1"},
+  "vpc-provider:account=123456789012:filter.vpc-id=vpc-2:region=us-east-2": {"vpcId": "vpc-2"},
+  "@aws-cdk/core:newStyleStackSynthesis": true
+}`), 0o600))
+	context, err = ReadContext(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ami", "vpc-provider"}, Providers(context))
+
+	key, value, err := Lookup(context, "ami")
+	require.NoError(t, err)
+	assert.Equal(t, "ami:account=123456789012:filters.name.0=al2023-*:region=us-east-2", key)
+	assert.Equal(t, "ami-1", value)
+	_, _, err = Lookup(context, "vpc-provider")
+	assert.ErrorContains(t, err, "want one vpc-provider lookup")
+	_, _, err = Lookup(context, "ssm")
+	assert.ErrorContains(t, err, "want one ssm lookup")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ContextFile), []byte(`{`), 0o600))
+	_, err = ReadContext(dir)
+	assert.ErrorContains(t, err, ContextFile)
+}
+
+func TestSynthPasses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synth.log")
+	passes, err := SynthPasses(path)
+	require.NoError(t, err)
+	assert.Zero(t, passes)
+
+	require.NoError(t, os.WriteFile(path, []byte("synth\nsynth\n"), 0o600))
+	passes, err = SynthPasses(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, passes)
+}
+
+func TestContains(t *testing.T) {
+	got := map[string]any{
+		"vpcId":            "vpc-1",
+		"securityGroupIds": []any{"sg-1"},
+		"allowAllOutbound": true,
+	}
+	assert.True(t, Contains(got, map[string]any{"vpcId": "vpc-1"}))
+	assert.True(t, Contains(got, map[string]any{"securityGroupIds": []string{"sg-1"}, "allowAllOutbound": true}))
+	assert.False(t, Contains(got, map[string]any{"vpcId": "vpc-2"}))
+	assert.False(t, Contains(got, map[string]any{"subnetGroups": []any{}}))
+	assert.False(t, Contains(got, map[string]any{"securityGroupIds": []string{"sg-1", "sg-2"}}))
+	assert.True(t, Contains([]any{"a", "b"}, []string{"a", "b"}))
+	assert.False(t, Contains([]any{"a", "b"}, []string{"b", "a"}))
+	assert.False(t, Contains("ami-1", map[string]any{}))
+}
+
+func TestCases(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range Cases {
+		assert.False(t, seen[c.Provider], "duplicate case %s", c.Provider)
+		seen[c.Provider] = true
+		assert.Contains(t, c.Providers, c.Provider, "case %s", c.Provider)
+		assert.NotEmpty(t, c.Calls, "case %s", c.Provider)
+		assert.GreaterOrEqual(t, c.Passes, 2, "case %s: a lookup takes a second pass", c.Provider)
+		assert.NotEmpty(t, c.Output, "case %s", c.Provider)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package naming is a Go port of src/naming.ts and src/cdk-logical-id.ts. Both sides are checked against the shared
+// corpus in tests/test-data/conformance so that they cannot drift.
+package naming
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const packageName = "aws-native"
+
+// TypeToken converts a CloudFormation type such as `AWS::EC2::VPC` to its aws-native token `aws-native:ec2:Vpc`.
+func TypeToken(typ string) (string, error) {
+	resourceName, err := TypeName(typ)
+	if err != nil {
+		return "", err
+	}
+	mName, err := ModuleName(typ)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s:%s", packageName, strings.ToLower(mName), resourceName), nil
+}
+
+// ModuleName returns the module part of a CloudFormation type.
+func ModuleName(resourceType string) (string, error) {
+	components := strings.Split(resourceType, "::")
+	if len(components) != 3 {
+		return "", fmt.Errorf("expected three parts in type %s", strings.Join(components, ","))
+	}
+	mName := components[1]
+
+	// Override the name of the Config module.
+	if mName == "Config" {
+		mName = "Configuration"
+	}
+
+	return LowerAcronyms(mName), nil
+}
+
+// TypeName returns the resource name part of a CloudFormation type.
+func TypeName(typ string) (string, error) {
+	components := strings.Split(typ, "::")
+	if len(components) != 3 {
+		return "", fmt.Errorf("expected three parts in type %s", strings.Join(components, ","))
+	}
+	name := components[2]
+	// Override name to avoid duplicate types due to "Output" suffix
+	// See https://github.com/pulumi/pulumi/issues/8018
+	if strings.HasSuffix(name, "Output") {
+		// Skip renaming existing FlowOutput type.
+		if typ == "AWS::MediaConnect::FlowOutput" {
+			return name, nil
+		}
+		name = strings.TrimSuffix(name, "Output") + "OutputResource"
+	}
+	return LowerAcronyms(name), nil
+}
+
+// ToSdkName converts a CloudFormation property or attribute name to the lowerCamelCase convention that is used in
+// Pulumi schema's properties.
+func ToSdkName(s string) string {
+	if s == "" {
+		return s
+	}
+	s = LowerAcronyms(s)
+	if s[0] >= 'A' && s[0] <= 'Z' {
+		s = strings.ToLower(s[:1]) + s[1:]
+	}
+	return s
+}
+
+// LowerAcronyms lowers the trailing characters of any uppercase acronyms.
+func LowerAcronyms(s string) string {
+	startIndex, endIndex := firstUppercaseAcronym(s)
+	if startIndex == -1 {
+		return s
+	}
+
+	startIndex++ // don't lower the first char of the run
+
+	return s[:startIndex] + strings.ToLower(s[startIndex:endIndex]) + LowerAcronyms(s[endIndex:])
+}
+
+// firstUppercaseAcronym returns the indices of the first uppercase acronym in the string.
+func firstUppercaseAcronym(s string) (int, int) {
+	startIndex, endIndex := findFirstRunOfUppercase(s, 2)
+	if startIndex == -1 {
+		return startIndex, endIndex
+	}
+
+	// Treat the last uppercase char in a run as part of the next word UNLESS:
+	// - we're at the end of the string
+	// - the acronym is followed by a single lowercase 's' (eg. as in "ARNs")
+	if !(endIndex == len(s) || startsWithIsolatedLowercaseS(s[endIndex:])) {
+		endIndex--
+	}
+
+	return startIndex, endIndex
+}
+
+func startsWithIsolatedLowercaseS(s string) bool {
+	switch len(s) {
+	case 0:
+		return false
+	case 1:
+		return s[0] == 's'
+	default:
+		return s[0] == 's' && isUpperAcronymChar(s[1])
+	}
+}
+
+// findFirstRunOfUppercase returns the indices of the first run of at least minLength uppercase characters.
+func findFirstRunOfUppercase(s string, minLength int) (int, int) {
+	startIndex := -1
+	for i := 0; i < len(s); i++ {
+		if startIndex == -1 {
+			if isUpperAcronymChar(s[i]) {
+				startIndex = i
+			}
+		} else if !isUpperAcronymChar(s[i]) {
+			if i-startIndex >= minLength {
+				return startIndex, i
+			}
+			startIndex = -1
+		}
+	}
+
+	if startIndex == -1 {
+		return -1, -1
+	}
+
+	return startIndex, len(s)
+}
+
+func isUpperAcronymChar(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+const (
+	// hiddenFromHumanID components do not appear in the human-readable part of the logical ID.
+	hiddenFromHumanID = "Resource"
+	// hiddenID components are completely hidden from the logical ID calculation.
+	hiddenID    = "Default"
+	maxHumanLen = 240
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// MakeUniqueID calculates the logical ID for a set of construct path components, without the hash suffix that CDK
+// would add. See `makeUniqueId` in src/cdk-logical-id.ts.
+func MakeUniqueID(components []string) (string, error) {
+	var filtered []string
+	for _, c := range components {
+		if c != hiddenID {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return "", fmt.Errorf("Unable to calculate a unique id for an empty set of components")
+	}
+
+	var human strings.Builder
+	for _, c := range removeDupes(filtered) {
+		if c == hiddenFromHumanID {
+			continue
+		}
+		human.WriteString(nonAlphanumeric.ReplaceAllString(c, ""))
+	}
+	id := human.String()
+	if len(id) > maxHumanLen {
+		id = id[:maxHumanLen]
+	}
+	return id, nil
+}
+
+// removeDupes skips a path component when the previous component ends with it.
+func removeDupes(path []string) []string {
+	var ret []string
+	for _, c := range path {
+		if len(ret) == 0 || !strings.HasSuffix(ret[len(ret)-1], c) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
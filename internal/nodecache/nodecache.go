@@ -0,0 +1,334 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodecache shares installed node_modules between the acceptance tests. An install is keyed on the program's
+// package.json and yarn.lock, with local tarballs such as the packed @pulumi/cdk hashed by content, and later tests
+// with the same key get hardlinks to it instead of running `yarn install` again.
+package nodecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/throttle"
+)
+
+// DirEnvVar overrides the directory installs are cached in.
+const DirEnvVar = "PULUMI_CDK_TEST_NODE_MODULES_DIR"
+
+// OfflineEnvVar makes a cache miss an error instead of running `yarn install`.
+const OfflineEnvVar = "PULUMI_CDK_TEST_OFFLINE"
+
+// Dir returns the directory installs are cached in, in the user's cache directory by default.
+func Dir() string {
+	if dir := os.Getenv(DirEnvVar); dir != "" {
+		return dir
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		cache = os.TempDir()
+	}
+	return filepath.Join(cache, "pulumi-cdk-test", "node_modules")
+}
+
+// Offline reports whether OfflineEnvVar is set.
+func Offline() bool {
+	return os.Getenv(OfflineEnvVar) != ""
+}
+
+// ErrNotCached is returned for a cache miss in offline mode.
+var ErrNotCached = errors.New("node_modules not cached")
+
+// ApplyOverrides pins packages in the package.json in dir the way the test harness does for
+// integration.ProgramTestOptions.Overrides: direct dependencies get the version, and a yarn resolution forces it for
+// transitive ones. Existing resolutions are replaced.
+func ApplyOverrides(dir string, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	path := filepath.Join(dir, "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var pkg map[string]any
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	resolutions := map[string]any{}
+	for name, version := range overrides {
+		for _, section := range []string{"dependencies", "devDependencies"} {
+			if deps, ok := pkg[section].(map[string]any); ok {
+				if _, ok := deps[name]; ok {
+					deps[name] = version
+				}
+			}
+		}
+		resolutions["**/"+name] = version
+	}
+	pkg["resolutions"] = resolutions
+	if data, err = json.MarshalIndent(pkg, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// RemoveOverride drops the yarn resolution that ApplyOverrides, or the test harness, added for a package to the
+// package.json in dir, so that a later `yarn add` of the package installs what it names.
+func RemoveOverride(dir, name string) error {
+	path := filepath.Join(dir, "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var pkg map[string]any
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	resolutions, _ := pkg["resolutions"].(map[string]any)
+	if _, ok := resolutions["**/"+name]; !ok {
+		return nil
+	}
+	delete(resolutions, "**/"+name)
+	if len(resolutions) == 0 {
+		delete(pkg, "resolutions")
+	}
+	if data, err = json.MarshalIndent(pkg, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// InstalledVersion returns the version of a package in the node_modules of dir.
+func InstalledVersion(dir, name string) (string, error) {
+	path := filepath.Join(dir, "node_modules", filepath.FromSlash(name), "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return pkg.Version, nil
+}
+
+// Key hashes what an install in dir depends on: package.json, with local `file:` tarballs replaced by the hash of
+// their content so that the key does not depend on where they are, yarn.lock, the platform and salt, e.g. the Node.js
+// version that native modules are built for.
+func Key(dir, salt string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return "", err
+	}
+	var pkg map[string]any
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("package.json: %w", err)
+	}
+	for _, section := range []string{"dependencies", "devDependencies", "resolutions"} {
+		deps, _ := pkg[section].(map[string]any)
+		for name, v := range deps {
+			version, _ := v.(string)
+			if deps[name], err = tarballHash(dir, version); err != nil {
+				return "", fmt.Errorf("%s in package.json: %w", name, err)
+			}
+		}
+	}
+	// Maps are marshalled with sorted keys, so this is stable.
+	if data, err = json.Marshal(pkg); err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%s\x00%s\x00", runtime.GOOS, runtime.GOARCH, salt)
+	h.Write(data)
+	h.Write([]byte{0})
+	lock, err := os.ReadFile(filepath.Join(dir, "yarn.lock"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	h.Write(lock)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// tarballHash returns "sha256:<hash>" for a version that installs a local tarball, and the version otherwise.
+func tarballHash(dir, version string) (string, error) {
+	path, ok := strings.CutPrefix(version, "file:")
+	if !ok || !strings.HasSuffix(path, ".tgz") {
+		return version, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Cache is a directory of installs, one per key, each holding node_modules and the yarn.lock the install left.
+type Cache struct {
+	Dir string
+	// Offline makes a miss an error instead of installing.
+	Offline bool
+	// Salt is added to the key.
+	Salt string
+	// Copy restores installs by copying instead of hardlinking, for projects that change their node_modules in
+	// place, e.g. with `yarn add`.
+	Copy bool
+}
+
+// Install gives the project in dir the node_modules its key maps to. On a miss it runs install and caches the result.
+// Concurrent misses of the same key, also from other processes, install once. It returns the key and whether it was
+// a hit.
+func (c *Cache) Install(ctx context.Context, dir string, install func() error) (string, bool, error) {
+	key, err := Key(dir, c.Salt)
+	if err != nil {
+		return "", false, err
+	}
+	entry := filepath.Join(c.Dir, key)
+	if hit, err := c.restore(entry, dir); hit || err != nil {
+		return key, hit, err
+	}
+	if c.Offline {
+		return key, false, fmt.Errorf("%w for %s (key %s) and %s is set", ErrNotCached, dir, key[:16], OfflineEnvVar)
+	}
+
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return key, false, err
+	}
+	release, err := throttle.New(c.Dir).Acquire(ctx, "install-"+key[:16])
+	if err != nil {
+		return key, false, err
+	}
+	defer release()
+	if hit, err := c.restore(entry, dir); hit || err != nil {
+		return key, hit, err
+	}
+
+	if err := install(); err != nil {
+		return key, false, err
+	}
+	return key, false, c.store(entry, dir)
+}
+
+// restore links the install in entry into dir, if it is complete.
+func (c *Cache) restore(entry, dir string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(entry, "node_modules")); err != nil {
+		return false, nil
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "node_modules")); err != nil {
+		return false, err
+	}
+	if err := linkTree(filepath.Join(entry, "node_modules"), filepath.Join(dir, "node_modules"), c.Copy); err != nil {
+		return false, fmt.Errorf("restoring node_modules: %w", err)
+	}
+	if err := copyFile(filepath.Join(entry, "yarn.lock"), filepath.Join(dir, "yarn.lock")); err != nil &&
+		!errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	return true, nil
+}
+
+// store copies the install in dir to entry. It is written next to entry first and renamed, so that an entry is only
+// ever seen complete. Copies keep the project's files from sharing inodes with the cache.
+func (c *Cache) store(entry, dir string) error {
+	tmp, err := os.MkdirTemp(c.Dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := linkTree(filepath.Join(dir, "node_modules"), filepath.Join(tmp, "node_modules"), true); err != nil {
+		return fmt.Errorf("caching node_modules: %w", err)
+	}
+	if err := copyFile(filepath.Join(dir, "yarn.lock"), filepath.Join(tmp, "yarn.lock")); err != nil &&
+		!errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmp, entry); err != nil {
+		if _, statErr := os.Stat(entry); statErr == nil {
+			// Another process stored it first.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// linkTree recreates the tree at src in dst with hardlinks to its files, or copies where hardlinks are not possible
+// or copy is set. Symlinks are recreated as they are.
+func linkTree(src, dst string, copy bool) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !copy:
+			if err := os.Link(path, target); err == nil {
+				return nil
+			}
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies src to dst, keeping its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	return errors.Join(err, out.Close())
+}
@@ -0,0 +1,224 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodecache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+// project writes a program that depends on the tarball at tgz.
+func project(t *testing.T, tgz string) string {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"name": "app", "dependencies": {"@pulumi/cdk": "file:` + tgz + `", "@pulumi/aws": "^6.0.0"}}`,
+		"yarn.lock":    "# lock\n",
+	})
+	return dir
+}
+
+// fakeInstall writes node_modules the way yarn would and counts how often it ran.
+func fakeInstall(t *testing.T, dir string, installs *int) func() error {
+	return func() error {
+		*installs++
+		writeFiles(t, dir, map[string]string{
+			"node_modules/@pulumi/cdk/index.js": "cdk",
+			"node_modules/left-pad/index.js":    "pad",
+			"yarn.lock":                         "# lock\n# installed\n",
+		})
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", ".bin"), 0o755))
+		return os.Symlink("../left-pad/index.js", filepath.Join(dir, "node_modules", ".bin", "left-pad"))
+	}
+}
+
+func TestKey(t *testing.T) {
+	tarballs := t.TempDir()
+	writeFiles(t, tarballs, map[string]string{"a/cdk.tgz": "build 1", "b/cdk.tgz": "build 1", "c/cdk.tgz": "build 2"})
+
+	key := func(dir, salt string) string {
+		k, err := Key(dir, salt)
+		require.NoError(t, err)
+		return k
+	}
+	a := project(t, filepath.Join(tarballs, "a", "cdk.tgz"))
+	assert.Equal(t, key(a, ""), key(project(t, filepath.Join(tarballs, "b", "cdk.tgz")), ""),
+		"the same tarball content in another place has the same key")
+	assert.NotEqual(t, key(a, ""), key(project(t, filepath.Join(tarballs, "c", "cdk.tgz")), ""))
+	assert.NotEqual(t, key(a, ""), key(a, "v20.0.0"))
+
+	writeFiles(t, a, map[string]string{"yarn.lock": "# lock\n# changed\n"})
+	assert.NotEqual(t, key(project(t, filepath.Join(tarballs, "a", "cdk.tgz")), ""), key(a, ""))
+
+	_, err := Key(project(t, filepath.Join(tarballs, "missing.tgz")), "")
+	assert.Error(t, err)
+}
+
+func TestApplyOverrides(t *testing.T) {
+	dir := project(t, "/tmp/cdk.tgz")
+	require.NoError(t, ApplyOverrides(dir, map[string]string{"@pulumi/cdk": "1.10.0", "@pulumi/pulumi": "3.0.0"}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+		Resolutions  map[string]string `json:"resolutions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &pkg))
+	assert.Equal(t, map[string]string{"@pulumi/cdk": "1.10.0", "@pulumi/aws": "^6.0.0"}, pkg.Dependencies)
+	assert.Equal(t, map[string]string{"**/@pulumi/cdk": "1.10.0", "**/@pulumi/pulumi": "3.0.0"}, pkg.Resolutions)
+}
+
+func TestRemoveOverride(t *testing.T) {
+	dir := project(t, "/tmp/cdk.tgz")
+	require.NoError(t, ApplyOverrides(dir, map[string]string{"@pulumi/cdk": "1.10.0", "@pulumi/pulumi": "3.0.0"}))
+	require.NoError(t, RemoveOverride(dir, "@pulumi/cdk"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	var pkg struct {
+		Resolutions map[string]string `json:"resolutions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &pkg))
+	assert.Equal(t, map[string]string{"**/@pulumi/pulumi": "3.0.0"}, pkg.Resolutions)
+
+	require.NoError(t, RemoveOverride(dir, "@pulumi/pulumi"))
+	data, err = os.ReadFile(filepath.Join(dir, "package.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "resolutions")
+}
+
+func TestInstalledVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"node_modules/@pulumi/cdk/package.json": `{"name": "@pulumi/cdk", "version": "0.0.0-test.0123456789ab"}`,
+	})
+	version, err := InstalledVersion(dir, "@pulumi/cdk")
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0-test.0123456789ab", version)
+
+	_, err = InstalledVersion(dir, "@pulumi/aws")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestInstall(t *testing.T) {
+	tgz := filepath.Join(t.TempDir(), "cdk.tgz")
+	writeFiles(t, filepath.Dir(tgz), map[string]string{"cdk.tgz": "build"})
+	c := &Cache{Dir: t.TempDir()}
+	installs := 0
+
+	first := project(t, tgz)
+	key, hit, err := c.Install(context.Background(), first, fakeInstall(t, first, &installs))
+	require.NoError(t, err)
+	assert.False(t, hit)
+	assert.DirExists(t, filepath.Join(c.Dir, key, "node_modules"))
+
+	second := project(t, tgz)
+	key2, hit, err := c.Install(context.Background(), second, fakeInstall(t, second, &installs))
+	require.NoError(t, err)
+	assert.True(t, hit)
+	assert.Equal(t, key, key2)
+	assert.Equal(t, 1, installs)
+
+	data, err := os.ReadFile(filepath.Join(second, "node_modules", "@pulumi", "cdk", "index.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "cdk", string(data))
+	data, err = os.ReadFile(filepath.Join(second, "yarn.lock"))
+	require.NoError(t, err)
+	assert.Equal(t, "# lock\n# installed\n", string(data), "the lockfile the install left is restored")
+	link, err := os.Readlink(filepath.Join(second, "node_modules", ".bin", "left-pad"))
+	require.NoError(t, err)
+	assert.Equal(t, "../left-pad/index.js", link)
+
+	cached, err := os.Stat(filepath.Join(c.Dir, key, "node_modules", "left-pad", "index.js"))
+	require.NoError(t, err)
+	linked, err := os.Stat(filepath.Join(second, "node_modules", "left-pad", "index.js"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(cached, linked), "hits are hardlinked")
+	installed, err := os.Stat(filepath.Join(first, "node_modules", "left-pad", "index.js"))
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(cached, installed), "the cache does not share files with the installing project")
+
+	c.Copy = true
+	third := project(t, tgz)
+	_, hit, err = c.Install(context.Background(), third, fakeInstall(t, third, &installs))
+	require.NoError(t, err)
+	assert.True(t, hit)
+	copied, err := os.Stat(filepath.Join(third, "node_modules", "left-pad", "index.js"))
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(cached, copied))
+}
+
+func TestInstallOffline(t *testing.T) {
+	tgz := filepath.Join(t.TempDir(), "cdk.tgz")
+	writeFiles(t, filepath.Dir(tgz), map[string]string{"cdk.tgz": "build"})
+	seeded := &Cache{Dir: t.TempDir()}
+	installs := 0
+	dir := project(t, tgz)
+	_, _, err := seeded.Install(context.Background(), dir, fakeInstall(t, dir, &installs))
+	require.NoError(t, err)
+
+	offline := &Cache{Dir: seeded.Dir, Offline: true}
+	dir = project(t, tgz)
+	_, hit, err := offline.Install(context.Background(), dir, fakeInstall(t, dir, &installs))
+	require.NoError(t, err)
+	assert.True(t, hit, "a seeded cache works offline")
+
+	offline.Salt = "other"
+	_, _, err = offline.Install(context.Background(), dir, fakeInstall(t, dir, &installs))
+	assert.ErrorIs(t, err, ErrNotCached)
+	assert.Equal(t, 1, installs)
+}
+
+func TestInstallConcurrently(t *testing.T) {
+	tgz := filepath.Join(t.TempDir(), "cdk.tgz")
+	writeFiles(t, filepath.Dir(tgz), map[string]string{"cdk.tgz": "build"})
+	c := &Cache{Dir: t.TempDir()}
+
+	var mu sync.Mutex
+	installs := 0
+	var wg sync.WaitGroup
+	for range 4 {
+		dir := project(t, tgz)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := c.Install(context.Background(), dir, func() error {
+				mu.Lock()
+				installs++
+				mu.Unlock()
+				return os.MkdirAll(filepath.Join(dir, "node_modules", "left-pad"), 0o755)
+			})
+			assert.NoError(t, err)
+			assert.DirExists(t, filepath.Join(dir, "node_modules", "left-pad"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, installs)
+}
@@ -0,0 +1,304 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pack builds the @pulumi/cdk of the working tree and packs it with `npm pack`, so that the acceptance tests
+// install one exact build instead of whatever `yarn link` points to on the machine. Tarballs are cached by a hash of
+// the sources they were built from, and a build is shared by the `go test` processes of a run.
+package pack
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/throttle"
+)
+
+// DirEnvVar overrides the directory tarballs are cached in.
+const DirEnvVar = "PULUMI_CDK_TEST_PACK_DIR"
+
+// Dir returns the directory tarballs are cached in, in the user's cache directory by default.
+func Dir() string {
+	if dir := os.Getenv(DirEnvVar); dir != "" {
+		return dir
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		cache = os.TempDir()
+	}
+	return filepath.Join(cache, "pulumi-cdk-test", "packs")
+}
+
+// versionPlaceholder is the version in package.json until CI sets the release version.
+const versionPlaceholder = "${VERSION}"
+
+// Build is a packed build of @pulumi/cdk.
+type Build struct {
+	// Tarball is the path of the packed package.
+	Tarball string `json:"tarball"`
+	// Hash is the hash of the sources the package was built from.
+	Hash    string `json:"hash"`
+	Version string `json:"version"`
+}
+
+func (b Build) String() string {
+	return fmt.Sprintf("@pulumi/cdk@%s (sources %s)", b.Version, b.Hash[:12])
+}
+
+// Dependency is the version constraint in package.json that installs the build.
+func (b Build) Dependency() string {
+	return "file:" + b.Tarball
+}
+
+// inputs are the files outside of src/ and the data directories that the package depends on.
+var inputs = []string{"package.json", "yarn.lock", "tsconfig.json", ".npmignore", "README.md", "LICENSE"}
+
+// dataDirs are packed as they are, next to lib/. The compiled code reads them relative to itself, e.g.
+// lib/pulumi-metadata.js reads schemas/aws-native-metadata.json.
+var dataDirs = []string{"schemas"}
+
+// SourceHash hashes the sources of the package in root: src/, the data directories and the files that control how it
+// is built and packed.
+func SourceHash(root string) (string, error) {
+	files := append([]string{}, inputs...)
+	for _, dir := range append([]string{"src"}, dataDirs...) {
+		err := walkFiles(root, dir, func(rel string) error {
+			files = append(files, rel)
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(root, file))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00", file)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Packer builds and packs the package.
+type Packer struct {
+	// Root is the root of the repository.
+	Root string
+	// Dir is where tarballs are cached.
+	Dir string
+	// Command runs a command in a directory. It defaults to running it with os/exec.
+	Command func(ctx context.Context, dir, name string, args ...string) error
+}
+
+func (p *Packer) run(ctx context.Context, dir, name string, args ...string) error {
+	if p.Command != nil {
+		return p.Command(ctx, dir, name, args...)
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w\n%s", name, strings.Join(args, " "), err, out)
+	}
+	return nil
+}
+
+// Pack returns the build of the current sources, building and packing it unless it is cached. Concurrent calls,
+// also from other processes, build only once.
+func (p *Packer) Pack(ctx context.Context) (Build, error) {
+	hash, err := SourceHash(p.Root)
+	if err != nil {
+		return Build{}, err
+	}
+	name := "pulumi-cdk-" + hash[:16]
+	tarball := filepath.Join(p.Dir, name+".tgz")
+	meta := filepath.Join(p.Dir, name+".json")
+	if b, ok := cached(meta, tarball); ok {
+		return b, nil
+	}
+
+	if err := os.MkdirAll(p.Dir, 0o755); err != nil {
+		return Build{}, err
+	}
+	locks := throttle.New(p.Dir)
+	release, err := locks.Acquire(ctx, "pack")
+	if err != nil {
+		return Build{}, err
+	}
+	defer release()
+	if b, ok := cached(meta, tarball); ok {
+		return b, nil
+	}
+
+	if err := p.run(ctx, p.Root, "yarn", "run", "build"); err != nil {
+		return Build{}, err
+	}
+	stage, err := os.MkdirTemp(p.Dir, "stage-")
+	if err != nil {
+		return Build{}, err
+	}
+	defer os.RemoveAll(stage)
+	version, err := p.stage(filepath.Join(stage, "package"), hash)
+	if err != nil {
+		return Build{}, err
+	}
+	out := filepath.Join(stage, "out")
+	if err := os.Mkdir(out, 0o755); err != nil {
+		return Build{}, err
+	}
+	if err := p.run(ctx, filepath.Join(stage, "package"), "npm", "pack", "--pack-destination", out); err != nil {
+		return Build{}, err
+	}
+	packed, err := filepath.Glob(filepath.Join(out, "*.tgz"))
+	if err != nil {
+		return Build{}, err
+	}
+	if len(packed) != 1 {
+		return Build{}, fmt.Errorf("npm pack wrote %d tarballs", len(packed))
+	}
+	if err := os.Rename(packed[0], tarball); err != nil {
+		return Build{}, err
+	}
+	b := Build{Tarball: tarball, Hash: hash, Version: version}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return Build{}, err
+	}
+	return b, os.WriteFile(meta, append(data, '\n'), 0o644)
+}
+
+func cached(meta, tarball string) (Build, bool) {
+	data, err := os.ReadFile(meta)
+	if err != nil {
+		return Build{}, false
+	}
+	var b Build
+	if json.Unmarshal(data, &b) != nil || b.Tarball != tarball {
+		return Build{}, false
+	}
+	if _, err := os.Stat(tarball); err != nil {
+		return Build{}, false
+	}
+	return b, true
+}
+
+// stage copies what `npm pack` packs into dir, and gives a package.json with the version placeholder a prerelease
+// version derived from the hash. It returns the version.
+func (p *Packer) stage(dir, hash string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.Root, "package.json"))
+	if err != nil {
+		return "", err
+	}
+	var pkg map[string]any
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("package.json: %w", err)
+	}
+	version, _ := pkg["version"].(string)
+	if version == "" || version == versionPlaceholder {
+		version = "0.0.0-test." + hash[:12]
+		pkg["version"] = version
+	}
+	if data, err = json.MarshalIndent(pkg, "", "  "); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), data, 0o644); err != nil {
+		return "", err
+	}
+
+	for _, file := range []string{".npmignore", "README.md", "LICENSE"} {
+		if err := copyFile(filepath.Join(p.Root, file), filepath.Join(dir, file)); err != nil &&
+			!errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	err = walkFiles(p.Root, "lib", func(rel string) error {
+		if !strings.HasSuffix(rel, ".js") && !strings.HasSuffix(rel, ".d.ts") {
+			return nil
+		}
+		return copyFile(filepath.Join(p.Root, rel), filepath.Join(dir, rel))
+	})
+	if err != nil {
+		return "", fmt.Errorf("copying lib/: %w", err)
+	}
+	for _, data := range dataDirs {
+		err := walkFiles(p.Root, data, func(rel string) error {
+			return copyFile(filepath.Join(p.Root, rel), filepath.Join(dir, rel))
+		})
+		if err != nil {
+			return "", fmt.Errorf("copying %s/: %w", data, err)
+		}
+	}
+	return version, nil
+}
+
+// walkFiles calls fn with the slash-separated path relative to root of every file under root/dir. A missing dir has no
+// files.
+func walkFiles(root, dir string, fn func(rel string) error) error {
+	err := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel))
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	return errors.Join(err, out.Close())
+}
@@ -0,0 +1,176 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+// fakePacker builds by writing lib/index.js and packs by recording the staged files.
+func fakePacker(t *testing.T) (*Packer, *[]string, *[]string) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json": `{"name": "@pulumi/cdk", "version": "${VERSION}"}`,
+		"yarn.lock":    "",
+		"src/index.ts": "export {};",
+		"src/stack.ts": "export {};",
+		"README.md":    "readme",
+
+		"schemas/aws-native-metadata.json": "{}",
+	})
+	var commands, staged []string
+	p := &Packer{Root: root, Dir: t.TempDir(), Command: func(ctx context.Context, dir, name string, args ...string) error {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		switch name {
+		case "yarn":
+			writeFiles(t, dir, map[string]string{"lib/index.js": "", "lib/index.d.ts": "", "lib/index.js.map": ""})
+		case "npm":
+			require.NoError(t, filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					rel, _ := filepath.Rel(dir, path)
+					staged = append(staged, filepath.ToSlash(rel))
+				}
+				return err
+			}))
+			writeFiles(t, args[len(args)-1], map[string]string{"pulumi-cdk-0.0.0.tgz": "tarball"})
+		}
+		return nil
+	}}
+	return p, &commands, &staged
+}
+
+func TestPack(t *testing.T) {
+	p, commands, staged := fakePacker(t)
+	b, err := p.Pack(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, *commands, 2)
+	assert.Equal(t, "yarn run build", (*commands)[0])
+	assert.True(t, strings.HasPrefix((*commands)[1], "npm pack --pack-destination "), (*commands)[1])
+	assert.ElementsMatch(t, []string{
+		"package.json", "README.md", "lib/index.js", "lib/index.d.ts", "schemas/aws-native-metadata.json",
+	}, *staged)
+	assert.Equal(t, "0.0.0-test."+b.Hash[:12], b.Version)
+	assert.Equal(t, filepath.Join(p.Dir, "pulumi-cdk-"+b.Hash[:16]+".tgz"), b.Tarball)
+	assert.Equal(t, "file:"+b.Tarball, b.Dependency())
+	assert.FileExists(t, b.Tarball)
+
+	var meta Build
+	data, err := os.ReadFile(strings.TrimSuffix(b.Tarball, ".tgz") + ".json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &meta))
+	assert.Equal(t, b, meta)
+
+	// The same sources are not built again.
+	again, err := p.Pack(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, b, again)
+	assert.Len(t, *commands, 2)
+
+	// Changed sources are.
+	writeFiles(t, p.Root, map[string]string{"src/stack.ts": "export const x = 1;"})
+	changed, err := p.Pack(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, b.Hash, changed.Hash)
+	assert.Len(t, *commands, 4)
+}
+
+func TestSourceHash(t *testing.T) {
+	p, _, _ := fakePacker(t)
+	hash, err := SourceHash(p.Root)
+	require.NoError(t, err)
+
+	// Build output and dependencies do not count.
+	writeFiles(t, p.Root, map[string]string{"lib/index.js": "built", "node_modules/x/index.js": ""})
+	same, err := SourceHash(p.Root)
+	require.NoError(t, err)
+	assert.Equal(t, hash, same)
+
+	// Moving content between files does.
+	writeFiles(t, p.Root, map[string]string{"src/index.ts": "", "src/stack.ts": "export {};export {};"})
+	moved, err := SourceHash(p.Root)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, moved)
+
+	// So does the metadata the package ships.
+	writeFiles(t, p.Root, map[string]string{"schemas/aws-native-metadata.json": `{"resources": {}}`})
+	schemas, err := SourceHash(p.Root)
+	require.NoError(t, err)
+	assert.NotEqual(t, moved, schemas)
+}
+
+func TestTarball(t *testing.T) {
+	if _, err := exec.LookPath("npm"); err != nil {
+		t.Skip("npm is not installed")
+	}
+	p, _, _ := fakePacker(t)
+	fake := p.Command
+	p.Command = func(ctx context.Context, dir, name string, args ...string) error {
+		if name != "npm" {
+			return fake(ctx, dir, name, args...)
+		}
+		// A Packer without Command runs npm for real.
+		return (&Packer{}).run(ctx, dir, name, args...)
+	}
+	b, err := p.Pack(context.Background())
+	require.NoError(t, err)
+
+	f, err := os.Open(b.Tarball)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	var files []string
+	r := tar.NewReader(gz)
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		files = append(files, h.Name)
+	}
+	// lib/pulumi-metadata.js requires ../schemas/aws-native-metadata.json.
+	assert.Contains(t, files, "package/schemas/aws-native-metadata.json")
+	assert.Contains(t, files, "package/lib/index.js")
+}
+
+func TestReleaseVersionIsKept(t *testing.T) {
+	p, _, _ := fakePacker(t)
+	writeFiles(t, p.Root, map[string]string{"package.json": `{"name": "@pulumi/cdk", "version": "1.12.0-alpha.1"}`})
+	b, err := p.Pack(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1.12.0-alpha.1", b.Version)
+}
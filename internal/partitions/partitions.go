@@ -0,0 +1,282 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package partitions checks that a program running in a region of one AWS partition refers to that partition only.
+// The converter resolves `AWS::Partition` and `AWS::URLSuffix` with the getPartition and getUrlSuffix invokes of
+// aws-native, and the synthesizer builds asset URLs from the URL suffix, so a hard-coded `arn:aws:` or `amazonaws.com`
+// only shows up when the program runs in `aws-cn` or `aws-us-gov`. Check finds ARNs of another partition, endpoints
+// with another URL suffix or in a region of another partition, and service principals of another partition.
+package partitions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/regions"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Partition is an AWS partition.
+type Partition struct {
+	// ID is the partition in ARNs, e.g. `aws-cn`.
+	ID string
+	// DNSSuffix is what `AWS::URLSuffix` resolves to in the partition.
+	DNSSuffix string
+	// Region is the region of the partition that the tests run in against the fake endpoints of internal/fakeaws.
+	Region string
+}
+
+var (
+	// AWS is the commercial partition.
+	AWS = Partition{ID: "aws", DNSSuffix: "amazonaws.com", Region: "us-east-1"}
+	// China is the partition of the Beijing and Ningxia regions.
+	China = Partition{ID: "aws-cn", DNSSuffix: "amazonaws.com.cn", Region: "cn-north-1"}
+	// GovCloud is the partition of the AWS GovCloud (US) regions.
+	GovCloud = Partition{ID: "aws-us-gov", DNSSuffix: "amazonaws.com", Region: "us-gov-west-1"}
+)
+
+// Others are the partitions besides AWS, which the tests only run in against fake endpoints.
+var Others = []Partition{China, GovCloud}
+
+// EnvVar lists the partitions that the acceptance tests preview the programs in against fake endpoints, e.g.
+// `aws-cn,aws-us-gov`.
+const EnvVar = "PULUMI_CDK_TEST_PARTITIONS"
+
+// Selected returns the partitions of Others that EnvVar lists, in the order of Others. It is empty if EnvVar is not
+// set.
+func Selected(getenv func(string) string) ([]Partition, error) {
+	var ids []string
+	for _, id := range strings.Split(getenv(EnvVar), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !slices.ContainsFunc(Others, func(p Partition) bool { return p.ID == id }) {
+			return nil, fmt.Errorf("%s: %q is not one of the partitions besides aws", EnvVar, id)
+		}
+		ids = append(ids, id)
+	}
+	var selected []Partition
+	for _, p := range Others {
+		if slices.Contains(ids, p.ID) {
+			selected = append(selected, p)
+		}
+	}
+	return selected, nil
+}
+
+// ForRegion returns the partition of a region. The isolated partitions are not covered, their regions count as AWS.
+func ForRegion(region string) Partition {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return China
+	case strings.HasPrefix(region, "us-gov-"):
+		return GovCloud
+	default:
+		return AWS
+	}
+}
+
+// Contains reports whether the region belongs to the partition.
+func (p Partition) Contains(region string) bool {
+	return ForRegion(region).ID == p.ID
+}
+
+// Kind names what a Violation refers to.
+type Kind string
+
+const (
+	// KindARN is an ARN of another partition, or of a region of another partition.
+	KindARN Kind = "arn"
+	// KindURLSuffix is an endpoint with the URL suffix of another partition, or in a region of another partition.
+	KindURLSuffix Kind = "urlSuffix"
+	// KindServicePrincipal is a service principal of another partition.
+	KindServicePrincipal Kind = "servicePrincipal"
+)
+
+// Violation is a value that refers to another partition.
+type Violation struct {
+	// URN is the resource whose inputs or outputs hold the value, empty for values checked on their own.
+	URN resource.URN `json:"urn,omitempty"`
+	// Path is the property path of the value, e.g. `outputs.roleArn` or
+	// `inputs.assumeRolePolicyDocument.Statement[0].Principal.Service`.
+	Path    string `json:"path"`
+	Kind    Kind   `json:"kind"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.URN == "" {
+		return fmt.Sprintf("%s: [%s] %s", v.Path, v.Kind, v.Message)
+	}
+	return fmt.Sprintf("%s: %s: [%s] %s", v.URN, v.Path, v.Kind, v.Message)
+}
+
+var (
+	arnPattern = regexp.MustCompile(`\barn:(aws[a-z-]*):([a-z0-9-]+):([a-z0-9-]*):[^\s"',]*`)
+	// hostPattern matches the hosts of AWS services, with the scheme if they are part of a URL.
+	hostPattern = regexp.MustCompile(`(?:(https?)://)?\b((?:[a-z0-9-]+\.)+)amazonaws\.com(\.cn)?\b`)
+)
+
+// Check returns the violations of the strings in v, e.g. the outputs of a stack, for a program running in partition
+// p. Path names v in the violations.
+func Check(p Partition, path string, v any) []Violation {
+	c := &checker{partition: p}
+	c.walk(path, v, false)
+	return c.violations
+}
+
+// CheckDeployment checks the inputs and outputs of every resource of a deployment, which include the stack outputs
+// as the outputs of the stack resource, see Check.
+func CheckDeployment(deployment *apitype.DeploymentV3, p Partition) []Violation {
+	if deployment == nil {
+		return nil
+	}
+	var violations []Violation
+	for _, res := range deployment.Resources {
+		violations = append(violations, checkResource(res.URN, p, res.Inputs, res.Outputs)...)
+	}
+	return violations
+}
+
+// CheckPlan checks the inputs and the proposed outputs of every resource in a plan file written by
+// `pulumi preview --save-plan`, see Check. Values that are unknown during the preview are not checked.
+func CheckPlan(path string, p Partition) ([]Violation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan apitype.DeploymentPlanV1
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	urns := make([]resource.URN, 0, len(plan.ResourcePlans))
+	for urn := range plan.ResourcePlans {
+		urns = append(urns, urn)
+	}
+	sort.Slice(urns, func(i, j int) bool { return urns[i] < urns[j] })
+
+	var violations []Violation
+	for _, urn := range urns {
+		rp := plan.ResourcePlans[urn]
+		inputs := map[string]any{}
+		if rp.Goal != nil {
+			for k, v := range rp.Goal.InputDiff.Adds {
+				inputs[k] = v
+			}
+			for k, v := range rp.Goal.InputDiff.Updates {
+				inputs[k] = v
+			}
+		}
+		violations = append(violations, checkResource(urn, p, inputs, rp.Outputs)...)
+	}
+	return violations, nil
+}
+
+func checkResource(urn resource.URN, p Partition, inputs, outputs map[string]any) []Violation {
+	violations := append(Check(p, "inputs", inputs), Check(p, "outputs", outputs)...)
+	for i := range violations {
+		violations[i].URN = urn
+	}
+	return violations
+}
+
+type checker struct {
+	partition  Partition
+	violations []Violation
+}
+
+// walk checks the strings in v. Strings under the Service key of an IAM principal are service principals.
+func (c *checker) walk(path string, v any, principal bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			c.walk(path+"."+k, v[k], k == "Service")
+		}
+	case []any:
+		for i, e := range v {
+			c.walk(fmt.Sprintf("%s[%d]", path, i), e, principal)
+		}
+	case string:
+		c.checkARNs(path, v)
+		c.checkHosts(path, v, principal)
+	}
+}
+
+func (c *checker) report(path string, kind Kind, value, format string, args ...any) {
+	c.violations = append(c.violations, Violation{
+		Path:    path,
+		Kind:    kind,
+		Value:   value,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) checkARNs(path, s string) {
+	p := c.partition
+	for _, m := range arnPattern.FindAllStringSubmatch(s, -1) {
+		arn, partition, region := m[0], m[1], m[3]
+		if partition != p.ID {
+			c.report(path, KindARN, arn, "%s is an ARN of partition %s, not %s", arn, partition, p.ID)
+		} else if regions.Valid(region) && !p.Contains(region) {
+			c.report(path, KindARN, arn, "%s is an ARN of region %s, which is not in partition %s", arn, region, p.ID)
+		}
+	}
+}
+
+// checkHosts checks the hosts of AWS services in s. Endpoints, i.e. hosts in a URL or with a region, must end with
+// the URL suffix of the partition. Global service principals end with amazonaws.com in every partition, and some
+// with the URL suffix of theirs as well.
+func (c *checker) checkHosts(path, s string, principal bool) {
+	p := c.partition
+	kind := KindURLSuffix
+	if principal {
+		kind = KindServicePrincipal
+	}
+	for _, m := range hostPattern.FindAllStringSubmatchIndex(s, -1) {
+		host := s[m[4]:m[1]]
+		url := m[2] >= 0
+		suffix := "amazonaws.com"
+		if m[6] >= 0 {
+			suffix = "amazonaws.com.cn"
+		}
+		region := ""
+		for _, label := range strings.Split(s[m[4]:m[5]], ".") {
+			if regions.Valid(label) {
+				region = label
+			}
+		}
+		switch {
+		case region != "" && !p.Contains(region):
+			c.report(path, kind, host, "%s is in region %s, which is not in partition %s", host, region, p.ID)
+		case (url || region != "") && suffix != p.DNSSuffix:
+			c.report(path, kind, host, "%s does not end with %s, the URL suffix of partition %s", host, p.DNSSuffix,
+				p.ID)
+		case suffix != AWS.DNSSuffix && suffix != p.DNSSuffix:
+			c.report(path, kind, host, "%s ends with %s, which is not a URL suffix of partition %s", host, suffix, p.ID)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partitions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForRegion(t *testing.T) {
+	assert.Equal(t, AWS, ForRegion("us-east-2"))
+	assert.Equal(t, China, ForRegion("cn-northwest-1"))
+	assert.Equal(t, GovCloud, ForRegion("us-gov-east-1"))
+	for _, p := range append(Others, AWS) {
+		assert.True(t, p.Contains(p.Region), p.ID)
+	}
+	assert.False(t, GovCloud.Contains("us-east-1"))
+}
+
+func TestSelected(t *testing.T) {
+	env := func(value string) func(string) string {
+		return func(key string) string {
+			if key == EnvVar {
+				return value
+			}
+			return ""
+		}
+	}
+
+	selected, err := Selected(env(""))
+	require.NoError(t, err)
+	assert.Empty(t, selected)
+
+	selected, err = Selected(env(" aws-us-gov,,aws-cn ,aws-cn"))
+	require.NoError(t, err)
+	assert.Equal(t, []Partition{China, GovCloud}, selected)
+
+	_, err = Selected(env("aws"))
+	assert.ErrorContains(t, err, `"aws" is not one of the partitions besides aws`)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition Partition
+		value     any
+		kinds     []Kind
+	}{
+		{
+			name:      "arn of the partition",
+			partition: China,
+			value:     "arn:aws-cn:s3:::bucket/*",
+		},
+		{
+			name:      "arn of another partition",
+			partition: China,
+			value:     "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+			kinds:     []Kind{KindARN},
+		},
+		{
+			name:      "arn of a region of another partition",
+			partition: GovCloud,
+			value:     "arn:aws-us-gov:sqs:us-east-1:123456789012:queue",
+			kinds:     []Kind{KindARN},
+		},
+		{
+			name:      "arns in a policy",
+			partition: GovCloud,
+			value: map[string]any{"Statement": []any{map[string]any{
+				"Resource": []any{"arn:aws-us-gov:s3:::bucket", "arn:aws:s3:::bucket/*"},
+			}}},
+			kinds: []Kind{KindARN},
+		},
+		{
+			name:      "endpoint with the url suffix",
+			partition: China,
+			value:     "https://s3.cn-north-1.amazonaws.com.cn/bucket/asset.zip",
+		},
+		{
+			name:      "endpoint with another url suffix",
+			partition: China,
+			value:     "https://s3.cn-north-1.amazonaws.com/bucket/asset.zip",
+			kinds:     []Kind{KindURLSuffix},
+		},
+		{
+			name:      "global endpoint with another url suffix",
+			partition: China,
+			value:     "https://sqs.amazonaws.com/queue",
+			kinds:     []Kind{KindURLSuffix},
+		},
+		{
+			name:      "endpoint in a region of another partition",
+			partition: GovCloud,
+			value:     "123456789012.dkr.ecr.us-west-2.amazonaws.com/repo:tag",
+			kinds:     []Kind{KindURLSuffix},
+		},
+		{
+			name:      "service principals of the partition",
+			partition: China,
+			value: map[string]any{"Principal": map[string]any{
+				"Service": []any{"lambda.amazonaws.com", "ec2.amazonaws.com.cn", "logs.cn-north-1.amazonaws.com.cn"},
+			}},
+		},
+		{
+			name:      "service principal of another partition",
+			partition: AWS,
+			value:     map[string]any{"Principal": map[string]any{"Service": "ec2.amazonaws.com.cn"}},
+			kinds:     []Kind{KindServicePrincipal},
+		},
+		{
+			name:      "regional service principal of another partition",
+			partition: China,
+			value:     map[string]any{"Principal": map[string]any{"Service": "logs.cn-north-1.amazonaws.com"}},
+			kinds:     []Kind{KindServicePrincipal},
+		},
+		{
+			name:      "no aws values",
+			partition: China,
+			value:     map[string]any{"name": "arn", "count": float64(1), "nested": []any{true, nil}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []Kind
+			for _, v := range Check(tt.partition, "outputs", tt.value) {
+				kinds = append(kinds, v.Kind)
+			}
+			assert.Equal(t, tt.kinds, kinds)
+		})
+	}
+}
+
+func TestCheckDeployment(t *testing.T) {
+	deployment := &apitype.DeploymentV3{Resources: []apitype.ResourceV3{
+		{
+			URN: "urn:pulumi:dev::proj::pulumi:pulumi:Stack::proj-dev",
+			Outputs: map[string]any{
+				"roleArn": "arn:aws:iam::123456789012:role/role",
+			},
+		},
+		{
+			URN: "urn:pulumi:dev::proj::cdk:index:App$cdk:construct:Stack$aws-native:iam:Role::role",
+			Inputs: map[string]any{
+				"assumeRolePolicyDocument": map[string]any{"Statement": []any{map[string]any{
+					"Principal": map[string]any{"Service": "lambda.amazonaws.com"},
+				}}},
+			},
+			Outputs: map[string]any{"arn": "arn:aws-cn:iam::123456789012:role/role"},
+		},
+	}}
+
+	violations := CheckDeployment(deployment, China)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "urn:pulumi:dev::proj::pulumi:pulumi:Stack::proj-dev: outputs.roleArn: [arn] "+
+		"arn:aws:iam::123456789012:role/role is an ARN of partition aws, not aws-cn", violations[0].String())
+	assert.Empty(t, CheckDeployment(nil, China))
+}
+
+func TestCheckPlan(t *testing.T) {
+	plan := `{
+		"resourcePlans": {
+			"urn:pulumi:dev::proj::cdk:index:App$cdk:construct:Stack$aws-native:lambda:Function::fn": {
+				"goal": {
+					"type": "aws-native:lambda:Function",
+					"name": "fn",
+					"custom": true,
+					"protect": false,
+					"inputDiff": {
+						"adds": {
+							"code": {"s3Bucket": "staging", "s3Key": "asset.zip"},
+							"role": "04da6b54-80e4-46f7-96ec-b56ff0331ba9"
+						},
+						"updates": {
+							"layers": ["arn:aws:lambda:us-gov-west-1:123456789012:layer:layer:1"]
+						}
+					}
+				},
+				"state": {
+					"arn": "arn:aws-us-gov:lambda:us-gov-west-1:123456789012:function:fn"
+				}
+			}
+		}
+	}`
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(plan), 0o600))
+
+	violations, err := CheckPlan(path, GovCloud)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+	assert.Equal(t, KindARN, violations[0].Kind)
+	assert.Equal(t, "inputs.layers[0]", violations[0].Path)
+
+	_, err = CheckPlan(filepath.Join(t.TempDir(), "missing.json"), GovCloud)
+	assert.Error(t, err)
+}
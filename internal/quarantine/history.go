@@ -0,0 +1,125 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of a quarantined test in one quarantine run.
+type Result struct {
+	Test   string    `json:"test"`
+	Time   time.Time `json:"time"`
+	Passed bool      `json:"passed"`
+	// Commit is the commit that was tested, if known.
+	Commit string `json:"commit,omitempty"`
+}
+
+var historyMu sync.Mutex
+
+// Record appends a run to the history file, a JSON object per line.
+func Record(path string, run Result) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return errors.Join(err, f.Close())
+}
+
+// LoadHistory reads a history file. A missing file is an empty history.
+func LoadHistory(path string) ([]Result, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var runs []Result
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, scanner.Err()
+}
+
+// Summary is the pass/fail history of a quarantined test.
+type Summary struct {
+	Test   string
+	Passed int
+	Failed int
+	// Streak is the number of most recent runs with the same outcome as the last one.
+	Streak   int
+	LastPass time.Time
+}
+
+func (s Summary) String() string {
+	last := "never"
+	if !s.LastPass.IsZero() {
+		last = s.LastPass.Format(dateLayout)
+	}
+	return fmt.Sprintf("%s: %d passed, %d failed, last passed %s", s.Test, s.Passed, s.Failed, last)
+}
+
+// Summarize returns the history per test, sorted by test name.
+func Summarize(runs []Result) []Summary {
+	byTest := map[string][]Result{}
+	for _, r := range runs {
+		byTest[r.Test] = append(byTest[r.Test], r)
+	}
+	var out []Summary
+	for test, rs := range byTest {
+		sort.SliceStable(rs, func(i, j int) bool { return rs[i].Time.Before(rs[j].Time) })
+		s := Summary{Test: test}
+		for i, r := range rs {
+			if r.Passed {
+				s.Passed++
+				s.LastPass = r.Time
+			} else {
+				s.Failed++
+			}
+			if i > 0 && r.Passed == rs[i-1].Passed {
+				s.Streak++
+			} else {
+				s.Streak = 1
+			}
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Test < out[j].Test })
+	return out
+}
@@ -0,0 +1,148 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quarantine tracks flaky or broken acceptance tests that are taken out of the blocking test run. Every
+// quarantine has a reason and an expiry date, and quarantined tests keep running in a non-blocking mode that records
+// their pass/fail history, so that skipped coverage does not rot silently.
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dateLayout is the format of expiry dates.
+const dateLayout = "2006-01-02"
+
+// Entry quarantines a test. The test is skipped in the blocking run and runs in the quarantine run, which records its
+// outcome.
+type Entry struct {
+	Test   string `json:"test"`
+	Reason string `json:"reason"`
+	// Issue links to the issue that tracks fixing the test.
+	Issue string `json:"issue"`
+	// Expires is the last day the quarantine applies, as YYYY-MM-DD.
+	Expires string `json:"expires"`
+}
+
+// Expired reports whether the quarantine ended before now.
+func (e Entry) Expired(now time.Time) bool {
+	expires, err := time.Parse(dateLayout, e.Expires)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expires.AddDate(0, 0, 1))
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s is quarantined until %s: %s (%s)", e.Test, e.Expires, e.Reason, e.Issue)
+}
+
+// File is a quarantine file.
+type File struct {
+	Tests []Entry `json:"tests"`
+}
+
+// Load reads and validates a quarantine file. A missing file is an empty quarantine.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func (f *File) validate() error {
+	seen := map[string]bool{}
+	for _, e := range f.Tests {
+		switch {
+		case e.Test == "":
+			return errors.New("quarantine entry without a test")
+		case seen[e.Test]:
+			return fmt.Errorf("%s is quarantined twice", e.Test)
+		case e.Reason == "":
+			return fmt.Errorf("%s: a reason is required", e.Test)
+		case e.Issue == "":
+			return fmt.Errorf("%s: an issue is required", e.Test)
+		}
+		if _, err := time.Parse(dateLayout, e.Expires); err != nil {
+			return fmt.Errorf("%s: expires must be a YYYY-MM-DD date: %w", e.Test, err)
+		}
+		seen[e.Test] = true
+	}
+	return nil
+}
+
+// Lookup returns the quarantine of a test.
+func (f *File) Lookup(test string) (Entry, bool) {
+	for _, e := range f.Tests {
+		if e.Test == test {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Expired returns the quarantines that ended before now.
+func (f *File) Expired(now time.Time) []Entry {
+	var out []Entry
+	for _, e := range f.Tests {
+		if e.Expired(now) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Test < out[j].Test })
+	return out
+}
+
+// Active returns the quarantines in effect at now.
+func (f *File) Active(now time.Time) []Entry {
+	var out []Entry
+	for _, e := range f.Tests {
+		if !e.Expired(now) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Pattern returns a `go test -run` or `-skip` pattern that matches exactly the given top-level tests. With no tests
+// it matches nothing.
+func Pattern(entries []Entry) string {
+	if len(entries) == 0 {
+		return "^$"
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = regexp.QuoteMeta(e.Test)
+	}
+	sort.Strings(names)
+	return "^(" + strings.Join(names, "|") + ")$"
+}
@@ -0,0 +1,104 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func day(s string) time.Time {
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestLoad(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, f.Tests)
+
+	for content, msg := range map[string]string{
+		`{"tests":[{"test":"TestA","reason":"flaky","expires":"2027-01-31"}]}`:                                 "TestA: an issue is required",
+		`{"tests":[{"test":"TestA","reason":"flaky","issue":"https://example.com/1","expires":"31/01/2027"}]}`: "TestA: expires must be a YYYY-MM-DD date",
+		`{"tests":[{"test":"TestA","issue":"https://example.com/1","expires":"2027-01-31"}]}`:                  "TestA: a reason is required",
+		`{"tests":[{"test":"TestA","reason":"x","issue":"https://example.com/1","expires":"2027-01-31"},
+		           {"test":"TestA","reason":"y","issue":"https://example.com/2","expires":"2027-01-31"}]}`: "TestA is quarantined twice",
+	} {
+		_, err := Load(writeFile(t, content))
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+func TestActive(t *testing.T) {
+	f, err := Load(writeFile(t, `{"tests":[
+		{"test":"TestFlaky","reason":"throttling","issue":"https://example.com/1","expires":"2027-01-31"},
+		{"test":"TestTimeout","reason":"slow","issue":"https://example.com/2","expires":"2027-01-31"},
+		{"test":"TestStale","reason":"old","issue":"https://example.com/3","expires":"2026-01-31"}
+	]}`))
+	require.NoError(t, err)
+	// The last day of a quarantine still counts.
+	now := day("2027-01-31").Add(23 * time.Hour)
+
+	assert.Equal(t, []string{"TestFlaky", "TestTimeout"}, names(f.Active(now)))
+	assert.Equal(t, []string{"TestStale"}, names(f.Expired(now)))
+	assert.Equal(t, []string{"TestFlaky", "TestStale", "TestTimeout"}, names(f.Expired(day("2027-02-01"))))
+
+	assert.Equal(t, "^(TestFlaky|TestTimeout)$", Pattern(f.Active(now)))
+	assert.Equal(t, "^$", Pattern(nil))
+
+	e, _ := f.Lookup("TestFlaky")
+	assert.Equal(t, "TestFlaky is quarantined until 2027-01-31: throttling (https://example.com/1)", e.String())
+}
+
+func names(entries []Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Test)
+	}
+	return out
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for i, passed := range []bool{true, false, false} {
+		require.NoError(t, Record(path, Result{Test: "TestFlaky", Time: day("2026-10-01").AddDate(0, 0, i), Passed: passed}))
+	}
+	require.NoError(t, Record(path, Result{Test: "TestNever", Time: day("2026-10-01")}))
+
+	runs, err := LoadHistory(path)
+	require.NoError(t, err)
+	summaries := Summarize(runs)
+	assert.Equal(t, []Summary{
+		{Test: "TestFlaky", Passed: 1, Failed: 2, Streak: 2, LastPass: day("2026-10-01")},
+		{Test: "TestNever", Failed: 1, Streak: 1},
+	}, summaries)
+	assert.Equal(t, "TestFlaky: 1 passed, 2 failed, last passed 2026-10-01", summaries[0].String())
+	assert.Equal(t, "TestNever: 0 passed, 1 failed, last passed never", summaries[1].String())
+}
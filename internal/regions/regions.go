@@ -0,0 +1,103 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package regions expands the acceptance tests into a matrix of AWS regions. The matrix is the comma-separated list
+// in EnvVar, or the single AWS_REGION when that is not set, so that a run without EnvVar behaves as before. Tests that
+// depend on a region declare a Constraint: pinned to the one region where a service or feature is available, or
+// excluded from regions where the test cannot say anything, e.g. because it deploys to that region explicitly.
+package regions
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// EnvVar lists the regions of the matrix, e.g. `us-east-2,eu-west-1`.
+const EnvVar = "PULUMI_CDK_TEST_REGIONS"
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// Valid reports whether s has the form of a region name, e.g. `us-east-2` or `cn-north-1`.
+func Valid(s string) bool {
+	return regionPattern.MatchString(s)
+}
+
+// Matrix returns the regions to run the tests in, in the order they are listed and without duplicates. It is empty
+// if neither EnvVar nor AWS_REGION is set.
+func Matrix(getenv func(string) string) ([]string, error) {
+	list := getenv(EnvVar)
+	if strings.TrimSpace(list) == "" {
+		list = getenv("AWS_REGION")
+	}
+	var matrix []string
+	for _, region := range strings.Split(list, ",") {
+		region = strings.TrimSpace(region)
+		if region == "" || slices.Contains(matrix, region) {
+			continue
+		}
+		if !Valid(region) {
+			return nil, fmt.Errorf("%s: %q is not a region", EnvVar, region)
+		}
+		matrix = append(matrix, region)
+	}
+	return matrix, nil
+}
+
+// Constraint restricts the regions a test runs in. The zero value runs the test in every region of the matrix.
+type Constraint struct {
+	// Pinned runs the test in this region only, whether or not the matrix lists it.
+	Pinned string
+	// Excluded regions of the matrix are skipped.
+	Excluded []string
+	// Reason explains the constraint in the skip message of the excluded regions.
+	Reason string
+}
+
+// Run is a region that a test is expanded into.
+type Run struct {
+	Region string
+	// Skip is why the test does not run in the region, empty if it does.
+	Skip string
+}
+
+// Expand returns the runs of a test with the constraint for the matrix, in the order of the matrix.
+func (c Constraint) Expand(matrix []string) []Run {
+	if c.Pinned != "" {
+		return []Run{{Region: c.Pinned}}
+	}
+	runs := make([]Run, len(matrix))
+	for i, region := range matrix {
+		runs[i] = Run{Region: region}
+		if slices.Contains(c.Excluded, region) {
+			runs[i].Skip = c.Reason
+			if runs[i].Skip == "" {
+				runs[i].Skip = "excluded"
+			}
+		}
+	}
+	return runs
+}
+
+// Other returns the first candidate that is none of the excepted regions, e.g. to deploy a second stack to a region
+// that differs from the one the test runs in. It returns "" if every candidate is excepted.
+func Other(candidates []string, except ...string) string {
+	for _, region := range candidates {
+		if !slices.Contains(except, region) {
+			return region
+		}
+	}
+	return ""
+}
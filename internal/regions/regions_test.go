@@ -0,0 +1,69 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package regions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrix(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	matrix, err := Matrix(env(map[string]string{"AWS_REGION": "us-east-2"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"us-east-2"}, matrix)
+
+	matrix, err = Matrix(env(map[string]string{
+		"AWS_REGION": "us-east-2",
+		EnvVar:       " eu-west-1, us-gov-west-1,,eu-west-1 ,cn-north-1",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1", "us-gov-west-1", "cn-north-1"}, matrix)
+
+	matrix, err = Matrix(env(nil))
+	require.NoError(t, err)
+	assert.Empty(t, matrix)
+
+	_, err = Matrix(env(map[string]string{EnvVar: "us-east-2,us-east"}))
+	assert.ErrorContains(t, err, `"us-east" is not a region`)
+}
+
+func TestExpand(t *testing.T) {
+	matrix := []string{"us-east-2", "us-east-1", "eu-west-1"}
+
+	assert.Equal(t, []Run{{Region: "us-east-2"}, {Region: "us-east-1"}, {Region: "eu-west-1"}},
+		Constraint{}.Expand(matrix))
+	assert.Equal(t, []Run{{Region: "us-east-1"}}, Constraint{Pinned: "us-east-1"}.Expand(matrix))
+	assert.Equal(t, []Run{{Region: "ap-south-1"}}, Constraint{Pinned: "ap-south-1"}.Expand(matrix))
+	assert.Equal(t, []Run{
+		{Region: "us-east-2"},
+		{Region: "us-east-1", Skip: "the program deploys to us-east-1 explicitly"},
+		{Region: "eu-west-1"},
+	}, Constraint{Excluded: []string{"us-east-1"}, Reason: "the program deploys to us-east-1 explicitly"}.Expand(matrix))
+	assert.Equal(t, []Run{{Region: "us-east-2", Skip: "excluded"}},
+		Constraint{Excluded: []string{"us-east-2"}}.Expand([]string{"us-east-2"}))
+}
+
+func TestOther(t *testing.T) {
+	candidates := []string{"us-west-2", "us-east-2"}
+	assert.Equal(t, "us-west-2", Other(candidates, "us-east-2", "us-east-1"))
+	assert.Equal(t, "us-east-2", Other(candidates, "us-west-2", "us-east-1"))
+	assert.Equal(t, "", Other(candidates, "us-west-2", "us-east-2"))
+}
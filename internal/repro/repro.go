@@ -0,0 +1,283 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package repro writes self-contained reproducer bundles for failed example tests: the program and its edits, the
+// temp project directory as the test left it, the resolved test options, the stack config, the exported state, the
+// engine events and a script that reruns the test locally up to the step that failed.
+package repro
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+)
+
+// Options is the part of integration.ProgramTestOptions that determines what a test deploys. Secret config values
+// are not included, only their keys.
+type Options struct {
+	Dir              string            `json:"dir"`
+	StackName        string            `json:"stackName"`
+	Config           map[string]string `json:"config,omitempty"`
+	SecretKeys       []string          `json:"secretKeys,omitempty"`
+	Env              []string          `json:"env,omitempty"`
+	Dependencies     []string          `json:"dependencies,omitempty"`
+	Overrides        map[string]string `json:"overrides,omitempty"`
+	Edits            []Edit            `json:"edits,omitempty"`
+	ExpectFailure    bool              `json:"expectFailure,omitempty"`
+	Quick            bool              `json:"quick,omitempty"`
+	SkipPreview      bool              `json:"skipPreview,omitempty"`
+	SkipRefresh      bool              `json:"skipRefresh,omitempty"`
+	RetryFailedSteps bool              `json:"retryFailedSteps,omitempty"`
+	RunUpdateTest    bool              `json:"runUpdateTest,omitempty"`
+}
+
+// Edit is an edit step of a test.
+type Edit struct {
+	Dir           string `json:"dir"`
+	Additive      bool   `json:"additive,omitempty"`
+	ExpectFailure bool   `json:"expectFailure,omitempty"`
+}
+
+// NewOptions resolves the options of a test.
+func NewOptions(opts *integration.ProgramTestOptions) Options {
+	o := Options{
+		Dir:              opts.Dir,
+		StackName:        opts.GetStackName().String(),
+		Config:           opts.Config,
+		Env:              opts.Env,
+		Dependencies:     opts.Dependencies,
+		Overrides:        opts.Overrides,
+		ExpectFailure:    opts.ExpectFailure,
+		Quick:            opts.Quick,
+		SkipPreview:      opts.SkipPreview,
+		SkipRefresh:      opts.SkipRefresh,
+		RetryFailedSteps: opts.RetryFailedSteps,
+		RunUpdateTest:    opts.RunUpdateTest,
+	}
+	for k := range opts.Secrets {
+		o.SecretKeys = append(o.SecretKeys, k)
+	}
+	sort.Strings(o.SecretKeys)
+	for _, e := range opts.EditDirs {
+		o.Edits = append(o.Edits, Edit{Dir: e.Dir, Additive: e.Additive, ExpectFailure: e.ExpectFailure})
+	}
+	return o
+}
+
+// Step identifies where a test failed.
+type Step struct {
+	// Index is 0 for the program itself and i for the i-th edit.
+	Index int `json:"index"`
+	// Command is the Pulumi command that failed, e.g. `preview` or `up`. It is empty when the runtime validation of
+	// the step failed, in which case the script deploys the step and stops there.
+	Command string `json:"command,omitempty"`
+}
+
+func (s Step) String() string {
+	where := "the program"
+	if s.Index > 0 {
+		where = fmt.Sprintf("edit %d", s.Index)
+	}
+	if s.Command == "" {
+		return "runtime validation of " + where
+	}
+	return fmt.Sprintf("`pulumi %s` of %s", s.Command, where)
+}
+
+// Bundle describes the reproducer of a failed test.
+type Bundle struct {
+	TestName   string  `json:"testName"`
+	Options    Options `json:"options"`
+	FailedStep Step    `json:"failedStep"`
+
+	// ProjectDir is the temp project directory of the test.
+	ProjectDir string `json:"-"`
+	// StateFile is the exported checkpoint, if the stack could be exported.
+	StateFile string `json:"-"`
+	// EventsFile is the engine event log of the last update, if there was one.
+	EventsFile string `json:"-"`
+}
+
+// secretEnvVar is the variable the script reads a secret config value from.
+func secretEnvVar(key string) string {
+	return "REPRO_SECRET_" + strings.ToUpper(strings.NewReplacer(":", "_", "-", "_", ".", "_").Replace(key))
+}
+
+// transientEnvVar reports whether a variable of the test only made sense while the test ran: the passphrase file of
+// the run's local backend, the endpoints and credentials of an in-process fake of internal/fakeaws and the variables
+// of the teardown shim.
+func transientEnvVar(key string) bool {
+	switch key {
+	case "PULUMI_CONFIG_PASSPHRASE_FILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN":
+		return true
+	}
+	return strings.HasPrefix(key, "AWS_ENDPOINT_URL_") || strings.HasPrefix(key, "PULUMI_CDK_TEARDOWN_")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+const (
+	upCommand      = "pulumi up --non-interactive --yes --skip-preview"
+	previewCommand = "pulumi preview --non-interactive"
+)
+
+// Script returns a bash script that reruns the test from the program and edits in the bundle. It uses a local
+// backend in a fresh directory unless PULUMI_BACKEND_URL is set, and deploys to the account of the caller. The
+// environment of the test is exported, except for the variables of the test run itself, which are left as comments.
+func (b Bundle) Script() string {
+	var s strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&s, format+"\n", args...)
+	}
+	install := func() {
+		line("yarn install")
+		for _, dep := range b.Options.Dependencies {
+			line("yarn link %s", shellQuote(dep))
+		}
+	}
+
+	line("#!/usr/bin/env bash")
+	line("# Reruns %s up to the %s.", b.TestName, b.FailedStep)
+	line("set -euo pipefail")
+	line("")
+	line(`here="$(cd "$(dirname "$0")" && pwd)"`)
+	line(`work="$(mktemp -d)"`)
+	line(`echo "working in $work"`)
+	line(`export PULUMI_BACKEND_URL="${PULUMI_BACKEND_URL:-file://$work/.backend}"`)
+	line(`export PULUMI_CONFIG_PASSPHRASE="${PULUMI_CONFIG_PASSPHRASE:-correct horse battery staple}"`)
+	var transient []string
+	for _, env := range b.Options.Env {
+		k, v, ok := strings.Cut(env, "=")
+		if !ok {
+			continue
+		}
+		if transientEnvVar(k) {
+			transient = append(transient, fmt.Sprintf("export %s=%s", k, shellQuote(v)))
+			continue
+		}
+		line("export %s=%s", k, shellQuote(v))
+	}
+	if len(transient) > 0 {
+		line("# The test also had these, which pointed at its backend, fakes and teardown shim and are gone now:")
+		for _, export := range transient {
+			line("# %s", export)
+		}
+	}
+	line(`mkdir -p "$work/.backend" "$work/project"`)
+	line(`cp -R "$here/program/." "$work/project/"`)
+	if len(b.Options.Overrides) > 0 {
+		line("# The package.json of the test has the overrides applied.")
+		line(`cp "$here/project/package.json" "$work/project/package.json"`)
+		for _, p := range b.packages() {
+			line(`sed -i.bak -e "s|file:%s|file:$here/%s|g" "$work/project/package.json"`, p.tarball, p.name)
+			line(`rm "$work/project/package.json.bak"`)
+		}
+	}
+	line(`cd "$work/project"`)
+	install()
+	line("pulumi stack init %s", shellQuote(b.Options.StackName))
+	keys := make([]string, 0, len(b.Options.Config))
+	for k := range b.Options.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		line("pulumi config set %s %s", shellQuote(k), shellQuote(b.Options.Config[k]))
+	}
+	for _, k := range b.Options.SecretKeys {
+		v := secretEnvVar(k)
+		line(`pulumi config set --secret %s "${%s:?set %s to the value of %s}"`, shellQuote(k), v, v, k)
+	}
+
+	for i := 0; i <= b.FailedStep.Index && i <= len(b.Options.Edits); i++ {
+		if i > 0 {
+			edit := b.Options.Edits[i-1]
+			line("")
+			line("# edit %d", i)
+			if !edit.Additive {
+				line(`find . -mindepth 1 -maxdepth 1 ! -name .pulumi ! -name Pulumi.yaml ! -name %s -exec rm -rf {} +`,
+					shellQuote("Pulumi."+b.Options.StackName+".yaml"))
+			}
+			line(`cp -R "$here/edits/%d/." .`, i)
+			install()
+		}
+		if i < b.FailedStep.Index {
+			line(upCommand)
+			continue
+		}
+		switch b.FailedStep.Command {
+		case "preview":
+			line(previewCommand)
+		case "", "up":
+			line(upCommand)
+		case "refresh", "destroy":
+			line(upCommand)
+			line("pulumi %s --non-interactive --yes", b.FailedStep.Command)
+		default:
+			line("# the test failed while setting up the stack (`pulumi %s`)", b.FailedStep.Command)
+		}
+	}
+	return s.String()
+}
+
+// bundledPackage is a local package tarball the test installed through an override, e.g. a packed @pulumi/cdk.
+type bundledPackage struct {
+	tarball string
+	// name is the path in the bundle.
+	name string
+}
+
+func (b Bundle) packages() []bundledPackage {
+	var out []bundledPackage
+	for _, v := range b.Options.Overrides {
+		if tarball, ok := strings.CutPrefix(v, "file:"); ok && strings.HasSuffix(tarball, ".tgz") {
+			out = append(out, bundledPackage{tarball: tarball, name: "packages/" + filepath.Base(tarball)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// files lists what goes into the archive: archive path to file or directory on disk.
+func (b Bundle) files() map[string]string {
+	files := map[string]string{"program": b.Options.Dir}
+	for _, p := range b.packages() {
+		if _, err := os.Stat(p.tarball); err == nil {
+			files[p.name] = p.tarball
+		}
+	}
+	for i, e := range b.Options.Edits {
+		files[fmt.Sprintf("edits/%d", i+1)] = e.Dir
+	}
+	if b.ProjectDir != "" {
+		files["project"] = b.ProjectDir
+		config := filepath.Join(b.ProjectDir, "Pulumi."+b.Options.StackName+".yaml")
+		if _, err := os.Stat(config); err == nil {
+			files["config.yaml"] = config
+		}
+	}
+	if b.StateFile != "" {
+		files["state.json"] = b.StateFile
+	}
+	if b.EventsFile != "" {
+		files["events.json"] = b.EventsFile
+	}
+	return files
+}
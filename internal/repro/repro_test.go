@@ -0,0 +1,144 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repro
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func testBundle(t *testing.T) Bundle {
+	root := t.TempDir()
+	program, edit, project := filepath.Join(root, "program"), filepath.Join(root, "step2"), filepath.Join(root, "project")
+	writeFiles(t, program, map[string]string{"index.ts": "v1", "node_modules/x/index.js": ""})
+	writeFiles(t, edit, map[string]string{"index.ts": "v2"})
+	writeFiles(t, project, map[string]string{"index.ts": "v2", "Pulumi.dev.yaml": "config: {}"})
+
+	opts := NewOptions(&integration.ProgramTestOptions{
+		Dir:       program,
+		StackName: "dev",
+		Config:    map[string]string{"aws:region": "us-west-2", "prefix": "a'b"},
+		Secrets:   map[string]string{"db:password": "hunter2-hunter2"},
+		Env: []string{
+			"PULUMI_CONFIG_PASSPHRASE_FILE=/tmp/backend/passphrase",
+			"AWS_REGION=us-west-2",
+			"PULUMI_CDK_OUTDIR=/tmp/out",
+			"AWS_ENDPOINT_URL_STS=http://127.0.0.1:41234",
+			"PULUMI_CDK_TEARDOWN_PIDFILE=/tmp/pulumi.pid",
+		},
+		Dependencies: []string{"@pulumi/cdk"},
+		EditDirs:     []integration.EditDir{{Dir: edit}},
+	})
+	return Bundle{
+		TestName:   "TestExample",
+		Options:    opts,
+		FailedStep: Step{Index: 1, Command: "preview"},
+		ProjectDir: project,
+	}
+}
+
+func TestNewOptionsOmitsSecretValues(t *testing.T) {
+	b := testBundle(t)
+	assert.Equal(t, []string{"db:password"}, b.Options.SecretKeys)
+	assert.NotContains(t, b.Script(), "hunter2")
+}
+
+func TestScript(t *testing.T) {
+	script := testBundle(t).Script()
+	assert.Contains(t, script, "# Reruns TestExample up to the `pulumi preview` of edit 1.")
+	assert.Contains(t, script, "export PULUMI_CDK_OUTDIR='/tmp/out'\n")
+	assert.Contains(t, script, "export AWS_REGION='us-west-2'\n")
+	// The backend, the fakes and the shim of the test run are gone.
+	assert.Contains(t, script, "# export PULUMI_CONFIG_PASSPHRASE_FILE='/tmp/backend/passphrase'\n"+
+		"# export AWS_ENDPOINT_URL_STS='http://127.0.0.1:41234'\n"+
+		"# export PULUMI_CDK_TEARDOWN_PIDFILE='/tmp/pulumi.pid'\n")
+	assert.NotRegexp(t, `(?m)^export (PULUMI_CONFIG_PASSPHRASE_FILE|AWS_ENDPOINT_URL_STS|PULUMI_CDK_TEARDOWN_PIDFILE)=`,
+		script)
+	assert.Contains(t, script, "pulumi config set 'prefix' 'a'\\''b'\n")
+	assert.Contains(t, script,
+		`pulumi config set --secret 'db:password' "${REPRO_SECRET_DB_PASSWORD:?set REPRO_SECRET_DB_PASSWORD to the value of db:password}"`)
+
+	// The program is deployed, then the edit is applied and previewed.
+	tail := script[strings.Index(script, "pulumi stack init"):]
+	assert.Regexp(t, `(?s)pulumi up .*# edit 1\nfind .*cp -R "\$here/edits/1/\." \.\nyarn install\nyarn link '@pulumi/cdk'\n`+
+		`pulumi preview --non-interactive\n$`, tail)
+}
+
+func TestStepString(t *testing.T) {
+	assert.Equal(t, "runtime validation of the program", Step{}.String())
+	assert.Equal(t, "`pulumi up` of edit 2", Step{Index: 2, Command: "up"}.String())
+}
+
+func TestWrite(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "repro.tar.gz")
+	require.NoError(t, testBundle(t).Write(out))
+
+	f, err := os.Open(out)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Typeflag == tar.TypeReg {
+			names = append(names, hdr.Name)
+		}
+	}
+	assert.Equal(t, []string{
+		"repro/bundle.json",
+		"repro/repro.sh",
+		"repro/config.yaml",
+		"repro/edits/1/index.ts",
+		"repro/program/index.ts",
+		"repro/project/Pulumi.dev.yaml",
+		"repro/project/index.ts",
+	}, names)
+}
+
+func TestPackedDependencies(t *testing.T) {
+	tarball := filepath.Join(t.TempDir(), "pulumi-cdk-0123.tgz")
+	require.NoError(t, os.WriteFile(tarball, []byte("tarball"), 0o644))
+	b := testBundle(t)
+	b.Options.Dependencies = nil
+	b.Options.Overrides = map[string]string{"@pulumi/cdk": "file:" + tarball, "@pulumi/aws": "6.83.2"}
+
+	assert.Contains(t, b.Script(), `sed -i.bak -e "s|file:`+tarball+`|file:$here/packages/pulumi-cdk-0123.tgz|g" `+
+		`"$work/project/package.json"`)
+	assert.Equal(t, tarball, b.files()["packages/pulumi-cdk-0123.tgz"])
+}
@@ -0,0 +1,304 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runreport records how long each step of the acceptance tests took, how often it was retried and how many
+// resources the tests deployed, and compares runs to flag examples whose deploy time regressed.
+package runreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/junit"
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+)
+
+// Phases of a test.
+const (
+	PhaseSetup   = "setup"
+	PhasePreview = "preview"
+	PhaseUpdate  = "update"
+	PhaseEdit    = "edit"
+	PhaseRefresh = "refresh"
+	PhaseDestroy = "destroy"
+)
+
+// Outcomes of a test.
+const (
+	Pass = "pass"
+	Fail = "fail"
+	Skip = "skip"
+)
+
+// Step is a step of a test as named by the integration test harness, e.g. `pulumi-update-initial`, with all of its
+// attempts.
+type Step struct {
+	Name     string  `json:"name"`
+	Phase    string  `json:"phase"`
+	Attempts int     `json:"attempts"`
+	Seconds  float64 `json:"seconds"`
+	// Failed is set when the last attempt failed.
+	Failed bool `json:"failed,omitempty"`
+}
+
+// Retries is the number of attempts after the first.
+func (s Step) Retries() int {
+	return s.Attempts - 1
+}
+
+// phase maps the harness's step names to phases.
+func phase(step string) string {
+	switch {
+	case strings.HasPrefix(step, "pulumi-preview-edit-"), strings.HasPrefix(step, "pulumi-update-edit-"):
+		return PhaseEdit
+	case step == "pulumi-refresh", step == "pulumi-preview-after-refresh":
+		return PhaseRefresh
+	case strings.HasPrefix(step, "pulumi-preview-"):
+		return PhasePreview
+	case strings.HasPrefix(step, "pulumi-update-"):
+		return PhaseUpdate
+	case step == "pulumi-destroy", step == "pulumi-stack-rm":
+		return PhaseDestroy
+	default:
+		return PhaseSetup
+	}
+}
+
+// Test is the record of a single test.
+type Test struct {
+	Name    string  `json:"name"`
+	Example string  `json:"example"`
+	Outcome string  `json:"outcome"`
+	Seconds float64 `json:"seconds"`
+	// Resources is the number of resources in the stack after the last update.
+	Resources int    `json:"resources"`
+	Steps     []Step `json:"steps"`
+}
+
+// Retries is the number of retried attempts over all steps.
+func (t *Test) Retries() int {
+	n := 0
+	for _, s := range t.Steps {
+		n += s.Retries()
+	}
+	return n
+}
+
+// PhaseSeconds sums up the time spent in a phase.
+func (t *Test) PhaseSeconds(phase string) float64 {
+	var total float64
+	for _, s := range t.Steps {
+		if s.Phase == phase {
+			total += s.Seconds
+		}
+	}
+	return total
+}
+
+// DeploySeconds is the time spent in `pulumi up`, including the updates of edits.
+func (t *Test) DeploySeconds() float64 {
+	var total float64
+	for _, s := range t.Steps {
+		if strings.HasPrefix(s.Name, "pulumi-update-") {
+			total += s.Seconds
+		}
+	}
+	return total
+}
+
+// Report is the record of a test run of one package. It is safe for concurrent use by parallel tests.
+type Report struct {
+	mu sync.Mutex
+
+	Package string `json:"package"`
+	// Shard is the CDK_TEST_SHARD the package ran with, if any.
+	Shard string `json:"shard,omitempty"`
+	// Build is the @pulumi/cdk build the tests installed, see internal/pack.
+	Build       string       `json:"build,omitempty"`
+	Started     time.Time    `json:"started"`
+	Tests       []*Test      `json:"tests"`
+	Regressions []Regression `json:"regressions,omitempty"`
+}
+
+// New returns an empty report for a package.
+func New(pkg string) *Report {
+	return &Report{Package: pkg, Started: time.Now()}
+}
+
+// SetBuild records the @pulumi/cdk build the tests installed.
+func (r *Report) SetBuild(build string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Build = build
+}
+
+// Recorder records a single test. It implements integration.TestStatsReporter so that it can be set as
+// ProgramTestOptions.ReportStats.
+type Recorder struct {
+	report  *Report
+	test    *Test
+	started time.Time
+}
+
+var _ integration.TestStatsReporter = (*Recorder)(nil)
+
+// Start starts recording a test.
+func (r *Report) Start(name, example string) *Recorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := &Test{Name: name, Example: example}
+	r.Tests = append(r.Tests, t)
+	return &Recorder{report: r, test: t, started: time.Now()}
+}
+
+// ReportCommand records an attempt of a step. Consecutive attempts of a step after a failed one are retries.
+func (rec *Recorder) ReportCommand(stats integration.TestCommandStats) {
+	rec.report.mu.Lock()
+	defer rec.report.mu.Unlock()
+	steps := rec.test.Steps
+	if n := len(steps); n > 0 && steps[n-1].Name == stats.StepName && steps[n-1].Failed {
+		last := &steps[n-1]
+		last.Attempts++
+		last.Seconds += stats.ElapsedSeconds
+		last.Failed = stats.IsError
+		return
+	}
+	rec.test.Steps = append(steps, Step{
+		Name:     stats.StepName,
+		Phase:    phase(stats.StepName),
+		Attempts: 1,
+		Seconds:  stats.ElapsedSeconds,
+		Failed:   stats.IsError,
+	})
+}
+
+// SetResources records the number of resources in the stack.
+func (rec *Recorder) SetResources(n int) {
+	rec.report.mu.Lock()
+	defer rec.report.mu.Unlock()
+	rec.test.Resources = n
+}
+
+// Finish records the outcome and the total duration of the test.
+func (rec *Recorder) Finish(outcome string) {
+	rec.report.mu.Lock()
+	defer rec.report.mu.Unlock()
+	rec.test.Outcome = outcome
+	rec.test.Seconds = time.Since(rec.started).Seconds()
+}
+
+// Load reads a report written by WriteJSON.
+func Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteJUnit writes the report as JUnit XML. Step durations, retries and resource counts are test case properties.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	suite := junit.TestSuite{Name: r.Package, Timestamp: r.Started.UTC().Format(time.RFC3339)}
+	if r.Build != "" {
+		suite.AddProperty("build", r.Build)
+	}
+	tests := append([]*Test{}, r.Tests...)
+	sort.SliceStable(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+	for _, t := range tests {
+		c := junit.TestCase{Name: t.Name, Classname: r.Package, Time: t.Seconds}
+		c.AddProperty("example", t.Example)
+		c.AddProperty("resources", fmt.Sprint(t.Resources))
+		c.AddProperty("retries", fmt.Sprint(t.Retries()))
+		for _, p := range []string{PhasePreview, PhaseUpdate, PhaseEdit, PhaseRefresh, PhaseDestroy} {
+			c.AddProperty(p+".seconds", fmt.Sprintf("%.1f", t.PhaseSeconds(p)))
+		}
+		switch t.Outcome {
+		case Fail:
+			msg := "test failed"
+			for _, s := range t.Steps {
+				if s.Failed {
+					msg = fmt.Sprintf("step %s failed after %d attempt(s)", s.Name, s.Attempts)
+				}
+			}
+			c.Failure = &junit.Failure{Message: msg}
+		case Skip:
+			c.Skipped = &junit.Skipped{}
+		}
+		suite.Add(c)
+	}
+	return junit.Write(w, r.Package, []junit.TestSuite{suite})
+}
+
+// Regression is a test whose deploy time grew beyond the threshold.
+type Regression struct {
+	Test            string  `json:"test"`
+	PreviousSeconds float64 `json:"previousSeconds"`
+	CurrentSeconds  float64 `json:"currentSeconds"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: deploy time %.0fs, was %.0fs (+%.0f%%)", r.Test, r.CurrentSeconds, r.PreviousSeconds,
+		100*(r.CurrentSeconds/r.PreviousSeconds-1))
+}
+
+// MinRegressionSeconds keeps short deploys from being flagged for a few seconds of noise.
+const MinRegressionSeconds = 30
+
+// Compare returns the tests that passed in both runs and whose deploy time grew by more than threshold, a fraction
+// of the previous deploy time, and by at least MinRegressionSeconds.
+func Compare(previous, current *Report, threshold float64) []Regression {
+	before := map[string]*Test{}
+	for _, t := range previous.Tests {
+		if t.Outcome == Pass {
+			before[t.Name] = t
+		}
+	}
+	var out []Regression
+	for _, t := range current.Tests {
+		prev, ok := before[t.Name]
+		if !ok || t.Outcome != Pass || prev.DeploySeconds() == 0 {
+			continue
+		}
+		was, now := prev.DeploySeconds(), t.DeploySeconds()
+		if now > was*(1+threshold) && now-was >= MinRegressionSeconds {
+			out = append(out, Regression{Test: t.Name, PreviousSeconds: was, CurrentSeconds: now})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Test < out[j].Test })
+	return out
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runreport
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/testing/integration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func record(rec *Recorder, steps ...integration.TestCommandStats) {
+	for _, s := range steps {
+		rec.ReportCommand(s)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := New("examples")
+	rec := r.Start("TestBucket", "bucket")
+	record(rec,
+		integration.TestCommandStats{StepName: "yarn-install", ElapsedSeconds: 20},
+		integration.TestCommandStats{StepName: "pulumi-preview-initial", ElapsedSeconds: 10},
+		integration.TestCommandStats{StepName: "pulumi-update-initial", ElapsedSeconds: 40, IsError: true},
+		integration.TestCommandStats{StepName: "pulumi-update-initial", ElapsedSeconds: 60},
+		integration.TestCommandStats{StepName: "pulumi-preview-edit-0", ElapsedSeconds: 5},
+		integration.TestCommandStats{StepName: "pulumi-update-edit-0", ElapsedSeconds: 30},
+		integration.TestCommandStats{StepName: "pulumi-destroy", ElapsedSeconds: 50},
+	)
+	rec.SetResources(12)
+	rec.Finish(Pass)
+	r.SetBuild("@pulumi/cdk@0.0.0-test.abc (sources abc)")
+
+	test := r.Tests[0]
+	assert.Equal(t, 1, test.Retries())
+	assert.Equal(t, Step{Name: "pulumi-update-initial", Phase: PhaseUpdate, Attempts: 2, Seconds: 100}, test.Steps[2])
+	assert.Equal(t, 130.0, test.DeploySeconds())
+	assert.Equal(t, 35.0, test.PhaseSeconds(PhaseEdit))
+	assert.Equal(t, 20.0, test.PhaseSeconds(PhaseSetup))
+
+	var xml bytes.Buffer
+	require.NoError(t, r.WriteJUnit(&xml))
+	assert.Contains(t, xml.String(), `<property name="build" value="@pulumi/cdk@0.0.0-test.abc (sources abc)"></property>`)
+	assert.Contains(t, xml.String(), `<property name="resources" value="12"></property>`)
+	assert.Contains(t, xml.String(), `<property name="update.seconds" value="100.0"></property>`)
+	assert.Contains(t, xml.String(), `<property name="retries" value="1"></property>`)
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	r := New("integration")
+	rec := r.Start("TestFailing", "failing")
+	record(rec, integration.TestCommandStats{StepName: "pulumi-update-initial", ElapsedSeconds: 3, IsError: true})
+	rec.Finish(Fail)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, r.WriteJSON(f))
+	require.NoError(t, f.Close())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, r.Tests, loaded.Tests)
+
+	var xml bytes.Buffer
+	require.NoError(t, loaded.WriteJUnit(&xml))
+	assert.Contains(t, xml.String(), `<failure message="step pulumi-update-initial failed after 1 attempt(s)"></failure>`)
+}
+
+func TestCompare(t *testing.T) {
+	report := func(seconds map[string]float64, outcome string) *Report {
+		r := New("examples")
+		for name, s := range seconds {
+			rec := r.Start(name, name)
+			record(rec, integration.TestCommandStats{StepName: "pulumi-update-initial", ElapsedSeconds: s})
+			rec.Finish(outcome)
+		}
+		return r
+	}
+	previous := report(map[string]float64{"TestSlower": 100, "TestNoisy": 10, "TestSame": 300, "TestNew": 0}, Pass)
+	current := report(map[string]float64{"TestSlower": 200, "TestNoisy": 30, "TestSame": 320, "TestNew": 100}, Pass)
+
+	regressions := Compare(previous, current, 0.5)
+	assert.Equal(t, []Regression{{Test: "TestSlower", PreviousSeconds: 100, CurrentSeconds: 200}}, regressions)
+	assert.Equal(t, "TestSlower: deploy time 200s, was 100s (+100%)", regressions[0].String())
+
+	failed := report(map[string]float64{"TestSlower": 500}, Fail)
+	assert.Empty(t, Compare(previous, failed, 0.5), "failed runs are not compared")
+}
@@ -0,0 +1,233 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secrets scans everything a test run leaves behind for plaintext copies of sensitive values: the exported
+// checkpoint, the engine event log and the captured output of the Pulumi CLI.
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// MinLength is the shortest value that can be declared as sensitive. Shorter values would match by accident.
+const MinLength = 6
+
+// Secret is a sensitive value that must never appear in plaintext.
+type Secret struct {
+	// Name identifies the value in findings, which never include the value itself.
+	Name  string
+	Value string
+}
+
+// Source provides sensitive values that are only known once the program ran, e.g. values that the program generated
+// and the test reads back after a step.
+type Source func() ([]Secret, error)
+
+// Finding is a plaintext copy of a sensitive value.
+type Finding struct {
+	Secret string `json:"secret"`
+	// Source is where the copy was found, e.g. `checkpoint`, `events` or `stderr`.
+	Source string `json:"source"`
+	// Location narrows it down within the source: a URN and property path, an event or a line.
+	Location string `json:"location"`
+	// Encoding is how the value was written, e.g. `plaintext` or `base64`.
+	Encoding string `json:"encoding"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("secret %q found in %s at %s (%s)", f.Secret, f.Source, f.Location, f.Encoding)
+}
+
+// Scanner looks for the declared secrets.
+type Scanner struct {
+	secrets []Secret
+	sources []Source
+}
+
+// NewScanner returns a scanner for the given secrets.
+func NewScanner(secrets ...Secret) (*Scanner, error) {
+	s := &Scanner{}
+	if err := s.add(secrets); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// AddSource registers a source that is queried on every scan.
+func (s *Scanner) AddSource(src Source) {
+	s.sources = append(s.sources, src)
+}
+
+func (s *Scanner) add(secrets []Secret) error {
+	for _, sec := range secrets {
+		if len(sec.Value) < MinLength {
+			return fmt.Errorf("secret %q is shorter than %d characters", sec.Name, MinLength)
+		}
+	}
+	s.secrets = append(s.secrets, secrets...)
+	return nil
+}
+
+type needle struct {
+	secret   string
+	encoding string
+	value    []byte
+}
+
+func (s *Scanner) needles() ([]needle, error) {
+	all := append([]Secret{}, s.secrets...)
+	for _, src := range s.sources {
+		found, err := src()
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range found {
+			if len(sec.Value) >= MinLength {
+				all = append(all, sec)
+			}
+		}
+	}
+
+	var out []needle
+	for _, sec := range all {
+		out = append(out,
+			needle{sec.Name, "plaintext", []byte(sec.Value)},
+			needle{sec.Name, "base64", []byte(base64.StdEncoding.EncodeToString([]byte(sec.Value)))})
+		// Values with quotes, backslashes or control characters look different once embedded in JSON.
+		if quoted := strconv.Quote(sec.Value); quoted[1:len(quoted)-1] != sec.Value {
+			out = append(out, needle{sec.Name, "json", []byte(quoted[1 : len(quoted)-1])})
+		}
+	}
+	return out, nil
+}
+
+// ScanText scans captured output line by line.
+func (s *Scanner) ScanText(source string, text []byte) ([]Finding, error) {
+	needles, err := s.needles()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for i, line := range bytes.Split(text, []byte("\n")) {
+		for _, n := range needles {
+			if bytes.Contains(line, n.value) {
+				findings = append(findings, Finding{n.secret, source, fmt.Sprintf("line %d", i+1), n.encoding})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// ScanValue scans a decoded JSON value, e.g. the stack outputs. Locations are property paths.
+func (s *Scanner) ScanValue(source, location string, v any) ([]Finding, error) {
+	needles, err := s.needles()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	walk(v, location, func(path, str string) {
+		for _, n := range needles {
+			if strings.Contains(str, string(n.value)) {
+				findings = append(findings, Finding{n.secret, source, path, n.encoding})
+			}
+		}
+	})
+	return findings, nil
+}
+
+// ScanDeployment scans the IDs, inputs and outputs of every resource in an exported checkpoint. Secret values are
+// stored as ciphertext there, so a plaintext copy means a secret lost its secretness somewhere along the way.
+func (s *Scanner) ScanDeployment(d *apitype.DeploymentV3) ([]Finding, error) {
+	if d == nil {
+		return nil, nil
+	}
+	var findings []Finding
+	for _, r := range d.Resources {
+		for _, part := range []struct {
+			name  string
+			value any
+		}{{"id", string(r.ID)}, {"inputs", r.Inputs}, {"outputs", r.Outputs}} {
+			f, err := s.ScanValue("checkpoint", string(r.URN)+" "+part.name, part.value)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, f...)
+		}
+	}
+	return findings, nil
+}
+
+// ScanEvents scans the engine event log of an update.
+func (s *Scanner) ScanEvents(events []apitype.EngineEvent) ([]Finding, error) {
+	needles, err := s.needles()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range needles {
+			if bytes.Contains(data, n.value) {
+				location := fmt.Sprintf("event %d (%s)", e.Sequence, eventKind(data))
+				findings = append(findings, Finding{n.secret, "events", location, n.encoding})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// eventKind returns the name of the payload field that is set, e.g. `diagnosticEvent`.
+func eventKind(data []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "unknown"
+	}
+	for k := range fields {
+		if strings.HasSuffix(k, "Event") {
+			return k
+		}
+	}
+	return "unknown"
+}
+
+func walk(v any, path string, visit func(path, s string)) {
+	switch x := v.(type) {
+	case string:
+		visit(path, x)
+	case []any:
+		for i, e := range x {
+			walk(e, fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walk(x[k], path+"."+k, visit)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScanner(t *testing.T) *Scanner {
+	t.Helper()
+	s, err := NewScanner(Secret{Name: "db-password", Value: `hunter2"pass`})
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewScannerRejectsShortValues(t *testing.T) {
+	_, err := NewScanner(Secret{Name: "pin", Value: "1234"})
+	assert.EqualError(t, err, `secret "pin" is shorter than 6 characters`)
+}
+
+func TestScanText(t *testing.T) {
+	findings, err := newScanner(t).ScanText("stderr", []byte("ok\nerror: password hunter2\"pass rejected\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{{Secret: "db-password", Source: "stderr", Location: "line 2", Encoding: "plaintext"}},
+		findings)
+	assert.Equal(t, `secret "db-password" found in stderr at line 2 (plaintext)`, findings[0].String())
+	assert.NotContains(t, findings[0].String(), "hunter2")
+}
+
+func TestScanDeployment(t *testing.T) {
+	d := &apitype.DeploymentV3{Resources: []apitype.ResourceV3{
+		{
+			URN: "urn:pulumi:dev::proj::aws-native:secretsmanager:Secret::encrypted",
+			Inputs: map[string]any{"secretString": map[string]any{
+				"4dabf18193072939515e22adb298388d": "1b47061264138c4ac30d75fd1eb44270",
+				"ciphertext":                       "v1:AAAA",
+			}},
+		},
+		{
+			URN:     "urn:pulumi:dev::proj::aws-native:ssm:Parameter::leaked",
+			Outputs: map[string]any{"tags": []any{map[string]any{"value": "aHVudGVyMiJwYXNz"}}},
+		},
+	}}
+	findings, err := newScanner(t).ScanDeployment(d)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{{
+		Secret:   "db-password",
+		Source:   "checkpoint",
+		Location: "urn:pulumi:dev::proj::aws-native:ssm:Parameter::leaked outputs.tags[0].value",
+		Encoding: "base64",
+	}}, findings)
+}
+
+func TestScanEvents(t *testing.T) {
+	events := []apitype.EngineEvent{
+		{Sequence: 1, StdoutEvent: &apitype.StdoutEngineEvent{Message: "updating"}},
+		{Sequence: 2, DiagnosticEvent: &apitype.DiagnosticEvent{Message: `value hunter2"pass is invalid`}},
+	}
+	findings, err := newScanner(t).ScanEvents(events)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Secret: "db-password", Source: "events", Location: "event 2 (diagnosticEvent)", Encoding: "json"},
+	}, findings)
+}
+
+func TestSource(t *testing.T) {
+	var found []Secret
+	scanner, err := NewScanner()
+	require.NoError(t, err)
+	scanner.AddSource(func() ([]Secret, error) { return found, nil })
+
+	findings, err := scanner.ScanText("stdout", []byte("token-123456"))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	// Sources are queried on every scan, and values too short to declare are skipped.
+	found = []Secret{{Name: "api", Value: "token-123456"}, {Name: "pin", Value: "1234"}}
+	findings, err = scanner.ScanText("stdout", []byte("token-123456 1234"))
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Secret: "api", Source: "stdout", Location: "line 1", Encoding: "plaintext"},
+	}, findings)
+}
@@ -0,0 +1,245 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shard splits the tests of an acceptance test package over several CI jobs. Tests are assigned by name,
+// weighted by their duration in earlier runs, so that every job gets about the same amount of work and the same test
+// list always yields the same assignment.
+package shard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EnvVar selects the shard to run, as `<index>/<total>` with a 1-based index, e.g. `2/5`.
+const EnvVar = "CDK_TEST_SHARD"
+
+// Shard is one of Total shards.
+type Shard struct {
+	// Index is 1-based.
+	Index int
+	Total int
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
+
+// Parse parses a shard of the form `<index>/<total>`.
+func Parse(s string) (Shard, error) {
+	index, total, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Shard{}, fmt.Errorf("shard %q: expected <index>/<total>", s)
+	}
+	var sh Shard
+	var err error
+	if sh.Index, err = strconv.Atoi(index); err != nil {
+		return Shard{}, fmt.Errorf("shard %q: %w", s, err)
+	}
+	if sh.Total, err = strconv.Atoi(total); err != nil {
+		return Shard{}, fmt.Errorf("shard %q: %w", s, err)
+	}
+	if sh.Total < 1 || sh.Index < 1 || sh.Index > sh.Total {
+		return Shard{}, fmt.Errorf("shard %q: index must be between 1 and the total", s)
+	}
+	return sh, nil
+}
+
+// Timings maps test names to their duration in seconds.
+type Timings map[string]float64
+
+// LoadTimings reads a timing file, a JSON object of test names to seconds. A missing file has no timings.
+func LoadTimings(path string) (Timings, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Timings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Timings
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Write writes the timings with sorted keys and whole seconds, so that the checked-in file diffs well.
+func (t Timings) Write(path string) error {
+	rounded := make(map[string]int, len(t))
+	for name, s := range t {
+		rounded[name] = int(s + 0.5)
+	}
+	data, err := json.MarshalIndent(rounded, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// weight returns the duration of a test, or the mean duration of the known tests for a test without timing. Every
+// test weighs at least a second so that tests without any timings are spread evenly.
+func (t Timings) weight(test string, mean float64) float64 {
+	if s, ok := t[test]; ok && s >= 1 {
+		return s
+	}
+	return max(mean, 1)
+}
+
+func (t Timings) mean(tests []string) float64 {
+	var sum float64
+	var n int
+	for _, test := range tests {
+		if s, ok := t[test]; ok {
+			sum += s
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// Assignment is the tests of one shard.
+type Assignment struct {
+	Index int      `json:"index"`
+	Tests []string `json:"tests"`
+	// EstimatedSeconds is the sum of the tests' durations according to the timings.
+	EstimatedSeconds float64 `json:"estimatedSeconds"`
+}
+
+// Plan assigns tests to total shards, longest test first to the shard with the least work so far. Ties are broken by
+// test name and shard index, so the plan only depends on the tests and the timings. Each shard's tests are sorted.
+func Plan(tests []string, timings Timings, total int) []Assignment {
+	mean := timings.mean(tests)
+	sorted := append([]string{}, tests...)
+	sort.Slice(sorted, func(i, j int) bool {
+		wi, wj := timings.weight(sorted[i], mean), timings.weight(sorted[j], mean)
+		if wi != wj {
+			return wi > wj
+		}
+		return sorted[i] < sorted[j]
+	})
+	plan := make([]Assignment, total)
+	for i := range plan {
+		plan[i].Index = i + 1
+	}
+	for _, test := range sorted {
+		least := 0
+		for i := range plan {
+			if plan[i].EstimatedSeconds < plan[least].EstimatedSeconds {
+				least = i
+			}
+		}
+		plan[least].Tests = append(plan[least].Tests, test)
+		plan[least].EstimatedSeconds += timings.weight(test, mean)
+	}
+	for i := range plan {
+		sort.Strings(plan[i].Tests)
+	}
+	return plan
+}
+
+// Pattern returns a `go test -run` pattern that matches exactly the given top-level tests. With no tests it matches
+// nothing.
+func Pattern(tests []string) string {
+	if len(tests) == 0 {
+		return "^$"
+	}
+	quoted := make([]string, len(tests))
+	for i, test := range tests {
+		quoted[i] = regexp.QuoteMeta(test)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// ListTests returns the names of the top-level tests declared in the _test.go files of a directory.
+func ListTests(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var tests []string
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && isTest(fn) {
+				tests = append(tests, fn.Name.Name)
+			}
+		}
+	}
+	sort.Strings(tests)
+	return tests, nil
+}
+
+// isTest matches the functions `go test` runs as tests: `TestXxx(t *testing.T)`, where Xxx does not start with a
+// lowercase letter.
+func isTest(fn *ast.FuncDecl) bool {
+	name := fn.Name.Name
+	if fn.Recv != nil || !strings.HasPrefix(name, "Test") || name == "TestMain" {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(name[len("Test"):]); unicode.IsLower(r) {
+		return false
+	}
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "T"
+}
+
+// Report records which tests each shard of a package runs.
+type Report struct {
+	Package string       `json:"package"`
+	Shard   int          `json:"shard"`
+	Total   int          `json:"total"`
+	Plan    []Assignment `json:"plan"`
+}
+
+// Tests returns the tests of the shard the report was written by.
+func (r Report) Tests() []string {
+	return r.Plan[r.Shard-1].Tests
+}
+
+// Write writes the report as JSON.
+func (r Report) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,104 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse("2/5")
+	require.NoError(t, err)
+	assert.Equal(t, Shard{Index: 2, Total: 5}, s)
+	assert.Equal(t, "2/5", s.String())
+
+	for _, bad := range []string{"", "2", "0/3", "4/3", "a/3", "1/b", "1/0"} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []string{"TestA", "TestB", "TestC", "TestD", "TestE", "TestNew"}
+	timings := Timings{"TestA": 600, "TestB": 300, "TestC": 300, "TestD": 100, "TestE": 100}
+
+	plan := Plan(tests, timings, 2)
+	// TestNew has no timing and weighs the mean of 280s.
+	assert.Equal(t, []Assignment{
+		{Index: 1, Tests: []string{"TestA", "TestNew"}, EstimatedSeconds: 880},
+		{Index: 2, Tests: []string{"TestB", "TestC", "TestD", "TestE"}, EstimatedSeconds: 800},
+	}, plan)
+
+	// The plan does not depend on the order of the tests.
+	assert.Equal(t, plan, Plan([]string{"TestNew", "TestE", "TestD", "TestC", "TestB", "TestA"}, timings, 2))
+
+	// Without timings, tests are spread evenly.
+	for _, a := range Plan(tests, nil, 3) {
+		assert.Len(t, a.Tests, 2)
+	}
+
+	// More shards than tests leaves shards empty.
+	empty := Plan([]string{"TestA"}, timings, 2)[1]
+	assert.Empty(t, empty.Tests)
+	assert.Equal(t, "^$", Pattern(empty.Tests))
+	assert.Equal(t, "^(TestA|TestNew)$", Pattern(plan[0].Tests))
+}
+
+func TestTimings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timings.json")
+	missing, err := LoadTimings(path)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	require.NoError(t, Timings{"TestB": 12.4, "TestA": 99.6}.Write(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"TestA\": 100,\n  \"TestB\": 12\n}\n", string(data))
+
+	loaded, err := LoadTimings(path)
+	require.NoError(t, err)
+	assert.Equal(t, Timings{"TestA": 100, "TestB": 12}, loaded)
+}
+
+func TestListTests(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a_test.go"), []byte(`package a
+
+import "testing"
+
+func TestMain(m *testing.M) {}
+func TestBucket(t *testing.T) {}
+func Testlower(t *testing.T) {}
+func TestHelper(t *testing.T, name string) {}
+func BenchmarkBucket(b *testing.B) {}
+func Test(t *testing.T) {}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b_test.go"), []byte(`package a
+
+import "testing"
+
+func TestApi(t *testing.T) {}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.go"), []byte("package a\n\nfunc TestNotATest(t *T) {}\n"), 0o600))
+
+	tests, err := ListTests(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Test", "TestApi", "TestBucket"}, tests)
+}
@@ -0,0 +1,305 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package state checks the shape of the Pulumi state that a pulumi-cdk program leaves behind after an update. The
+// checks encode what src/converters/app-converter.ts promises about the resources it registers, so that a regression
+// in the converter shows up as a named violation instead of as a subtle diff on the next deployment.
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+	"github.com/pulumi/pulumi-cdk/internal/cfn"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+const (
+	appType             = "cdk:index:App"
+	constructTypePrefix = "cdk:construct:"
+	nestedStackPrefix   = "cdk:construct:nested-stack/"
+	unknownRegion       = "unknown-region"
+)
+
+// Rule names the invariant that a Violation breaks.
+type Rule string
+
+const (
+	// RuleParent requires every AWS resource to be parented under the App component and a construct component.
+	RuleParent Rule = "parent"
+	// RuleDuplicateLogicalID requires logical IDs to be unique within a stack.
+	RuleDuplicateLogicalID Rule = "duplicateLogicalId"
+	// RuleRetainOnDelete requires RetainOnDelete to match the DeletionPolicy of the template resource.
+	RuleRetainOnDelete Rule = "retainOnDelete"
+	// RuleProvider requires resources of a region-pinned stack to use an explicit provider for that region.
+	RuleProvider Rule = "provider"
+)
+
+// Violation is a resource that breaks one of the invariants.
+type Violation struct {
+	URN     resource.URN `json:"urn"`
+	Rule    Rule         `json:"rule"`
+	Message string       `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: [%s] %s", v.URN, v.Rule, v.Message)
+}
+
+// Options tunes the checks for programs that intentionally step outside of what the converter produces.
+type Options struct {
+	// AllowedOutsideConstructs lists the names of resources that the program registers itself, e.g. with
+	// `{ parent: app }`, and which are therefore not expected to be parented under a construct.
+	AllowedOutsideConstructs []string
+}
+
+// Check validates the resources of the deployment. The assembly is the cloud assembly the program synthesized and
+// may be nil, in which case the checks that need the templates are skipped.
+func Check(deployment *apitype.DeploymentV3, a *assembly.Assembly, opts Options) []Violation {
+	if deployment == nil {
+		return nil
+	}
+	c := &checker{
+		assembly: a,
+		allowed:  map[string]bool{},
+		byURN:    map[resource.URN]*apitype.ResourceV3{},
+		seen:     map[logicalKey][]resource.URN{},
+	}
+	for _, name := range opts.AllowedOutsideConstructs {
+		c.allowed[name] = true
+	}
+	for i := range deployment.Resources {
+		r := &deployment.Resources[i]
+		if !r.Delete {
+			c.byURN[r.URN] = r
+		}
+	}
+	for i := range deployment.Resources {
+		r := &deployment.Resources[i]
+		if r.Delete || !isAWSResource(r) {
+			continue
+		}
+		c.checkResource(r)
+	}
+	c.checkDuplicates()
+
+	sort.SliceStable(c.violations, func(i, j int) bool {
+		if c.violations[i].URN != c.violations[j].URN {
+			return c.violations[i].URN < c.violations[j].URN
+		}
+		return c.violations[i].Rule < c.violations[j].Rule
+	})
+	return c.violations
+}
+
+type logicalKey struct {
+	stackPath string
+	id        string
+}
+
+type checker struct {
+	assembly   *assembly.Assembly
+	allowed    map[string]bool
+	byURN      map[resource.URN]*apitype.ResourceV3
+	seen       map[logicalKey][]resource.URN
+	violations []Violation
+}
+
+func (c *checker) report(urn resource.URN, rule Rule, format string, args ...any) {
+	c.violations = append(c.violations, Violation{URN: urn, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+func isAWSResource(r *apitype.ResourceV3) bool {
+	if !r.Custom || providers.IsProviderType(r.Type) {
+		return false
+	}
+	pkg := string(r.Type.Package())
+	return pkg == "aws" || pkg == "aws-native"
+}
+
+// ancestors returns the parents of the resource, nearest first.
+func (c *checker) ancestors(r *apitype.ResourceV3) []*apitype.ResourceV3 {
+	var out []*apitype.ResourceV3
+	for p := c.byURN[r.Parent]; p != nil; p = c.byURN[p.Parent] {
+		out = append(out, p)
+	}
+	return out
+}
+
+func (c *checker) checkResource(r *apitype.ResourceV3) {
+	ancestors := c.ancestors(r)
+
+	var app, construct *apitype.ResourceV3
+	for _, a := range ancestors {
+		switch {
+		case string(a.Type) == appType:
+			app = a
+		case construct == nil && strings.HasPrefix(string(a.Type), constructTypePrefix):
+			construct = a
+		}
+	}
+	if app == nil {
+		if !c.allowed[r.URN.Name()] {
+			c.report(r.URN, RuleParent, "resource is not parented under a %s component", appType)
+		}
+		return
+	}
+	if construct == nil {
+		if !c.allowed[r.URN.Name()] {
+			c.report(r.URN, RuleParent, "resource is not parented under a %s* component", constructTypePrefix)
+		}
+		return
+	}
+
+	rootPath, stackPath := stackPaths(ancestors, app)
+	if stackPath == "" {
+		return
+	}
+	key := logicalKey{stackPath: stackPath, id: r.URN.Name()}
+	c.seen[key] = append(c.seen[key], r.URN)
+
+	if c.assembly == nil {
+		return
+	}
+	stack := c.stackForPath(rootPath)
+	if stack == nil {
+		return
+	}
+	if t := stack.Templates()[stackPath]; t != nil {
+		if cfnRes, ok := t.Resources[r.URN.Name()]; ok {
+			c.checkRetainOnDelete(r, cfnRes)
+		}
+	}
+	c.checkProvider(r, stack)
+}
+
+// stackPaths derives the construct path of the top-level stack that owns a resource and of the (possibly nested)
+// stack whose template declares it. Stack components are registered as direct children of the App and nested stacks
+// as `cdk:construct:nested-stack/<path>`, both named `<app>/<path>`.
+func stackPaths(ancestors []*apitype.ResourceV3, app *apitype.ResourceV3) (root, stack string) {
+	prefix := app.URN.Name() + "/"
+	for i, a := range ancestors {
+		if stack == "" && strings.HasPrefix(string(a.Type), nestedStackPrefix) {
+			stack = strings.TrimPrefix(string(a.Type), nestedStackPrefix)
+		}
+		if i+1 < len(ancestors) && ancestors[i+1] == app {
+			root = strings.TrimPrefix(a.URN.Name(), prefix)
+			break
+		}
+	}
+	if stack == "" {
+		stack = root
+	}
+	return root, stack
+}
+
+func (c *checker) stackForPath(path string) *assembly.Stack {
+	for _, s := range c.assembly.Stacks {
+		if s.Tree != nil && s.Tree.Path == path {
+			return s
+		}
+	}
+	return nil
+}
+
+// checkRetainOnDelete follows getRetainOnDelete in src/converters/app-converter.ts, which only looks at the
+// DeletionPolicy: Delete means false, every other policy true, and without one RetainOnDelete stays unset. The
+// converter ignores the UpdateReplacePolicy, so it is not checked either.
+func (c *checker) checkRetainOnDelete(r *apitype.ResourceV3, cfnRes cfn.Resource) {
+	policy := cfnRes.DeletionPolicy
+	if policy == "" {
+		if r.RetainOnDelete {
+			c.report(r.URN, RuleRetainOnDelete, "RetainOnDelete is set but the template has no DeletionPolicy")
+		}
+		return
+	}
+	want := policy != "Delete"
+	if r.RetainOnDelete != want {
+		c.report(r.URN, RuleRetainOnDelete, "RetainOnDelete is %t but the template DeletionPolicy is %s",
+			r.RetainOnDelete, policy)
+	}
+}
+
+// checkProvider verifies that a resource of a stack pinned to a region is managed by an explicit provider for that
+// region. A default provider is a violation even when it is configured for the same region: its region comes from the
+// stack configuration rather than from the stack, so the resource would move along with the configuration.
+func (c *checker) checkProvider(r *apitype.ResourceV3, stack *assembly.Stack) {
+	region := pinnedRegion(stack.Environment)
+	if region == "" || r.Provider == "" {
+		return
+	}
+	ref, err := providers.ParseReference(r.Provider)
+	if err != nil {
+		c.report(r.URN, RuleProvider, "invalid provider reference %q: %v", r.Provider, err)
+		return
+	}
+	p := c.byURN[ref.URN()]
+	if p == nil {
+		c.report(r.URN, RuleProvider, "provider %s is not in the state", ref.URN())
+		return
+	}
+	got, _ := p.Inputs["region"].(string)
+	if got == "" {
+		got = "no region"
+	}
+	if providers.IsDefaultProvider(ref.URN()) {
+		c.report(r.URN, RuleProvider, "stack %s is pinned to %s but the resource uses default provider %s configured for %s",
+			stack.ID, region, ref.URN().Name(), got)
+		return
+	}
+	if got != region {
+		c.report(r.URN, RuleProvider, "stack %s is pinned to %s but the resource uses explicit provider %s configured for %s",
+			stack.ID, region, ref.URN().Name(), got)
+	}
+}
+
+// pinnedRegion returns the region of an `aws://account/region` environment, or "" when it is environment agnostic.
+func pinnedRegion(env string) string {
+	parts := strings.Split(strings.TrimPrefix(env, "aws://"), "/")
+	if len(parts) != 2 || parts[1] == unknownRegion {
+		return ""
+	}
+	return parts[1]
+}
+
+func (c *checker) checkDuplicates() {
+	for key, urns := range c.seen {
+		if len(urns) < 2 {
+			continue
+		}
+		for _, urn := range urns {
+			c.report(urn, RuleDuplicateLogicalID, "logical ID %s is used by %d resources in stack %s", key.id, len(urns),
+				key.stackPath)
+		}
+	}
+}
+
+// CheckWithAssemblyDir is Check with the cloud assembly loaded from dir. The template checks are skipped when dir does
+// not hold an assembly, e.g. because the program set its own `outdir`.
+func CheckWithAssemblyDir(deployment *apitype.DeploymentV3, dir string, opts Options) ([]Violation, error) {
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err != nil {
+		return Check(deployment, nil, opts), nil
+	}
+	a, err := assembly.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	return Check(deployment, a, opts), nil
+}
@@ -0,0 +1,212 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi-cdk/internal/assembly"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nestedStackAssembly = "../../tests/test-data/nested-stack"
+
+type deploymentBuilder struct {
+	resources []apitype.ResourceV3
+}
+
+func (b *deploymentBuilder) add(typ, name string, parent resource.URN, custom bool) resource.URN {
+	parentType := tokens.Type("")
+	if parent != "" {
+		parentType = parent.QualifiedType()
+	}
+	urn := resource.NewURN("dev", "proj", parentType, tokens.Type(typ), name)
+	b.resources = append(b.resources, apitype.ResourceV3{URN: urn, Type: tokens.Type(typ), Parent: parent, Custom: custom})
+	return urn
+}
+
+func (b *deploymentBuilder) get(urn resource.URN) *apitype.ResourceV3 {
+	for i := range b.resources {
+		if b.resources[i].URN == urn {
+			return &b.resources[i]
+		}
+	}
+	return nil
+}
+
+func (b *deploymentBuilder) deployment() *apitype.DeploymentV3 {
+	return &apitype.DeploymentV3{Resources: b.resources}
+}
+
+// nestedStackDeployment registers the resources the converter creates for tests/test-data/nested-stack, with every
+// invariant holding.
+func nestedStackDeployment() (*deploymentBuilder, map[string]resource.URN) {
+	b := &deploymentBuilder{}
+	urns := map[string]resource.URN{}
+
+	provider := b.add("pulumi:providers:aws-native", "cdk-aws-native", "", true)
+	b.get(provider).ID = "provider-id"
+	b.get(provider).Inputs = map[string]any{"region": "us-west-2"}
+	ref := string(provider) + "::provider-id"
+
+	app := b.add("cdk:index:App", "app", "", false)
+	stack := b.add("cdk:construct:teststack", "app/teststack", app, false)
+	urns["bucket"] = b.add("aws-native:s3:Bucket", "bucket", stack, true)
+	b.get(urns["bucket"]).RetainOnDelete = true
+
+	nesty := b.add("cdk:construct:nested-stack/teststack/nesty", "app/teststack/nesty", stack, false)
+	nestedBucket := b.add("cdk:construct:aws-cdk-lib/aws_s3:Bucket", "app/teststack/nesty/bucket", nesty, false)
+	urns["nestedBucket"] = b.add("aws-native:s3:Bucket", "bucket43879C71", nestedBucket, true)
+
+	for _, urn := range urns {
+		b.get(urn).Provider = ref
+	}
+	return b, urns
+}
+
+func loadAssembly(t *testing.T) *assembly.Assembly {
+	t.Helper()
+	a, err := assembly.Load(nestedStackAssembly)
+	require.NoError(t, err)
+	return a
+}
+
+func TestCheckValid(t *testing.T) {
+	b, _ := nestedStackDeployment()
+	assert.Empty(t, Check(b.deployment(), loadAssembly(t), Options{}))
+	assert.Empty(t, Check(b.deployment(), nil, Options{}))
+}
+
+func TestCheckParent(t *testing.T) {
+	b, _ := nestedStackDeployment()
+	orphan := b.add("aws-native:ssm:Parameter", "orphan", "", true)
+	app := b.resources[1].URN
+	underApp := b.add("aws-native:ssm:Parameter", "instance-param", app, true)
+
+	violations := Check(b.deployment(), nil, Options{})
+	require.Len(t, violations, 2)
+	assert.Equal(t, orphan, violations[0].URN)
+	assert.Equal(t, RuleParent, violations[0].Rule)
+	assert.Equal(t, "resource is not parented under a cdk:index:App component", violations[0].Message)
+	assert.Equal(t, underApp, violations[1].URN)
+	assert.Equal(t, "resource is not parented under a cdk:construct:* component", violations[1].Message)
+
+	violations = Check(b.deployment(), nil, Options{AllowedOutsideConstructs: []string{"instance-param"}})
+	require.Len(t, violations, 1)
+	assert.Equal(t, orphan, violations[0].URN)
+}
+
+func TestCheckDuplicateLogicalID(t *testing.T) {
+	b, urns := nestedStackDeployment()
+	stack := b.get(urns["bucket"]).Parent
+	dup := b.add("aws:s3:BucketPolicy", "bucket", stack, true)
+
+	violations := Check(b.deployment(), nil, Options{})
+	require.Len(t, violations, 2)
+	for _, v := range violations {
+		assert.Equal(t, RuleDuplicateLogicalID, v.Rule)
+		assert.Equal(t, "logical ID bucket is used by 2 resources in stack teststack", v.Message)
+	}
+	assert.ElementsMatch(t, []resource.URN{urns["bucket"], dup}, []resource.URN{violations[0].URN, violations[1].URN})
+
+	// The same logical ID in a nested stack is a different resource.
+	b, urns = nestedStackDeployment()
+	b.add("aws-native:s3:Bucket", "bucket", b.get(urns["nestedBucket"]).Parent, true)
+	assert.Empty(t, Check(b.deployment(), nil, Options{}))
+}
+
+func TestCheckRetainOnDelete(t *testing.T) {
+	b, urns := nestedStackDeployment()
+	b.get(urns["bucket"]).RetainOnDelete = false
+	b.get(urns["nestedBucket"]).RetainOnDelete = true
+
+	violations := Check(b.deployment(), loadAssembly(t), Options{})
+	require.Len(t, violations, 2)
+	assert.Equal(t, Violation{
+		URN:     urns["bucket"],
+		Rule:    RuleRetainOnDelete,
+		Message: "RetainOnDelete is false but the template DeletionPolicy is Retain",
+	}, violations[0])
+	assert.Equal(t, Violation{
+		URN:     urns["nestedBucket"],
+		Rule:    RuleRetainOnDelete,
+		Message: "RetainOnDelete is true but the template DeletionPolicy is Delete",
+	}, violations[1])
+	assert.Contains(t, violations[0].String(), "::bucket: [retainOnDelete] RetainOnDelete is false")
+}
+
+func TestCheckRetainOnDeleteIgnoresUpdateReplacePolicy(t *testing.T) {
+	b, urns := nestedStackDeployment()
+	b.get(urns["bucket"]).RetainOnDelete = false
+	a := loadAssembly(t)
+	template := a.Stacks[0].Template
+	res := template.Resources["bucket"]
+	res.DeletionPolicy = ""
+	require.Equal(t, "Retain", res.UpdateReplacePolicy)
+	template.Resources["bucket"] = res
+
+	assert.Empty(t, Check(b.deployment(), a, Options{}))
+
+	b.get(urns["bucket"]).RetainOnDelete = true
+	assert.Equal(t, []Violation{{
+		URN:     urns["bucket"],
+		Rule:    RuleRetainOnDelete,
+		Message: "RetainOnDelete is set but the template has no DeletionPolicy",
+	}}, Check(b.deployment(), a, Options{}))
+}
+
+func TestCheckProvider(t *testing.T) {
+	b, urns := nestedStackDeployment()
+	def := b.add("pulumi:providers:aws-native", "default_1_0_0", "", true)
+	b.get(def).ID = "default-id"
+	b.get(def).Inputs = map[string]any{"region": "us-east-1"}
+	b.get(urns["bucket"]).Provider = string(def) + "::default-id"
+
+	violations := Check(b.deployment(), loadAssembly(t), Options{})
+	require.Len(t, violations, 1)
+	assert.Equal(t, urns["bucket"], violations[0].URN)
+	assert.Equal(t, RuleProvider, violations[0].Rule)
+	assert.Equal(t,
+		"stack teststack is pinned to us-west-2 but the resource uses default provider default_1_0_0 configured for us-east-1",
+		violations[0].Message)
+
+	// So is a default provider for the pinned region, whose region follows the stack configuration.
+	b.get(def).Inputs["region"] = "us-west-2"
+	violations = Check(b.deployment(), loadAssembly(t), Options{})
+	require.Len(t, violations, 1)
+	assert.Equal(t,
+		"stack teststack is pinned to us-west-2 but the resource uses default provider default_1_0_0 configured for us-west-2",
+		violations[0].Message)
+
+	// An explicit provider for another region is not.
+	b.get(urns["bucket"]).Provider = string(b.resources[0].URN) + "::provider-id"
+	b.resources[0].Inputs["region"] = "eu-west-1"
+	violations = Check(b.deployment(), loadAssembly(t), Options{})
+	require.Len(t, violations, 2)
+	assert.Equal(t,
+		"stack teststack is pinned to us-west-2 but the resource uses explicit provider cdk-aws-native configured for eu-west-1",
+		violations[0].Message)
+}
+
+func TestPinnedRegion(t *testing.T) {
+	assert.Equal(t, "us-west-2", pinnedRegion("aws://616138583583/us-west-2"))
+	assert.Equal(t, "us-west-2", pinnedRegion("aws://unknown-account/us-west-2"))
+	assert.Equal(t, "", pinnedRegion("aws://unknown-account/unknown-region"))
+	assert.Equal(t, "", pinnedRegion(""))
+}
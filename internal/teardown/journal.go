@@ -0,0 +1,127 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package teardown
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Statuses of a stack in the journal.
+const (
+	// Created stacks may hold resources.
+	Created = "created"
+	// Destroyed stacks are gone.
+	Destroyed = "destroyed"
+	// Failed stacks could not be destroyed.
+	Failed = "failed"
+)
+
+// Entry records the status of a test stack.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Test   string    `json:"test"`
+	Stack  string    `json:"stack"`
+	Status string    `json:"status"`
+	// Backend is the URL of the Pulumi backend that holds the stack's state.
+	Backend string `json:"backend,omitempty"`
+	// ProjectDir is the copy of the program the stack was deployed from.
+	ProjectDir string `json:"projectDir,omitempty"`
+	Region     string `json:"region,omitempty"`
+	// Prefix is the prefix of the stack's resource names, for finding them when the state is lost.
+	Prefix string `json:"prefix,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (e Entry) String() string {
+	s := fmt.Sprintf("%s (%s): stack %s is %s", e.Test, e.Time.Format(time.RFC3339), e.Stack, e.Status)
+	if e.Region != "" || e.Prefix != "" {
+		s += fmt.Sprintf(", resources in %s prefixed %q", e.Region, e.Prefix)
+	}
+	if e.Error != "" {
+		s += ": " + e.Error
+	}
+	return s
+}
+
+var journalMu sync.Mutex
+
+// Record appends an entry to the journal, a JSON object per line.
+func Record(path string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return errors.Join(err, f.Close())
+}
+
+// Load reads a journal. A missing journal is empty.
+func Load(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Leftovers returns the latest entry of every stack that was not destroyed, oldest first. Stacks are identified by
+// backend and name.
+func Leftovers(entries []Entry) []Entry {
+	type key struct{ backend, stack string }
+	latest := map[key]Entry{}
+	for _, e := range entries {
+		k := key{e.Backend, e.Stack}
+		if prev, ok := latest[k]; !ok || !e.Time.Before(prev.Time) {
+			latest[k] = e
+		}
+	}
+	var out []Entry
+	for _, e := range latest {
+		if e.Status != Destroyed {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
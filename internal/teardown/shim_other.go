@@ -0,0 +1,35 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix
+
+package teardown
+
+import "errors"
+
+// ShimSupported reports whether Pulumi commands can be run through the shim on this platform. Without it, a canceled
+// test stops before its next command rather than interrupting the running one.
+const ShimSupported = false
+
+// ShimEnv is not supported on this platform.
+func ShimEnv(bin, pidFile string) []string {
+	return nil
+}
+
+// RunShim does nothing on this platform.
+func RunShim() {}
+
+func interruptProcess(pid int) error {
+	return errors.New("interrupting processes is not supported on this platform")
+}
@@ -0,0 +1,69 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package teardown
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Environment of the shim.
+const (
+	shimBinEnvVar = "PULUMI_CDK_TEARDOWN_PULUMI"
+	shimPIDEnvVar = "PULUMI_CDK_TEARDOWN_PIDFILE"
+)
+
+// ShimSupported reports whether Pulumi commands can be run through the shim on this platform.
+const ShimSupported = true
+
+// ShimEnv returns the environment that makes a test binary act as the shim for the Pulumi CLI at bin.
+func ShimEnv(bin, pidFile string) []string {
+	return []string{shimBinEnvVar + "=" + bin, shimPIDEnvVar + "=" + pidFile}
+}
+
+// RunShim turns the process into the Pulumi CLI when it was started as the shim, and returns otherwise. Test
+// binaries call it first thing in TestMain and are set as ProgramTestOptions.Bin. The shim writes its process id
+// for the Watcher and moves to its own process group, so that a Ctrl-C in the terminal reaches Pulumi only once,
+// through the Watcher: a second SIGINT would make Pulumi exit without saving its state.
+func RunShim() {
+	bin := os.Getenv(shimBinEnvVar)
+	if bin == "" {
+		return
+	}
+	err := os.WriteFile(os.Getenv(shimPIDEnvVar), []byte(strconv.Itoa(os.Getpid())), 0o600)
+	if err == nil {
+		err = syscall.Setpgid(0, 0)
+	}
+	if err == nil {
+		var env []string
+		for _, kv := range os.Environ() {
+			if !strings.HasPrefix(kv, shimBinEnvVar+"=") && !strings.HasPrefix(kv, shimPIDEnvVar+"=") {
+				env = append(env, kv)
+			}
+		}
+		err = syscall.Exec(bin, append([]string{bin}, os.Args[1:]...), env)
+	}
+	fmt.Fprintf(os.Stderr, "pulumi shim: %v\n", err)
+	os.Exit(255)
+}
+
+func interruptProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGINT)
+}
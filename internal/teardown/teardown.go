@@ -0,0 +1,172 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package teardown makes sure acceptance tests destroy their stacks before `go test -timeout` or a signal kills the
+// test process. A Watcher cancels the running Pulumi command once only the teardown budget is left before the test's
+// deadline, or when the process is interrupted, so that the test moves on to `pulumi destroy` while there is still
+// time. Stacks that could not be destroyed are recorded in a journal for later cleanup.
+package teardown
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// BudgetEnvVar overrides DefaultBudget, as a Go duration.
+const BudgetEnvVar = "PULUMI_CDK_TEARDOWN_BUDGET"
+
+// DefaultBudget is the time reserved for teardown at the end of a test. Destroying an EKS cluster alone takes
+// about 15 minutes.
+const DefaultBudget = 20 * time.Minute
+
+// AbortMargin is how long before the deadline a running teardown command is interrupted as well, so that Pulumi
+// saves its state before the process is killed.
+const AbortMargin = time.Minute
+
+// Budget returns the teardown budget from BudgetEnvVar, or DefaultBudget.
+func Budget() (time.Duration, error) {
+	s := os.Getenv(BudgetEnvVar)
+	if s == "" {
+		return DefaultBudget, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", BudgetEnvVar, err)
+	}
+	return d, nil
+}
+
+// Reserve is the part of the time left until deadline that goes to teardown: the budget, but never more than half
+// of the time left, so that short `-timeout`s still leave room for the test itself.
+func Reserve(now, deadline time.Time, budget time.Duration) time.Duration {
+	return min(budget, deadline.Sub(now)/2)
+}
+
+var (
+	signalOnce  sync.Once
+	interrupted = make(chan struct{})
+	received    os.Signal
+)
+
+// watchSignals turns the first SIGINT or SIGTERM into closing interrupted. Later signals get the default behaviour
+// again, so a second Ctrl-C still kills the tests right away.
+func watchSignals() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		received = <-ch
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		fmt.Fprintf(os.Stderr, "received %s, tearing down the running tests; signal again to exit right away\n", received)
+		close(interrupted)
+	}()
+}
+
+// Watcher watches the deadline of a test and the signals of the process, and interrupts the Pulumi command the test
+// is running through the shim, see RunShim.
+type Watcher struct {
+	pidFile  string
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	reason  string
+	running bool
+}
+
+// Watch starts watching. The zero deadline means the test has none. pidFile is where the shim writes the process
+// id of the running Pulumi command.
+func Watch(deadline time.Time, budget time.Duration, pidFile string) *Watcher {
+	signalOnce.Do(watchSignals)
+	w := &Watcher{pidFile: pidFile, stop: make(chan struct{})}
+
+	var cancelAt, abortAt <-chan time.Time
+	if !deadline.IsZero() {
+		reserve := Reserve(time.Now(), deadline, budget)
+		cancelAt = time.After(time.Until(deadline.Add(-reserve)))
+		abortAt = time.After(time.Until(deadline.Add(-min(AbortMargin, reserve/4))))
+	}
+	go func() {
+		select {
+		case <-cancelAt:
+			w.cancel(fmt.Sprintf("the test deadline is %s away", time.Until(deadline).Round(time.Second)))
+		case <-interrupted:
+			w.cancel(fmt.Sprintf("received %s", received))
+		case <-w.stop:
+			return
+		}
+		select {
+		case <-abortAt:
+			w.interrupt()
+		case <-w.stop:
+		}
+	}()
+	return w
+}
+
+func (w *Watcher) cancel(reason string) {
+	w.mu.Lock()
+	w.reason = reason
+	w.mu.Unlock()
+	w.interrupt()
+}
+
+// interrupt sends SIGINT to the running command, which makes Pulumi cancel the operation gracefully.
+func (w *Watcher) interrupt() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.running {
+		return
+	}
+	data, err := os.ReadFile(w.pidFile)
+	if err != nil {
+		return
+	}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+		_ = interruptProcess(pid)
+	}
+}
+
+// Canceled reports why the test was canceled, if it was. A canceled test must only tear down.
+func (w *Watcher) Canceled() (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reason, w.reason != ""
+}
+
+// Started marks a command as running, so that cancellation interrupts it.
+func (w *Watcher) Started() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running = true
+}
+
+// Finished marks the command as done. The process id it left behind is removed, so that a reused id is never
+// interrupted.
+func (w *Watcher) Finished() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.running = false
+	_ = os.Remove(w.pidFile)
+}
+
+// Stop stops watching.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
@@ -0,0 +1,126 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package teardown
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	RunShim()
+	os.Exit(m.Run())
+}
+
+func TestBudget(t *testing.T) {
+	t.Setenv(BudgetEnvVar, "")
+	b, err := Budget()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBudget, b)
+
+	t.Setenv(BudgetEnvVar, "45m")
+	b, err = Budget()
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Minute, b)
+
+	t.Setenv(BudgetEnvVar, "soon")
+	_, err = Budget()
+	assert.Error(t, err)
+
+	now := time.Now()
+	assert.Equal(t, 20*time.Minute, Reserve(now, now.Add(2*time.Hour), 20*time.Minute))
+	// The default `go test -timeout` of 10 minutes leaves half for the test.
+	assert.Equal(t, 5*time.Minute, Reserve(now, now.Add(10*time.Minute), 20*time.Minute))
+}
+
+func TestWatcherInterruptsCommand(t *testing.T) {
+	if !ShimSupported {
+		t.Skip("the shim is not supported on this platform")
+	}
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	// Half of the time left is reserved for teardown, so the command is canceled after about a second.
+	w := Watch(time.Now().Add(2*time.Second), time.Hour, pidFile)
+	defer w.Stop()
+
+	cmd := exec.Command(os.Args[0], "-c", `trap "exit 42" INT; while :; do sleep 0.01; done`)
+	cmd.Env = append(os.Environ(), ShimEnv("/bin/sh", pidFile)...)
+	cmd.WaitDelay = 10 * time.Second
+	w.Started()
+	require.NoError(t, cmd.Start())
+	timer := time.AfterFunc(10*time.Second, func() { _ = cmd.Process.Kill() })
+	defer timer.Stop()
+	err := cmd.Wait()
+	w.Finished()
+
+	var exit *exec.ExitError
+	require.True(t, errors.As(err, &exit), "%v", err)
+	assert.Equal(t, 42, exit.ExitCode(), "the command was interrupted")
+	reason, canceled := w.Canceled()
+	assert.True(t, canceled)
+	assert.Contains(t, reason, "deadline")
+	assert.NoFileExists(t, pidFile)
+}
+
+func TestWatcherWithoutDeadline(t *testing.T) {
+	w := Watch(time.Time{}, time.Hour, filepath.Join(t.TempDir(), "pid"))
+	time.Sleep(10 * time.Millisecond)
+	w.Stop()
+	w.Stop()
+	_, canceled := w.Canceled()
+	assert.False(t, canceled)
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	record := func(minutes int, stack, status string) {
+		require.NoError(t, Record(path, Entry{
+			Time:    start.Add(time.Duration(minutes) * time.Minute),
+			Test:    "Test" + stack,
+			Stack:   stack,
+			Status:  status,
+			Backend: "file:///state",
+			Region:  "us-east-2",
+			Prefix:  "a1234",
+		}))
+	}
+	record(0, "eks", Created)
+	record(0, "bucket", Created)
+	record(1, "alb", Created)
+	record(5, "bucket", Destroyed)
+	record(9, "alb", Failed)
+
+	entries, err = Load(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+
+	leftovers := Leftovers(entries)
+	require.Len(t, leftovers, 2)
+	assert.Equal(t, "eks", leftovers[0].Stack)
+	assert.Equal(t, Failed, leftovers[1].Status)
+	assert.Equal(t, `Testeks (2026-10-01T12:00:00Z): stack eks is created, resources in us-east-2 prefixed "a1234"`,
+		leftovers[0].String())
+}
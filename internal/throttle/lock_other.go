@@ -0,0 +1,54 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix
+
+package throttle
+
+import (
+	"os"
+	"sync"
+)
+
+// Without flock(2), slots are only exclusive within a process.
+var (
+	heldMu sync.Mutex
+	held   = map[string]bool{}
+)
+
+type lock struct {
+	path string
+}
+
+func tryLock(path string) (*lock, bool, error) {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	if held[path] {
+		return nil, false, nil
+	}
+	if f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644); err != nil {
+		return nil, false, err
+	} else if err := f.Close(); err != nil {
+		return nil, false, err
+	}
+	held[path] = true
+	return &lock{path: path}, true, nil
+}
+
+func (l *lock) unlock() error {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	delete(held, l.path)
+	return stamp(l.path)
+}
@@ -0,0 +1,49 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package throttle
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lock is an exclusive flock(2) on a file. Locks belong to the open file, so two goroutines of the same process
+// exclude each other just like two processes do, and the kernel releases the lock of a process that dies.
+type lock struct {
+	path string
+	f    *os.File
+}
+
+func tryLock(path string) (*lock, bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return &lock{path: path, f: f}, true, nil
+}
+
+func (l *lock) unlock() error {
+	return errors.Join(stamp(l.path), syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN), l.f.Close())
+}
@@ -0,0 +1,168 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package throttle limits how many acceptance tests use a rate limited AWS API at the same time. API Gateway's
+// DeleteRestApi, for example, allows one call per 30 seconds per account, so tests that deploy REST APIs fail with
+// throttling errors when they update or destroy concurrently. Limits apply across goroutines and across `go test`
+// processes on the same machine, which hold a slot by locking a file.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Throttled services. Keys are free-form, so a CloudFormation resource type works as well.
+const (
+	// APIGateway covers REST APIs, whose DeleteRestApi allows one call per 30 seconds.
+	APIGateway = "apigateway"
+)
+
+// DirEnvVar overrides the directory of the lock files. Processes only limit each other when they use the same
+// directory.
+const DirEnvVar = "PULUMI_CDK_TEST_LOCKS"
+
+// Dir returns the directory of the lock files, `$TMPDIR/pulumi-cdk-test-locks` by default.
+func Dir() string {
+	if dir := os.Getenv(DirEnvVar); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "pulumi-cdk-test-locks")
+}
+
+// DefaultPoll is how often Acquire retries a busy key.
+const DefaultPoll = time.Second
+
+// Semaphore hands out a limited number of slots per key.
+type Semaphore struct {
+	dir string
+	// Limits is the number of slots of a key. Keys without a limit have a single slot.
+	Limits map[string]int
+	// Cooldowns is how long a slot stays unused after it was released, for APIs that allow one call per period.
+	Cooldowns map[string]time.Duration
+	Poll      time.Duration
+}
+
+// New returns a semaphore whose lock files live in dir, with the cooldowns of the known throttled services.
+func New(dir string) *Semaphore {
+	return &Semaphore{
+		dir:       dir,
+		Limits:    map[string]int{},
+		Cooldowns: map[string]time.Duration{APIGateway: 30 * time.Second},
+		Poll:      DefaultPoll,
+	}
+}
+
+func (s *Semaphore) limit(key string) int {
+	if n := s.Limits[key]; n > 0 {
+		return n
+	}
+	return 1
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+func (s *Semaphore) slotPath(key string, slot int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.%d.lock", unsafeChars.ReplaceAllString(key, "_"), slot))
+}
+
+// Acquire takes a slot of every key, waiting until one is free or ctx is done. Keys are taken in sorted order so that
+// tests holding several keys cannot deadlock. The returned function releases the slots.
+func (s *Semaphore) Acquire(ctx context.Context, keys ...string) (func() error, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, err
+	}
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	var held []*lock
+	release := func() error {
+		var errs []error
+		for i := len(held) - 1; i >= 0; i-- {
+			errs = append(errs, held[i].unlock())
+		}
+		held = nil
+		return errors.Join(errs...)
+	}
+	for _, key := range keys {
+		l, err := s.acquire(ctx, key)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("acquiring %s: %w", key, err), release())
+		}
+		held = append(held, l)
+		if err := s.cooldown(ctx, key, l); err != nil {
+			return nil, errors.Join(fmt.Errorf("acquiring %s: %w", key, err), release())
+		}
+	}
+	return release, nil
+}
+
+// cooldown waits until the key's cooldown since the last release of the slot has passed. Lock files hold the time
+// of their last release.
+func (s *Semaphore) cooldown(ctx context.Context, key string, l *lock) error {
+	period := s.Cooldowns[key]
+	if period <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	released, err := time.Parse(time.RFC3339Nano, string(data))
+	if err != nil {
+		// A new lock file.
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(released.Add(period))):
+		return nil
+	}
+}
+
+func (s *Semaphore) acquire(ctx context.Context, key string) (*lock, error) {
+	poll := s.Poll
+	if poll <= 0 {
+		poll = DefaultPoll
+	}
+	for {
+		for slot := 0; slot < s.limit(key); slot++ {
+			l, ok, err := tryLock(s.slotPath(key, slot))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return l, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// stamp records the release time in a lock file that is still locked.
+func stamp(path string) error {
+	return os.WriteFile(path, []byte(time.Now().UTC().Format(time.RFC3339Nano)), 0o644)
+}
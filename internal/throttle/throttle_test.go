@@ -0,0 +1,155 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttle
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSemaphore(t *testing.T) *Semaphore {
+	s := New(t.TempDir())
+	s.Poll = 5 * time.Millisecond
+	return s
+}
+
+// maxConcurrent runs n goroutines that each hold keys for a moment and returns how many held them at once.
+func maxConcurrent(t *testing.T, s *Semaphore, n int, keys ...string) int32 {
+	var current, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.Acquire(context.Background(), keys...)
+			if !assert.NoError(t, err) {
+				return
+			}
+			c := current.Add(1)
+			for p := peak.Load(); c > p && !peak.CompareAndSwap(p, c); p = peak.Load() {
+			}
+			time.Sleep(20 * time.Millisecond)
+			current.Add(-1)
+			assert.NoError(t, release())
+		}()
+	}
+	wg.Wait()
+	return peak.Load()
+}
+
+func TestAcquire(t *testing.T) {
+	s := newSemaphore(t)
+	s.Cooldowns = nil
+	assert.Equal(t, int32(1), maxConcurrent(t, s, 4, APIGateway))
+
+	s.Limits["AWS::Lambda::Function"] = 2
+	assert.Equal(t, int32(2), maxConcurrent(t, s, 4, "AWS::Lambda::Function"))
+
+	// Overlapping key sets in different orders must not deadlock.
+	var wg sync.WaitGroup
+	for _, keys := range [][]string{{"a", "b"}, {"b", "a"}, {"b", "a", "a"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			maxConcurrent(t, s, 2, keys...)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAcquireCanceled(t *testing.T) {
+	s := newSemaphore(t)
+	release, err := s.Acquire(context.Background(), "a", "b")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = s.Acquire(ctx, "b")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, release())
+	release, err = s.Acquire(context.Background(), "b")
+	require.NoError(t, err)
+	require.NoError(t, release())
+}
+
+func TestCooldown(t *testing.T) {
+	s := newSemaphore(t)
+	s.Cooldowns["slow"] = 100 * time.Millisecond
+
+	start := time.Now()
+	release, err := s.Acquire(context.Background(), "slow")
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "a new slot has no cooldown")
+	require.NoError(t, release())
+
+	released := time.Now()
+	release, err = s.Acquire(context.Background(), "slow")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(released), 90*time.Millisecond)
+	require.NoError(t, release())
+}
+
+const helperEnv = "THROTTLE_TEST_HOLD_DIR"
+
+// TestHelperProcess holds the APIGateway key for a while when run by TestAcrossProcesses.
+func TestHelperProcess(t *testing.T) {
+	dir := os.Getenv(helperEnv)
+	if dir == "" {
+		t.Skip("only run by TestAcrossProcesses")
+	}
+	s := New(dir)
+	s.Cooldowns = nil
+	release, err := s.Acquire(context.Background(), APIGateway)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "held"), nil, 0o600))
+	time.Sleep(300 * time.Millisecond)
+	require.NoError(t, release())
+}
+
+func TestAcrossProcesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("slots are only exclusive within a process on Windows")
+	}
+	s := newSemaphore(t)
+	s.Cooldowns = nil
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), helperEnv+"="+s.dir)
+	require.NoError(t, cmd.Start())
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(s.dir, "held"))
+		return err == nil
+	}, 10*time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := s.Acquire(ctx, APIGateway)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the other process holds the slot")
+
+	release, err := s.Acquire(context.Background(), APIGateway)
+	require.NoError(t, err)
+	require.NoError(t, release())
+	require.NoError(t, cmd.Wait())
+}
@@ -0,0 +1,256 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/artifacts"
+	"github.com/pulumi/pulumi-cdk/internal/junit"
+)
+
+// Event is a line of `go test -json` output, as also written by `gotestsum --jsonfile`.
+type Event struct {
+	Time    time.Time `json:"Time"`
+	Action  string    `json:"Action"`
+	Package string    `json:"Package"`
+	Test    string    `json:"Test,omitempty"`
+	Output  string    `json:"Output,omitempty"`
+	Elapsed float64   `json:"Elapsed,omitempty"`
+}
+
+// Outcomes of a test run.
+const (
+	Pass = "pass"
+	Fail = "fail"
+	Skip = "skip"
+)
+
+// TestRun is the outcome and output of a test. A package that failed outside of any test, e.g. because it did not
+// build, is a TestRun without Test.
+type TestRun struct {
+	Package string
+	Test    string
+	Outcome string
+	Elapsed float64
+	Output  string
+}
+
+// ParseGoTest reads `go test -json` output. Lines that are not JSON events are ignored.
+func ParseGoTest(r io.Reader) ([]TestRun, error) {
+	type key struct{ pkg, test string }
+	runs := map[key]*TestRun{}
+	var order []key
+	get := func(k key) *TestRun {
+		if run, ok := runs[k]; ok {
+			return run
+		}
+		run := &TestRun{Package: k.pkg, Test: k.test}
+		runs[k] = run
+		order = append(order, k)
+		return run
+	}
+	outputs := map[key]*strings.Builder{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Action == "" {
+			continue
+		}
+		k := key{e.Package, e.Test}
+		switch e.Action {
+		case "output", "build-output":
+			if outputs[k] == nil {
+				outputs[k] = &strings.Builder{}
+			}
+			outputs[k].WriteString(e.Output)
+		case Pass, Fail, Skip:
+			run := get(k)
+			run.Outcome = e.Action
+			run.Elapsed = e.Elapsed
+		case "build-fail":
+			get(key{e.Package, ""}).Outcome = Fail
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var out []TestRun
+	failedTests := map[string]bool{}
+	for _, k := range order {
+		if k.test != "" && runs[k].Outcome == Fail {
+			failedTests[k.pkg] = true
+		}
+	}
+	for _, k := range order {
+		run := runs[k]
+		if outputs[k] != nil {
+			run.Output = outputs[k].String()
+		}
+		// A package fails whenever one of its tests does; only report it when nothing else explains the failure.
+		if k.test == "" && (run.Outcome != Fail || failedTests[k.pkg]) {
+			continue
+		}
+		out = append(out, *run)
+	}
+	return out, nil
+}
+
+// Result is a test run with the classification of its failure.
+type Result struct {
+	Package        string          `json:"package"`
+	Test           string          `json:"test,omitempty"`
+	Outcome        string          `json:"outcome"`
+	Elapsed        float64         `json:"elapsed"`
+	Classification *Classification `json:"classification,omitempty"`
+	// ArtifactDir is the artifact directory the test pointed to, see internal/artifacts.
+	ArtifactDir string `json:"artifactDir,omitempty"`
+
+	output string
+}
+
+// Report is the triaged outcome of a test run.
+type Report struct {
+	Results []Result `json:"results"`
+	// Categories counts the failed tests per category.
+	Categories map[string]int `json:"categories"`
+}
+
+// Triage classifies every failed test. A test is classified on its own output, the output of its subtests and the
+// command logs in its artifact directory, which is where the Pulumi CLI output of the acceptance tests ends up.
+func Triage(runs []TestRun, rules *Rules) Report {
+	report := Report{Categories: map[string]int{}}
+	for _, run := range runs {
+		result := Result{
+			Package: run.Package,
+			Test:    run.Test,
+			Outcome: run.Outcome,
+			Elapsed: run.Elapsed,
+			output:  run.Output,
+		}
+		if run.Outcome == Fail {
+			var text strings.Builder
+			for _, other := range runs {
+				if other.Package == run.Package && (other.Test == run.Test || run.Test == "" ||
+					strings.HasPrefix(other.Test, run.Test+"/")) {
+					text.WriteString(other.Output)
+				}
+			}
+			result.ArtifactDir = artifactDir(run.Output)
+			text.WriteString(readLogs(result.ArtifactDir))
+			c := rules.Classify(text.String())
+			result.Classification = &c
+			report.Categories[c.Category]++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+func artifactDir(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if dir, ok := artifacts.ParsePointer(line); ok {
+			return dir
+		}
+	}
+	return ""
+}
+
+// readLogs returns the concatenated command logs in dir, oldest first.
+func readLogs(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	logs, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return ""
+	}
+	sort.Strings(logs)
+	var text strings.Builder
+	for _, log := range logs {
+		if data, err := os.ReadFile(log); err == nil {
+			text.Write(data)
+			text.WriteString("\n")
+		}
+	}
+	return text.String()
+}
+
+// failureTail is how many lines of a failed test's output go into the JUnit report.
+const failureTail = 100
+
+// JUnit converts the report to JUnit test suites, one per package. The failure type is the category.
+func (r Report) JUnit() []junit.TestSuite {
+	var suites []junit.TestSuite
+	index := map[string]int{}
+	for _, res := range r.Results {
+		i, ok := index[res.Package]
+		if !ok {
+			i = len(suites)
+			index[res.Package] = i
+			suites = append(suites, junit.TestSuite{Name: res.Package})
+		}
+		name := res.Test
+		if name == "" {
+			name = "(package)"
+		}
+		c := junit.TestCase{Name: name, Classname: res.Package, Time: res.Elapsed}
+		switch res.Outcome {
+		case Fail:
+			cl := res.Classification
+			c.Failure = &junit.Failure{Type: cl.Category, Message: cl.Evidence, Body: tail(res.output, failureTail)}
+			c.AddProperty("kind", cl.Kind)
+			for _, issue := range cl.Issues {
+				c.AddProperty("issue", issue)
+			}
+			if res.ArtifactDir != "" {
+				c.AddProperty("artifacts", res.ArtifactDir)
+			}
+		case Skip:
+			c.Skipped = &junit.Skipped{Message: strings.TrimSpace(lastLine(res.output))}
+		}
+		suites[i].Add(c)
+	}
+	return suites
+}
+
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastLine returns the last line of output before the `--- SKIP` line, which is the skip message.
+func lastLine(s string) string {
+	var last string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" && !strings.Contains(line, "--- SKIP") {
+			last = line
+		}
+	}
+	return last
+}
@@ -0,0 +1,134 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package triage sorts failed acceptance tests into categories such as throttling or assertion failures by matching
+// their output against a rules file, so that flaky infrastructure can be told apart from product bugs.
+package triage
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Kinds of failure categories.
+const (
+	// KindProduct failures are bugs in @pulumi/cdk, the examples or the tests.
+	KindProduct = "product"
+	// KindUpstream failures are limitations of aws-native or Cloud Control.
+	KindUpstream = "upstream"
+	// KindInfrastructure failures are caused by the AWS account or API and usually pass on a retry.
+	KindInfrastructure = "infrastructure"
+	// KindUnknown is used for failures that no rule matched.
+	KindUnknown = "unknown"
+)
+
+// Unclassified is the category of failures that no rule matched.
+const Unclassified = "unclassified"
+
+// Rule maps output matching any of its patterns to a category.
+type Rule struct {
+	Category    string   `json:"category"`
+	Kind        string   `json:"kind"`
+	Description string   `json:"description,omitempty"`
+	Patterns    []string `json:"patterns"`
+	// Issues are links to known issues behind the category.
+	Issues []string `json:"issues,omitempty"`
+
+	compiled []*regexp.Regexp
+}
+
+// Rules are tried in order; the first rule with a matching line wins. Rules for infrastructure problems therefore
+// come before the assertion failures they cause.
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+//go:embed rules.json
+var defaultRules []byte
+
+// DefaultRules returns the rules checked in next to this package.
+func DefaultRules() (*Rules, error) {
+	return parseRules(defaultRules)
+}
+
+// LoadRules reads a rules file.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := parseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func parseRules(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i := range rules.Rules {
+		r := &rules.Rules[i]
+		if r.Category == "" || len(r.Patterns) == 0 {
+			return nil, fmt.Errorf("rule %d needs a category and at least one pattern", i)
+		}
+		switch r.Kind {
+		case KindProduct, KindUpstream, KindInfrastructure:
+		default:
+			return nil, fmt.Errorf("rule %q has unknown kind %q", r.Category, r.Kind)
+		}
+		for _, p := range r.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: %w", r.Category, err)
+			}
+			r.compiled = append(r.compiled, re)
+		}
+	}
+	return &rules, nil
+}
+
+// Classification is the category of a failure and the line of output that decided it.
+type Classification struct {
+	Category string   `json:"category"`
+	Kind     string   `json:"kind"`
+	Evidence string   `json:"evidence,omitempty"`
+	Issues   []string `json:"issues,omitempty"`
+}
+
+// Classify returns the category of the first rule that matches a line of output.
+func (rs *Rules) Classify(output string) Classification {
+	lines := strings.Split(output, "\n")
+	for _, r := range rs.Rules {
+		for _, line := range lines {
+			for _, re := range r.compiled {
+				if re.MatchString(line) {
+					return Classification{
+						Category: r.Category,
+						Kind:     r.Kind,
+						Evidence: strings.TrimSpace(line),
+						Issues:   r.Issues,
+					}
+				}
+			}
+		}
+	}
+	return Classification{Category: Unclassified, Kind: KindUnknown}
+}
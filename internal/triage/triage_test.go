@@ -0,0 +1,140 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triage
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi-cdk/internal/artifacts"
+	"github.com/pulumi/pulumi-cdk/internal/junit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadDefaultRules(t *testing.T) *Rules {
+	t.Helper()
+	rules, err := DefaultRules()
+	require.NoError(t, err)
+	return rules
+}
+
+func TestClassify(t *testing.T) {
+	rules := loadDefaultRules(t)
+	for _, tc := range []struct {
+		output   string
+		category string
+		kind     string
+	}{
+		{"error: operation CREATE failed with \"Throttling\": Rate exceeded", "throttling", KindInfrastructure},
+		{"index.ts(3,1): error TS2304: Cannot find name 'foo'.", "compile-error", KindProduct},
+		{"The role defined for the function cannot be assumed by Lambda.", "iam-propagation", KindInfrastructure},
+		{"api error DependencyViolation: resource sg-123 has a dependent object", "dependency-violation",
+			KindInfrastructure},
+		{"UnsupportedActionException: Resource type AWS::Foo::Bar does not support LIST action", "cloudcontrol-unsupported",
+			KindUpstream},
+		{"Error Trace:\texamples_test.go:12\n\tError: Not equal", "assertion-failure", KindProduct},
+		// The throttling caused the assertion failure.
+		{"Error Trace:\texamples_test.go:12\nerror: TooManyRequestsException", "throttling", KindInfrastructure},
+		{"something else entirely", Unclassified, KindUnknown},
+	} {
+		c := rules.Classify(tc.output)
+		assert.Equal(t, tc.category, c.Category, tc.output)
+		assert.Equal(t, tc.kind, c.Kind, tc.output)
+	}
+}
+
+func TestLoadRulesValidates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"rules":[{"category":"x","kind":"flaky","patterns":["a"]}]}`), 0o644))
+	_, err := LoadRules(path)
+	assert.ErrorContains(t, err, `rule "x" has unknown kind "flaky"`)
+}
+
+func goTestJSON(t *testing.T, events ...Event) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		buf.Write(append(data, '\n'))
+	}
+	return &buf
+}
+
+func TestTriage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "03-up.log"),
+		[]byte("error: creating bucket: BucketAlreadyExists\nAlreadyExistsException: bucket\n"), 0o644))
+
+	const pkg = "github.com/pulumi/pulumi-cdk/examples"
+	input := goTestJSON(t,
+		Event{Action: "run", Package: pkg, Test: "TestBucket"},
+		Event{Action: "output", Package: pkg, Test: "TestBucket", Output: "    program.go:10: " +
+			artifacts.Pointer(dir) + "\n"},
+		Event{Action: "fail", Package: pkg, Test: "TestBucket", Elapsed: 61.5},
+		Event{Action: "output", Package: pkg, Test: "TestSkipped", Output: "    x_test.go:3: Skipping test due to throttling\n"},
+		Event{Action: "output", Package: pkg, Test: "TestSkipped", Output: "--- SKIP: TestSkipped (0.00s)\n"},
+		Event{Action: "skip", Package: pkg, Test: "TestSkipped"},
+		Event{Action: "output", Package: pkg, Test: "TestParent/sub", Output: "Error Trace:\tx_test.go:5\n"},
+		Event{Action: "fail", Package: pkg, Test: "TestParent/sub", Elapsed: 1},
+		Event{Action: "fail", Package: pkg, Test: "TestParent", Elapsed: 1},
+		Event{Action: "pass", Package: pkg, Test: "TestOK", Elapsed: 2},
+		Event{Action: "fail", Package: pkg, Elapsed: 70},
+	)
+	// Non-JSON lines, e.g. from a build, are ignored.
+	input.WriteString("# github.com/pulumi/pulumi-cdk/examples\n")
+
+	runs, err := ParseGoTest(input)
+	require.NoError(t, err)
+	require.Len(t, runs, 5, "the package failure is explained by its tests")
+
+	report := Triage(runs, loadDefaultRules(t))
+	assert.Equal(t, map[string]int{"leftover-resource": 1, "assertion-failure": 2}, report.Categories)
+	bucket := report.Results[0]
+	assert.Equal(t, dir, bucket.ArtifactDir)
+	assert.Equal(t, "AlreadyExistsException: bucket", bucket.Classification.Evidence)
+	assert.Equal(t, "assertion-failure", report.Results[3].Classification.Category, "TestParent inherits its subtest's output")
+
+	suites := report.JUnit()
+	require.Len(t, suites, 1)
+	assert.Equal(t, 5, suites[0].Tests)
+	assert.Equal(t, 3, suites[0].Failures)
+	assert.Equal(t, 1, suites[0].Skipped)
+	assert.Equal(t, "Skipping test due to throttling", strings.TrimPrefix(suites[0].Cases[1].Skipped.Message,
+		"x_test.go:3: "))
+	assert.Equal(t, "leftover-resource", suites[0].Cases[0].Failure.Type)
+
+	var xml bytes.Buffer
+	require.NoError(t, junit.Write(&xml, "examples", suites))
+	assert.Contains(t, xml.String(), `<testsuites name="examples" tests="5" failures="3" skipped="1"`)
+	assert.Contains(t, xml.String(), `<property name="kind" value="infrastructure"></property>`)
+}
+
+func TestBuildFailure(t *testing.T) {
+	const pkg = "github.com/pulumi/pulumi-cdk/examples"
+	runs, err := ParseGoTest(goTestJSON(t,
+		Event{Action: "build-output", Package: pkg, Output: "examples_test.go:3:2: undefined: foo [build failed]\n"},
+		Event{Action: "build-fail", Package: pkg},
+	))
+	require.NoError(t, err)
+	report := Triage(runs, loadDefaultRules(t))
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "compile-error", report.Results[0].Classification.Category)
+}
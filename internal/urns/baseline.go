@@ -0,0 +1,67 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package urns
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// LoadPlan returns the URNs of every resource in a plan file written by `pulumi preview --save-plan`. A preview is
+// enough to learn the URNs, so nothing has to be deployed to compare two library versions.
+func LoadPlan(path string) ([]resource.URN, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan apitype.DeploymentPlanV1
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	out := make([]resource.URN, 0, len(plan.ResourcePlans))
+	for urn := range plan.ResourcePlans {
+		out = append(out, urn)
+	}
+	sortURNs(out)
+	return out, nil
+}
+
+// PinDependency rewrites the version of an npm dependency in package.json, e.g. to install the published baseline of
+// `@pulumi/cdk` instead of linking the local build.
+func PinDependency(packageJSON, name, version string) error {
+	data, err := os.ReadFile(packageJSON)
+	if err != nil {
+		return err
+	}
+	var pkg map[string]any
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return fmt.Errorf("parsing %s: %w", packageJSON, err)
+	}
+	deps, _ := pkg["dependencies"].(map[string]any)
+	if deps == nil {
+		deps = map[string]any{}
+		pkg["dependencies"] = deps
+	}
+	deps[name] = version
+	out, err := json.MarshalIndent(pkg, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(packageJSON, append(out, '\n'), 0o644)
+}
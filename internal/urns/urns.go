@@ -0,0 +1,222 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package urns compares the URNs a program registers with two versions of the library. Construct path handling,
+// `typeFromFqn` and nested stack parenting all end up in URNs, and a changed URN makes Pulumi replace the resource.
+package urns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// Key identifies a resource independently of the stack and project it was deployed to, e.g.
+// `cdk:index:App$aws-native:s3:Bucket::bucket`.
+func Key(urn resource.URN) string {
+	return string(urn.QualifiedType()) + "::" + urn.Name()
+}
+
+// Diff lists the URNs that only one of two runs registered.
+type Diff struct {
+	Removed []resource.URN `json:"removed"`
+	Added   []resource.URN `json:"added"`
+}
+
+// Empty reports whether both runs registered the same resources.
+func (d Diff) Empty() bool {
+	return len(d.Removed) == 0 && len(d.Added) == 0
+}
+
+// Compare diffs the URNs of a baseline run against the URNs of the current run by Key.
+func Compare(baseline, current []resource.URN) Diff {
+	before := keySet(baseline)
+	after := keySet(current)
+	d := Diff{Removed: []resource.URN{}, Added: []resource.URN{}}
+	for _, urn := range baseline {
+		if !after[Key(urn)] {
+			d.Removed = append(d.Removed, urn)
+		}
+	}
+	for _, urn := range current {
+		if !before[Key(urn)] {
+			d.Added = append(d.Added, urn)
+		}
+	}
+	sortURNs(d.Removed)
+	sortURNs(d.Added)
+	return d
+}
+
+func keySet(urns []resource.URN) map[string]bool {
+	set := make(map[string]bool, len(urns))
+	for _, urn := range urns {
+		set[Key(urn)] = true
+	}
+	return set
+}
+
+func sortURNs(urns []resource.URN) {
+	sort.Slice(urns, func(i, j int) bool { return urns[i] < urns[j] })
+}
+
+// Alias proposes registering the resource at To with an alias to From, so that the upgrade moves the existing
+// resource instead of replacing it.
+type Alias struct {
+	From   resource.URN `json:"from"`
+	To     resource.URN `json:"to"`
+	Reason string       `json:"reason"`
+}
+
+// ProposeAliases pairs removed URNs with added ones. Pairs are only proposed when they are unambiguous: the resource
+// kept its name and type but moved to another parent, kept its name but changed type, or is the only resource of its
+// type under an unchanged parent that was renamed.
+func ProposeAliases(d Diff) []Alias {
+	removed := append([]resource.URN{}, d.Removed...)
+	added := append([]resource.URN{}, d.Added...)
+	aliases := []Alias{}
+
+	match := func(reason string, key func(resource.URN) string) {
+		from := groupBy(removed, key)
+		to := groupBy(added, key)
+		matched := map[resource.URN]bool{}
+		for k, olds := range from {
+			news := to[k]
+			if len(olds) != 1 || len(news) != 1 {
+				continue
+			}
+			aliases = append(aliases, Alias{From: olds[0], To: news[0], Reason: reason})
+			matched[olds[0]], matched[news[0]] = true, true
+		}
+		removed = without(removed, matched)
+		added = without(added, matched)
+	}
+	match("parent changed", func(u resource.URN) string { return string(u.Type()) + "::" + u.Name() })
+	match("type changed", func(u resource.URN) string { return u.Name() })
+	match("name changed", func(u resource.URN) string { return string(u.QualifiedType()) })
+
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].To < aliases[j].To })
+	return aliases
+}
+
+func groupBy(urns []resource.URN, key func(resource.URN) string) map[string][]resource.URN {
+	out := map[string][]resource.URN{}
+	for _, u := range urns {
+		out[key(u)] = append(out[key(u)], u)
+	}
+	return out
+}
+
+func without(urns []resource.URN, drop map[resource.URN]bool) []resource.URN {
+	var out []resource.URN
+	for _, u := range urns {
+		if !drop[u] {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// Ack acknowledges URN changes that are expected, e.g. because the release notes tell users to add the proposed
+// aliases. Entries are Keys, so the file does not depend on the stack the test ran in.
+type Ack struct {
+	Reason  string   `json:"reason"`
+	Removed []string `json:"removed,omitempty"`
+	Added   []string `json:"added,omitempty"`
+}
+
+// LoadAck reads an acknowledgement file. A missing file acknowledges nothing.
+func LoadAck(path string) (*Ack, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Ack{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ack Ack
+	if err := json.Unmarshal(data, &ack); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &ack, nil
+}
+
+// Unacknowledged returns the part of the diff that the acknowledgement file does not cover.
+func (a *Ack) Unacknowledged(d Diff) Diff {
+	removed := toSet(a.Removed)
+	added := toSet(a.Added)
+	out := Diff{Removed: []resource.URN{}, Added: []resource.URN{}}
+	for _, u := range d.Removed {
+		if !removed[Key(u)] {
+			out.Removed = append(out.Removed, u)
+		}
+	}
+	for _, u := range d.Added {
+		if !added[Key(u)] {
+			out.Added = append(out.Added, u)
+		}
+	}
+	return out
+}
+
+// AckFor returns an acknowledgement of every change in the diff, as a starting point for the file.
+func AckFor(d Diff, reason string) *Ack {
+	a := &Ack{Reason: reason}
+	for _, u := range d.Removed {
+		a.Removed = append(a.Removed, Key(u))
+	}
+	for _, u := range d.Added {
+		a.Added = append(a.Added, Key(u))
+	}
+	return a
+}
+
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
+
+// WriteReport writes a human readable summary of the diff and the proposed aliases.
+func WriteReport(w io.Writer, d Diff, aliases []Alias, unacked Diff) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("%d URNs removed, %d URNs added\n", len(d.Removed), len(d.Added))
+	for _, u := range d.Removed {
+		printf("  - %s\n", u)
+	}
+	for _, u := range d.Added {
+		printf("  + %s\n", u)
+	}
+	if len(aliases) > 0 {
+		printf("proposed aliases:\n")
+		for _, a := range aliases {
+			printf("  %s (%s)\n    aliases: [%q]\n", a.To, a.Reason, a.From)
+		}
+	}
+	if !unacked.Empty() {
+		printf("%d changes are not acknowledged\n", len(unacked.Removed)+len(unacked.Added))
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package urns
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	app       = resource.URN("urn:pulumi:dev::proj::cdk:index:App::app")
+	stack     = resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:teststack::app/teststack")
+	bucket    = resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:teststack$aws-native:s3:Bucket::bucket")
+	movedTo   = resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:Stack$aws-native:s3:Bucket::bucket")
+	retyped   = resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:teststack$aws:s3:Bucket::bucket")
+	renamed   = resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:teststack$aws-native:s3:Bucket::bucket2")
+	otherApp  = resource.URN("urn:pulumi:other::proj::cdk:index:App::app")
+	newBucket = resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:teststack$aws-native:sqs:Queue::queue")
+)
+
+func TestCompareIgnoresStack(t *testing.T) {
+	d := Compare([]resource.URN{app, stack, bucket}, []resource.URN{otherApp, stack, bucket})
+	assert.True(t, d.Empty())
+}
+
+func TestProposeAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		current resource.URN
+		reason  string
+	}{
+		{"parent", movedTo, "parent changed"},
+		{"type", retyped, "type changed"},
+		{"name", renamed, "name changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Compare([]resource.URN{app, stack, bucket}, []resource.URN{app, stack, tt.current})
+			assert.Equal(t, []resource.URN{bucket}, d.Removed)
+			assert.Equal(t, []resource.URN{tt.current}, d.Added)
+			assert.Equal(t, []Alias{{From: bucket, To: tt.current, Reason: tt.reason}}, ProposeAliases(d))
+		})
+	}
+}
+
+func TestProposeAliasesAmbiguous(t *testing.T) {
+	// Two new resources of the same type could each be the renamed bucket, so nothing is proposed.
+	another := resource.URN("urn:pulumi:dev::proj::cdk:index:App$cdk:construct:teststack$aws-native:s3:Bucket::b3")
+	d := Compare([]resource.URN{bucket}, []resource.URN{renamed, another, newBucket})
+	assert.Empty(t, ProposeAliases(d))
+}
+
+func TestAck(t *testing.T) {
+	d := Compare([]resource.URN{app, stack, bucket}, []resource.URN{app, stack, movedTo, newBucket})
+
+	ack, err := LoadAck(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	assert.Equal(t, d, ack.Unacknowledged(d))
+
+	path := filepath.Join(t.TempDir(), "ack.json")
+	data, err := json.Marshal(AckFor(Diff{Removed: d.Removed, Added: d.Added[:1]}, "moved stacks"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	ack, err = LoadAck(path)
+	require.NoError(t, err)
+	assert.Equal(t, "moved stacks", ack.Reason)
+	assert.Equal(t, Diff{Removed: []resource.URN{}, Added: []resource.URN{d.Added[1]}}, ack.Unacknowledged(d))
+}
+
+func TestWriteReport(t *testing.T) {
+	d := Compare([]resource.URN{bucket}, []resource.URN{movedTo})
+	var buf bytes.Buffer
+	require.NoError(t, WriteReport(&buf, d, ProposeAliases(d), d))
+	assert.Equal(t, `1 URNs removed, 1 URNs added
+  - `+string(bucket)+`
+  + `+string(movedTo)+`
+proposed aliases:
+  `+string(movedTo)+` (parent changed)
+    aliases: ["`+string(bucket)+`"]
+2 changes are not acknowledged
+`, buf.String())
+}
+
+func TestLoadPlan(t *testing.T) {
+	urns, err := LoadPlan("testdata/baseline.plan.json")
+	require.NoError(t, err)
+	assert.Equal(t, []resource.URN{bucket, stack, app}, urns)
+}
+
+func TestPinDependency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"name": "x", "dependencies": {"@pulumi/cdk": "1.11.0"}}`), 0o600))
+	require.NoError(t, PinDependency(path, "@pulumi/cdk", "1.9.0"))
+
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &pkg))
+	assert.Equal(t, map[string]string{"@pulumi/cdk": "1.9.0"}, pkg.Dependencies)
+}
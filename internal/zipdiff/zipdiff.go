@@ -0,0 +1,272 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zipdiff compares the zip archives that the synthesizer builds for ZIP_DIRECTORY file assets with
+// zipDirectory in src/zip.ts. The archive is uploaded under the hash of the source directory, but the Lambda functions
+// and other resources that reference it see the bytes of the archive: an archive that is not byte-identical across
+// synth runs, e.g. because it records when or where it was built, updates those resources on every `pulumi up`.
+// Compare and CompareAssemblies report what differs entry by entry, so that the cause is named instead of only
+// detected.
+package zipdiff
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pulumi/pulumi-cdk/internal/assets"
+)
+
+// Field names what differs between two archives.
+type Field string
+
+const (
+	// FieldMissing is an entry, or an asset, that is only in one of the two.
+	FieldMissing Field = "missing"
+	// FieldOrder is the order of the entries.
+	FieldOrder    Field = "order"
+	FieldModified Field = "modified"
+	FieldMode     Field = "mode"
+	FieldContent  Field = "content"
+	FieldMethod   Field = "method"
+	FieldExtra    Field = "extra"
+	FieldComment  Field = "comment"
+	// FieldBytes is a difference outside of the entries, e.g. in the headers the writer chose, which is reported
+	// when the archives differ although every entry is the same.
+	FieldBytes Field = "bytes"
+)
+
+// Difference is one way in which two archives differ.
+type Difference struct {
+	// Archive is the asset ID when comparing assemblies, and empty otherwise.
+	Archive string `json:"archive,omitempty"`
+	// Entry is the name of the entry, empty for differences of the whole archive.
+	Entry string `json:"entry,omitempty"`
+	Field Field  `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+func (d Difference) String() string {
+	where := d.Entry
+	if d.Archive != "" {
+		where = d.Archive + ":" + d.Entry
+	}
+	if where == "" {
+		where = "archive"
+	}
+	return fmt.Sprintf("%s: [%s] %s != %s", where, d.Field, d.A, d.B)
+}
+
+// Compare compares the zip archives at the two paths. Byte-identical archives have no differences.
+func Compare(a, b string) ([]Difference, error) {
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return nil, err
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(dataA, dataB) {
+		return nil, nil
+	}
+	zipA, err := zip.NewReader(bytes.NewReader(dataA), int64(len(dataA)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a, err)
+	}
+	zipB, err := zip.NewReader(bytes.NewReader(dataB), int64(len(dataB)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b, err)
+	}
+
+	diffs := compareEntries(zipA.File, zipB.File)
+	if zipA.Comment != zipB.Comment {
+		diffs = append(diffs, Difference{Field: FieldComment, A: strconv.Quote(zipA.Comment),
+			B: strconv.Quote(zipB.Comment)})
+	}
+	if len(diffs) == 0 {
+		diffs = append(diffs, Difference{Field: FieldBytes, A: fmt.Sprintf("%d bytes", len(dataA)),
+			B: fmt.Sprintf("%d bytes", len(dataB))})
+	}
+	return diffs, nil
+}
+
+func compareEntries(a, b []*zip.File) []Difference {
+	var diffs []Difference
+	namesA, namesB := names(a), names(b)
+	byNameB := map[string]*zip.File{}
+	for _, f := range b {
+		byNameB[f.Name] = f
+	}
+	var orderA []string
+	for _, f := range a {
+		g, ok := byNameB[f.Name]
+		if !ok {
+			diffs = append(diffs, Difference{Entry: f.Name, Field: FieldMissing, A: "present", B: "absent"})
+			continue
+		}
+		orderA = append(orderA, f.Name)
+		diffs = append(diffs, compareEntry(f, g)...)
+	}
+	for _, name := range namesB {
+		if !slices.Contains(namesA, name) {
+			diffs = append(diffs, Difference{Entry: name, Field: FieldMissing, A: "absent", B: "present"})
+		}
+	}
+	// The order only matters for the entries in both; the missing ones are reported already.
+	orderB := slices.DeleteFunc(slices.Clone(namesB), func(name string) bool { return !slices.Contains(orderA, name) })
+	if !slices.Equal(orderA, orderB) {
+		diffs = append(diffs, Difference{Field: FieldOrder, A: fmt.Sprint(orderA), B: fmt.Sprint(orderB)})
+	}
+	return diffs
+}
+
+func names(files []*zip.File) []string {
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f.Name
+	}
+	return out
+}
+
+func compareEntry(a, b *zip.File) []Difference {
+	var diffs []Difference
+	add := func(field Field, x, y string) {
+		if x != y {
+			diffs = append(diffs, Difference{Entry: a.Name, Field: field, A: x, B: y})
+		}
+	}
+	add(FieldModified, modified(a), modified(b))
+	add(FieldMode, mode(a), mode(b))
+	add(FieldContent, content(a), content(b))
+	add(FieldMethod, strconv.Itoa(int(a.Method)), strconv.Itoa(int(b.Method)))
+	add(FieldExtra, extra(a), extra(b))
+	add(FieldComment, strconv.Quote(a.Comment), strconv.Quote(b.Comment))
+	return diffs
+}
+
+// modified is the modification time as the entry records it, which is the MS-DOS time unless an extra field has a
+// more precise one.
+func modified(f *zip.File) string {
+	return f.Modified.UTC().Format(time.RFC3339Nano)
+}
+
+// mode is the file mode, with the creator system it was recorded for, since a zip from Windows has no Unix modes.
+func mode(f *zip.File) string {
+	return fmt.Sprintf("%s (creator %d)", f.Mode(), f.CreatorVersion>>8)
+}
+
+// Header IDs of the extra fields that only hold times, which modified already compares.
+const (
+	extraNTFS              = 0x000a
+	extraExtendedTimestamp = 0x5455
+)
+
+// extra is the extra data of the entry without the fields that only hold times.
+func extra(f *zip.File) string {
+	var out []byte
+	for b := f.Extra; len(b) >= 4; {
+		id := binary.LittleEndian.Uint16(b)
+		size := 4 + int(binary.LittleEndian.Uint16(b[2:]))
+		if size > len(b) {
+			// Malformed; compare the rest as is.
+			out = append(out, b...)
+			break
+		}
+		if id != extraNTFS && id != extraExtendedTimestamp {
+			out = append(out, b[:size]...)
+		}
+		b = b[size:]
+	}
+	return hex.EncodeToString(out)
+}
+
+func content(f *zip.File) string {
+	return fmt.Sprintf("crc32 %08x, %d bytes", f.CRC32, f.UncompressedSize64)
+}
+
+// CompareAssemblies compares the zip archives of the ZIP_DIRECTORY assets of two cloud assemblies of the same
+// program, pairing them by asset ID. Since the ID is the hash of the source directory, an asset that is only in one
+// of them means the directory itself differed.
+func CompareAssemblies(a, b string) ([]Difference, error) {
+	zipsA, err := zipAssets(a)
+	if err != nil {
+		return nil, err
+	}
+	zipsB, err := zipAssets(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []Difference
+	for _, id := range sortedKeys(zipsA) {
+		pathB, ok := zipsB[id]
+		if !ok {
+			diffs = append(diffs, Difference{Archive: id, Field: FieldMissing, A: "present", B: "absent"})
+			continue
+		}
+		d, err := Compare(zipsA[id], pathB)
+		if err != nil {
+			return nil, err
+		}
+		for _, diff := range d {
+			diff.Archive = id
+			diffs = append(diffs, diff)
+		}
+	}
+	for _, id := range sortedKeys(zipsB) {
+		if _, ok := zipsA[id]; !ok {
+			diffs = append(diffs, Difference{Archive: id, Field: FieldMissing, A: "absent", B: "present"})
+		}
+	}
+	return diffs, nil
+}
+
+// ZipAssets returns the number of ZIP_DIRECTORY assets in the cloud assembly in dir.
+func ZipAssets(dir string) (int, error) {
+	zips, err := zipAssets(dir)
+	return len(zips), err
+}
+
+// zipAssets returns the paths of the archives of the ZIP_DIRECTORY assets by asset ID.
+func zipAssets(dir string) (map[string]string, error) {
+	e, err := assets.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	zips := map[string]string{}
+	for _, f := range e.Files {
+		if f.Packaging == assets.PackagingZip {
+			zips[f.ID] = filepath.Join(dir, f.Path+".zip")
+		}
+	}
+	return zips, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
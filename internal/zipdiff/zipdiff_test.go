@@ -0,0 +1,187 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipdiff
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// entry is a file in a test archive; the zero value is what zipDirectory writes for an empty file.
+type entry struct {
+	name    string
+	content string
+	mode    os.FileMode
+	date    time.Time
+	store   bool
+}
+
+var epoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func writeZip(t *testing.T, path string, entries ...entry) string {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: epoch}
+		if e.store {
+			h.Method = zip.Store
+		}
+		if !e.date.IsZero() {
+			h.Modified = e.date
+		}
+		mode := e.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		h.SetMode(mode)
+		fw, err := w.CreateHeader(h)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return path
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	base := []entry{{name: "index.js", content: "exports.handler = 1"}, {name: "lib/util.js", content: "util"}}
+	a := writeZip(t, filepath.Join(dir, "a.zip"), base...)
+
+	t.Run("identical", func(t *testing.T) {
+		diffs, err := Compare(a, writeZip(t, filepath.Join(dir, "same.zip"), base...))
+		require.NoError(t, err)
+		assert.Empty(t, diffs)
+	})
+
+	tests := []struct {
+		name    string
+		entries []entry
+		want    []string
+	}{
+		{
+			name:    "order",
+			entries: []entry{base[1], base[0]},
+			want:    []string{"archive: [order] [index.js lib/util.js] != [lib/util.js index.js]"},
+		},
+		{
+			name: "mtime",
+			entries: []entry{
+				{name: "index.js", content: "exports.handler = 1", date: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+				base[1],
+			},
+			want: []string{"index.js: [modified] 1980-01-01T00:00:00Z != 2026-10-18T12:00:00Z"},
+		},
+		{
+			name:    "mode",
+			entries: []entry{{name: "index.js", content: "exports.handler = 1", mode: 0o755}, base[1]},
+			want:    []string{"index.js: [mode] -rw-r--r-- (creator 3) != -rwxr-xr-x (creator 3)"},
+		},
+		{
+			name:    "content and method",
+			entries: []entry{base[0], {name: "lib/util.js", content: "util2", store: true}},
+			want: []string{
+				"lib/util.js: [content] crc32 de66b2a3, 4 bytes != crc32 55d42aed, 5 bytes",
+				"lib/util.js: [method] 8 != 0",
+			},
+		},
+		{
+			name:    "missing",
+			entries: []entry{base[0], {name: "lib/other.js", content: "util"}},
+			want: []string{
+				"lib/util.js: [missing] present != absent",
+				"lib/other.js: [missing] absent != present",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Compare(a, writeZip(t, filepath.Join(t.TempDir(), "b.zip"), tt.entries...))
+			require.NoError(t, err)
+			var got []string
+			for _, d := range diffs {
+				got = append(got, d.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("not a zip", func(t *testing.T) {
+		path := filepath.Join(dir, "not.zip")
+		require.NoError(t, os.WriteFile(path, []byte("nope"), 0o600))
+		_, err := Compare(a, path)
+		assert.Error(t, err)
+	})
+}
+
+// writeAssembly writes a cloud assembly whose asset manifest has the ZIP_DIRECTORY assets with the given IDs, and a
+// file asset, which is not compared.
+func writeAssembly(t *testing.T, dir string, zips map[string][]entry) string {
+	files := `"file": {"source": {"path": "asset.file.txt", "packaging": "file"},
+		"destinations": {"d": {"objectKey": "file.txt"}}}`
+	for id, entries := range zips {
+		files += `, "` + id + `": {"source": {"path": "asset.` + id + `", "packaging": "zip"},
+			"destinations": {"d": {"objectKey": "` + id + `.zip"}}}`
+		writeZip(t, filepath.Join(dir, "asset."+id+".zip"), entries...)
+	}
+	for path, content := range map[string]string{
+		"manifest.json": `{"version": "36.0.0", "artifacts": {
+			"stack.assets": {"type": "cdk:asset-manifest", "properties": {"file": "stack.assets.json"}},
+			"stack": {"type": "aws:cloudformation:stack", "properties": {"templateFile": "stack.template.json"}}}}`,
+		"tree.json":           `{"tree": {"id": "App", "path": "", "children": {"stack": {"id": "stack", "path": "stack"}}}}`,
+		"stack.template.json": `{"Resources": {}}`,
+		"stack.assets.json":   `{"version": "36.0.0", "files": {` + files + `}}`,
+		"asset.file.txt":      "not a zip",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestCompareAssemblies(t *testing.T) {
+	handler := []entry{{name: "index.js", content: "exports.handler = 1"}}
+	a := writeAssembly(t, t.TempDir(), map[string][]entry{"aaa": handler, "bbb": handler})
+	n, err := ZipAssets(a)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	diffs, err := CompareAssemblies(a, writeAssembly(t, t.TempDir(), map[string][]entry{"aaa": handler, "bbb": handler}))
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	diffs, err = CompareAssemblies(a, writeAssembly(t, t.TempDir(), map[string][]entry{
+		"aaa": {{name: "index.js", content: "exports.handler = 1", mode: 0o600}},
+		"ccc": handler,
+	}))
+	require.NoError(t, err)
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.String())
+	}
+	assert.Equal(t, []string{
+		"aaa:index.js: [mode] -rw-r--r-- (creator 3) != -rw------- (creator 3)",
+		"bbb:: [missing] present != absent",
+		"ccc:: [missing] absent != present",
+	}, got)
+}
@@ -60,7 +60,12 @@ export function mapToCustomResource(
     return undefined;
 }
 
-function convertToCustomTimeouts(seconds?: number): pulumi.CustomTimeouts | undefined {
+/**
+ * Converts the ServiceTimeout of a custom resource, in seconds, to the customTimeouts of all its operations.
+ *
+ * @internal
+ */
+export function convertToCustomTimeouts(seconds?: number): pulumi.CustomTimeouts | undefined {
     if (seconds === undefined) {
         return undefined;
     }
@@ -201,7 +201,9 @@ export class App extends pulumi.ComponentResource<AppResource> implements AppCom
             {
                 contextStore: new CdkAppMultiContext(rootDir),
                 lookups,
-                outdir: this.appProps?.outdir,
+                // PULUMI_CDK_OUTDIR keeps the cloud assembly around for inspection instead of synthesizing into a
+                // temporary directory, see "Inspecting the Cloud Assembly" in the README.
+                outdir: this.appProps?.outdir ?? process.env.PULUMI_CDK_OUTDIR,
                 loadAssemblyOptions: {
                     checkVersion: false,
                 },
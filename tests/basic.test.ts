@@ -11,6 +11,9 @@
 // WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 // See the License for the specific language governing permissions and
 // limitations under the License.
+import * as fs from 'fs';
+import * as os from 'os';
+import * as path from 'path';
 import * as pulumi from '@pulumi/pulumi';
 import * as aws from '@pulumi/aws';
 import * as native from '@pulumi/aws-native';
@@ -274,3 +277,40 @@ describe('Stack environment validation', () => {
         expect(region).toContain('us-west-1');
     });
 });
+
+describe('Cloud assembly directory', () => {
+    let outdir: string;
+    beforeEach(() => {
+        outdir = fs.mkdtempSync(path.join(os.tmpdir(), 'pulumi-cdk-outdir-'));
+        process.env.PULUMI_CDK_OUTDIR = outdir;
+    });
+    afterEach(() => {
+        delete process.env.PULUMI_CDK_OUTDIR;
+        fs.rmSync(outdir, { recursive: true, force: true });
+    });
+
+    test('PULUMI_CDK_OUTDIR keeps the cloud assembly', async () => {
+        setMocks();
+        await testApp((scope: Construct) => {
+            new s3.Bucket(scope, 'MyFirstBucket');
+        });
+        expect(fs.existsSync(path.join(outdir, 'manifest.json'))).toBe(true);
+    });
+
+    test('outdir in the app props takes precedence', async () => {
+        setMocks();
+        const propsOutdir = fs.mkdtempSync(path.join(os.tmpdir(), 'pulumi-cdk-props-outdir-'));
+        try {
+            await testApp(
+                (scope: Construct) => {
+                    new s3.Bucket(scope, 'MyFirstBucket');
+                },
+                { appOptions: { props: { outdir: propsOutdir } } },
+            );
+            expect(fs.existsSync(path.join(propsOutdir, 'manifest.json'))).toBe(true);
+            expect(fs.existsSync(path.join(outdir, 'manifest.json'))).toBe(false);
+        } finally {
+            fs.rmSync(propsOutdir, { recursive: true, force: true });
+        }
+    });
+});
@@ -0,0 +1,71 @@
+// Copyright 2016-2026, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as fs from 'fs';
+import * as path from 'path';
+import { moduleName, toSdkName, typeToken } from '../src/naming';
+import { parseSub } from '../src/sub';
+import { makeUniqueId } from '../src/cdk-logical-id';
+import { convertToCustomTimeouts } from '../src/custom-resource-mapping';
+
+// The same corpus is checked by the Go runner in internal/conformance; keep the version in sync with it.
+const CORPUS_VERSION = 1;
+
+type Case<In, Out> = {
+    input: In;
+    expected?: Out;
+    error?: string;
+};
+
+const corpus = JSON.parse(fs.readFileSync(path.join(__dirname, 'test-data', 'conformance', 'corpus.json'), 'utf-8'));
+
+function runCases<In, Out>(cases: Case<In, Out>[], fn: (input: In) => Out) {
+    for (const c of cases) {
+        if (c.error !== undefined) {
+            expect(() => fn(c.input)).toThrow(c.error);
+        } else {
+            expect(fn(c.input)).toEqual(c.expected);
+        }
+    }
+}
+
+describe('Conformance corpus', () => {
+    test('has the expected version', () => {
+        expect(corpus.version).toEqual(CORPUS_VERSION);
+    });
+
+    test('typeToken', () => {
+        runCases(corpus.typeToken, typeToken);
+    });
+
+    test('moduleName', () => {
+        runCases(corpus.moduleName, moduleName);
+    });
+
+    test('toSdkName', () => {
+        runCases(corpus.toSdkName, toSdkName);
+    });
+
+    test('parseSub', () => {
+        runCases(corpus.parseSub, parseSub);
+    });
+
+    test('makeUniqueId', () => {
+        runCases(corpus.makeUniqueId, makeUniqueId);
+    });
+
+    test('customTimeouts', () => {
+        runCases(corpus.customTimeouts, (props: any) => convertToCustomTimeouts(props.ServiceTimeout));
+    });
+});